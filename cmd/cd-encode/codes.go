@@ -0,0 +1,49 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// discCodes holds the identifiers cd-rip read from the disc's subchannel
+type discCodes struct {
+	MCN   string         // Media catalog number (UPC/EAN)
+	ISRCs map[int]string // ISRC by track number
+}
+
+// loadDiscCodes reads the MCN and ISRCs from the toc.json in dir.
+// A rip without toc.json has no codes.
+func loadDiscCodes(dir string) (discCodes, error) {
+	codes := discCodes{ISRCs: make(map[int]string)}
+
+	data, err := os.ReadFile(filepath.Join(dir, "toc.json"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return codes, nil
+	}
+	if err != nil {
+		return codes, fmt.Errorf("read toc.json: %w", err)
+	}
+
+	var toc struct {
+		MCN    string `json:"mcn"`
+		Tracks []struct {
+			Num  int    `json:"num"`
+			ISRC string `json:"isrc"`
+		} `json:"tracks"`
+	}
+	if err := json.Unmarshal(data, &toc); err != nil {
+		return codes, fmt.Errorf("parse toc.json: %w", err)
+	}
+
+	codes.MCN = toc.MCN
+	for _, t := range toc.Tracks {
+		if t.ISRC != "" {
+			codes.ISRCs[t.Num] = t.ISRC
+		}
+	}
+	return codes, nil
+}
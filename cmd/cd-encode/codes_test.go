@@ -0,0 +1,46 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestLoadDiscCodes(t *testing.T) {
+	dir := t.TempDir()
+	toc := `{
+  "first_track": 1,
+  "last_track": 2,
+  "tracks": [
+    {"num": 1, "lba": 0, "type": "audio", "isrc": "USEE10001993"},
+    {"num": 2, "lba": 1000, "type": "audio"}
+  ],
+  "leadout_lba": 2000,
+  "read_offset": 6,
+  "mcn": "0724384260729"
+}`
+	if err := os.WriteFile(filepath.Join(dir, "toc.json"), []byte(toc), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	codes, err := loadDiscCodes(dir)
+	if err != nil {
+		t.Fatalf("loadDiscCodes error: %v", err)
+	}
+	if codes.MCN != "0724384260729" {
+		t.Errorf("MCN = %q, want 0724384260729", codes.MCN)
+	}
+	if len(codes.ISRCs) != 1 || codes.ISRCs[1] != "USEE10001993" {
+		t.Errorf("ISRCs = %v, want track 1 only", codes.ISRCs)
+	}
+}
+
+func TestLoadDiscCodes_NoTOC(t *testing.T) {
+	codes, err := loadDiscCodes(t.TempDir())
+	if err != nil {
+		t.Fatalf("loadDiscCodes error: %v", err)
+	}
+	if codes.MCN != "" || len(codes.ISRCs) != 0 {
+		t.Errorf("codes = %+v, want none", codes)
+	}
+}
@@ -6,11 +6,14 @@ import (
 	"fmt"
 	"os"
 	"path/filepath"
+	"runtime"
+	"slices"
 	"sort"
 	"strconv"
 	"strings"
 
 	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/loudness"
 	"github.com/binaryphile/crostini-cd-rip/internal/metadata"
 	"github.com/binaryphile/crostini-cd-rip/internal/musicbrainz"
 )
@@ -23,14 +26,25 @@ const (
 
 func main() {
 	// Parse flags
-	quality := flag.Int("q", 2, "LAME VBR quality (0-9, lower is better)")
-	flag.IntVar(quality, "quality", 2, "LAME VBR quality")
+	format := flag.String("format", "mp3", "Output format: "+strings.Join(encode.Formats(), ", "))
+
+	quality := flag.Int("q", 2, "VBR quality (0-9, lower is better; LAME -V scale, mapped for opus, ogg and m4a)")
+	flag.IntVar(quality, "quality", 2, "VBR quality")
+	bitrate := flag.Int("bitrate", 0, "Target kbps for opus, ogg and m4a (overrides -q)")
 
 	discIDFile := flag.String("discid", "", "Disc ID file (default: input-dir/discid.txt)")
 
 	search := flag.String("search", "", "Manual album search instead of disc ID")
 
 	dest := flag.String("dest", "", "Destination directory (default: ~/Music)")
+	template := flag.String("template", "", "File layout: preset ("+strings.Join(encode.Presets(), ", ")+") or template like {albumartist}/{album}/{track:02} {title} (default: from config, else "+encode.DefaultPreset+")")
+	charset := flag.String("charset", "", "File name characters: ascii (shell-safe) or unicode (any script; only filesystem-illegal characters replaced) (default: from config, else ascii)")
+	transliterate := flag.Bool("transliterate", false, "Write kana and Cyrillic in file names as Latin letters instead of dropping them")
+	configPath := flag.String("config", encode.DefaultConfigPath(), "Settings file (JSON)")
+
+	workers := flag.Int("j", runtime.NumCPU(), "Tracks to encode at once")
+
+	replayGain := flag.Bool("replaygain", true, "Write ReplayGain 2.0 track and album gain tags")
 
 	dryRun := flag.Bool("dry-run", false, "Show what would be done")
 
@@ -42,7 +56,7 @@ func main() {
 
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, "Usage: %s [flags] <input-dir>\n\n", os.Args[0])
-		fmt.Fprintf(os.Stderr, "Encode WAV files to MP3 with MusicBrainz metadata.\n\n")
+		fmt.Fprintf(os.Stderr, "Encode WAV files to MP3, FLAC, Opus, Ogg Vorbis or M4A with MusicBrainz metadata.\n\n")
 		fmt.Fprintf(os.Stderr, "Flags:\n")
 		flag.PrintDefaults()
 	}
@@ -56,24 +70,53 @@ func main() {
 
 	inputDir := flag.Arg(0)
 
+	encoder, err := encode.EncoderFor(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	config, err := encode.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	naming, err := config.Naming(encode.Config{Template: *template, Charset: *charset, Transliterate: *transliterate})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Validate input directory
 	if _, err := os.Stat(inputDir); err != nil {
 		fmt.Fprintf(os.Stderr, "Error: input directory not found: %s\n", inputDir)
 		os.Exit(1)
 	}
 
+	// Split a single-image rip into track files
+	image, splitTracks, err := splitImage(inputDir, *dryRun)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error splitting disc image: %v\n", err)
+		os.Exit(1)
+	}
+
 	// Find WAV files
-	wavFiles, err := findWAVFiles(inputDir)
+	wavFiles, err := findWAVFiles(inputDir, image)
 	if err != nil {
 		fmt.Fprintf(os.Stderr, "Error finding WAV files: %v\n", err)
 		os.Exit(1)
 	}
+	if *dryRun && image != "" {
+		// Not split yet: list the tracks the split would write
+		wavFiles = slices.DeleteFunc(splitTracks, func(p string) bool {
+			return strings.EqualFold(filepath.Base(p), "track00.wav")
+		})
+	}
 	if len(wavFiles) == 0 {
 		fmt.Fprintln(os.Stderr, "No WAV files found in input directory")
 		os.Exit(1)
 	}
 
-	fmt.Printf("cd-encode - MusicBrainz lookup + lame encoding + ID3 tagging\n")
+	fmt.Printf("cd-encode - MusicBrainz lookup + encoding + tagging\n")
 	fmt.Println(strings.Repeat("=", 60))
 	fmt.Printf("Input: %s (%d WAV files)\n", inputDir, len(wavFiles))
 
@@ -94,9 +137,18 @@ func main() {
 		fmt.Printf("Disc ID: %s\n\n", discID)
 	}
 
-	// Check lame
-	if !encode.LameAvailable() {
-		fmt.Fprintln(os.Stderr, "Error: lame not found. Install with: nix-shell -p lame")
+	// Catalog number and ISRCs read by cd-rip
+	codes, err := loadDiscCodes(inputDir)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	if codes.MCN != "" {
+		fmt.Printf("Catalog number: %s\n", codes.MCN)
+	}
+
+	// Check the encoder
+	if !encode.Available(encoder) {
+		fmt.Fprintf(os.Stderr, "Error: %s not found. Install with: %s\n", encoder.Tool(), encode.InstallHint(encoder))
 		os.Exit(1)
 	}
 
@@ -160,68 +212,94 @@ func main() {
 			fmt.Println("Looking up on MusicBrainz...")
 			releases, err = client.LookupByDiscID(discID)
 		}
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "MusicBrainz lookup failed: %v\n", err)
-			os.Exit(1)
-		}
 
-		if len(releases) == 0 {
-			fmt.Fprintln(os.Stderr, "No releases found. Try --search \"Artist Album\" or --metadata file.json")
-			os.Exit(1)
-		}
+		// Fall back to the disc's CD-TEXT before giving up
+		if err != nil || len(releases) == 0 {
+			cdTextPath := filepath.Join(inputDir, "cdtext.json")
+			album, cdErr := metadata.ParseCDText(cdTextPath)
+			if cdErr != nil {
+				if err != nil {
+					fmt.Fprintf(os.Stderr, "MusicBrainz lookup failed: %v\n", err)
+				} else {
+					fmt.Fprintln(os.Stderr, "No releases found. Try --search \"Artist Album\" or --metadata file.json")
+				}
+				os.Exit(1)
+			}
+			if err != nil {
+				fmt.Printf("MusicBrainz lookup failed: %v\n", err)
+			} else {
+				fmt.Println("No releases found on MusicBrainz")
+			}
+			fmt.Printf("Using CD-TEXT from: %s\n", cdTextPath)
 
-		// Sort releases: exact track count matches first, then by year (newest first)
-		releases = musicbrainz.SortReleasesByTrackMatch(releases, len(wavFiles))
+			if errs := album.Validate(len(wavFiles)); len(errs) > 0 {
+				for _, e := range errs {
+					fmt.Fprintf(os.Stderr, "Warning: %v\n", e)
+				}
+				if *strict {
+					fmt.Fprintln(os.Stderr, "Validation failed (--strict mode)")
+					os.Exit(1)
+				}
+			}
 
-		// Present options
-		var release *musicbrainz.Release
-		if len(releases) == 1 {
-			fmt.Printf("Found: %s - %s (%d, %d tracks)\n", releases[0].Artist, releases[0].Title, releases[0].Year, releases[0].TrackCount)
-			release = &releases[0]
+			fullRelease = album.ToRelease()
+			genre = album.Genre
+			fmt.Printf("Album: %s - %s\n", fullRelease.Artist, fullRelease.Title)
 		} else {
-			fmt.Printf("\nFound %d releases:\n", len(releases))
-			for i, r := range releases {
-				fmt.Printf("  %d. %s - %s (%d, %s, %d tracks)\n", i+1, r.Artist, r.Title, r.Year, r.Country, r.TrackCount)
-			}
-			fmt.Print("\nSelect release (1): ")
+			// Sort releases: barcode matches, then exact track count matches, then by year (newest first)
+			releases = musicbrainz.SortReleasesByTrackMatch(releases, len(wavFiles), codes.MCN)
+
+			// Present options
+			var release *musicbrainz.Release
+			if len(releases) == 1 {
+				fmt.Printf("Found: %s - %s (%d, %d tracks)\n", releases[0].Artist, releases[0].Title, releases[0].Year, releases[0].TrackCount)
+				release = &releases[0]
+			} else {
+				fmt.Printf("\nFound %d releases:\n", len(releases))
+				for i, r := range releases {
+					fmt.Printf("  %d. %s - %s (%d, %s, %d tracks)\n", i+1, r.Artist, r.Title, r.Year, r.Country, r.TrackCount)
+				}
+				fmt.Print("\nSelect release (1): ")
 
-			reader := bufio.NewReader(os.Stdin)
-			input, _ := reader.ReadString('\n')
-			input = strings.TrimSpace(input)
+				reader := bufio.NewReader(os.Stdin)
+				input, _ := reader.ReadString('\n')
+				input = strings.TrimSpace(input)
 
-			choice := 1
-			if input != "" {
-				if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(releases) {
-					choice = n
+				choice := 1
+				if input != "" {
+					if n, err := strconv.Atoi(input); err == nil && n >= 1 && n <= len(releases) {
+						choice = n
+					}
 				}
+				release = &releases[choice-1]
 			}
-			release = &releases[choice-1]
-		}
 
-		// Get full track info
-		fmt.Println("\nFetching track details...")
-		fullRelease, err = client.GetReleaseTracks(release.MBID)
-		if err != nil {
-			fmt.Fprintf(os.Stderr, "Failed to get track info: %v\n", err)
-			os.Exit(1)
-		}
+			// Get full track info
+			fmt.Println("\nFetching track details...")
+			fullRelease, err = client.GetReleaseTracks(release.MBID)
+			if err != nil {
+				fmt.Fprintf(os.Stderr, "Failed to get track info: %v\n", err)
+				os.Exit(1)
+			}
+			fullRelease.DiscID = release.DiscID
 
-		// Fetch cover art (optional)
-		fmt.Print("Fetching cover art... ")
-		coverArt, coverMIME, err = client.GetCoverArt(release.MBID)
-		if err != nil {
-			fmt.Printf("Warning: %v\n", err)
-			coverArt = nil // Ensure we continue without cover
-		} else if coverArt == nil {
-			fmt.Println("not available")
-		} else {
-			fmt.Printf("OK (%d KB, %s)\n", len(coverArt)/1024, coverMIME)
-		}
+			// Fetch cover art (optional)
+			fmt.Print("Fetching cover art... ")
+			coverArt, coverMIME, err = client.GetCoverArt(release.MBID)
+			if err != nil {
+				fmt.Printf("Warning: %v\n", err)
+				coverArt = nil // Ensure we continue without cover
+			} else if coverArt == nil {
+				fmt.Println("not available")
+			} else {
+				fmt.Printf("OK (%d KB, %s)\n", len(coverArt)/1024, coverMIME)
+			}
 
-		// Validate track count
-		if len(fullRelease.Tracks) != len(wavFiles) {
-			fmt.Fprintf(os.Stderr, "Warning: Track count mismatch (%d WAV files, %d tracks in release)\n",
-				len(wavFiles), len(fullRelease.Tracks))
+			// Validate track count
+			if len(fullRelease.Tracks) != len(wavFiles) {
+				fmt.Fprintf(os.Stderr, "Warning: Track count mismatch (%d WAV files, %d tracks in release)\n",
+					len(wavFiles), len(fullRelease.Tracks))
+			}
 		}
 
 		discNum = 0 // MusicBrainz doesn't tell us which disc we ripped
@@ -234,121 +312,73 @@ func main() {
 		destDir = filepath.Join(home, "Music")
 	}
 
-	fmt.Printf("\nDestination: %s\n", destDir)
-	fmt.Printf("Quality: V%d\n", *quality)
-
-	if *dryRun {
-		fmt.Println("\n[DRY RUN] Would encode:")
-	} else {
-		fmt.Println("\nEncoding:")
-	}
-
-	// Process each track
 	opts := encode.EncodeOptions{
 		Quality: *quality,
+		Bitrate: *bitrate,
 		Verbose: *verbose,
 	}
 
-	for i, wavFile := range wavFiles {
-		var trackNum int
-		var trackTitle, trackArtist string
-
-		if i < len(fullRelease.Tracks) {
-			track := fullRelease.Tracks[i]
-			trackNum = track.Num
-			trackTitle = track.Title
-			trackArtist = track.Artist
-		} else {
-			trackNum = i + 1
-			trackTitle = fmt.Sprintf("Track %d", trackNum)
-			trackArtist = fullRelease.Artist
-		}
-
-		// Generate filename
-		var filename string
-		if fullRelease.Compilation {
-			filename = encode.GenerateCompilationFilename(
-				fullRelease.Title,
-				discNum,
-				trackNum,
-				trackArtist,
-				trackTitle,
-			)
-		} else {
-			filename = encode.GenerateFilename(
-				fullRelease.Artist,
-				fullRelease.Title,
-				discNum,
-				trackNum,
-				trackTitle,
-			)
-		}
-
-		mp3Path := filepath.Join(destDir, filename)
+	fmt.Printf("\nDestination: %s\n", destDir)
+	fmt.Printf("Format: %s (%s)\n", encoder.Format(), encode.QualityLabel(encoder, opts))
+	if !*dryRun {
+		fmt.Printf("Workers: %d\n", max(*workers, 1))
+	}
 
-		if *dryRun {
-			fmt.Printf("  %s -> %s\n", filepath.Base(wavFile), filename)
-			continue
-		}
+	// Process each track
+	album := encode.Album{
+		Release:   fullRelease,
+		DiscNum:   discNum,
+		Genre:     genre,
+		ISRCs:     codes.ISRCs,
+		CoverArt:  coverArt,
+		CoverMIME: coverMIME,
+		Encoder:   encoder,
+		Naming:    &naming,
+	}
 
-		fmt.Printf("  %02d. %s... ", trackNum, trackTitle)
+	jobs := make([]encode.Job, len(wavFiles))
+	for i, wavFile := range wavFiles {
+		jobs[i] = album.TrackJob(i, wavFile, destDir)
+	}
 
-		// Create dest dir if needed
-		if err := os.MkdirAll(destDir, 0755); err != nil {
-			fmt.Printf("ERROR: %v\n", err)
-			continue
+	if *dryRun {
+		fmt.Println("\n[DRY RUN] Would encode:")
+		for _, job := range jobs {
+			rel, _ := filepath.Rel(destDir, job.Dest)
+			fmt.Printf("  %s -> %s\n", filepath.Base(job.WAV), rel)
 		}
+		return
+	}
 
-		// Encode
-		tempMP3 := filepath.Join(inputDir, fmt.Sprintf("track%02d.mp3", trackNum))
-		if err := encode.EncodeWAV(wavFile, tempMP3, opts); err != nil {
-			fmt.Printf("ENCODE ERROR: %v\n", err)
-			continue
+	// Album gain needs every track, so measure them all before encoding
+	if *replayGain {
+		fmt.Print("\nAnalyzing loudness... ")
+		if err := analyzeReplayGain(jobs, *workers, loudness.AnalyzeWAV); err != nil {
+			fmt.Printf("Warning: %v (no ReplayGain tags)\n", err)
+		} else {
+			fmt.Printf("OK (album gain %+.2f dB)\n", jobs[0].Tags.ReplayGain.AlbumGain)
 		}
+	}
 
-		// Tag
-		tags := encode.BuildTags(encode.TrackMeta{
-			Artist:       trackArtist,
-			AlbumArtist:  fullRelease.Artist,
-			Album:        fullRelease.Title,
-			Title:        trackTitle,
-			TrackNum:     trackNum,
-			TrackTotal:   len(fullRelease.Tracks),
-			DiscNum:      discNum,
-			DiscTotal:    fullRelease.DiscCount,
-			Year:         fullRelease.Year,
-			Genre:        genre,
-			Compilation:  fullRelease.Compilation,
-			CoverArt:     coverArt,
-			CoverArtMIME: coverMIME,
-		})
-
-		if err := tags.Apply(tempMP3); err != nil {
-			fmt.Printf("TAG ERROR: %v\n", err)
-			os.Remove(tempMP3)
-			continue
-		}
+	fmt.Println("\nEncoding:")
+	run := func(j encode.Job) error { return j.Run(opts) }
+	failures := encodeAll(jobs, *workers, run, os.Stdout)
 
-		// Move to destination
-		if err := os.Rename(tempMP3, mp3Path); err != nil {
-			// Try copy if cross-device
-			if data, err := os.ReadFile(tempMP3); err == nil {
-				if err := os.WriteFile(mp3Path, data, 0644); err == nil {
-					os.Remove(tempMP3)
-				}
-			}
+	fmt.Printf("\n%s\n", strings.Repeat("=", 60))
+	if len(failures) > 0 {
+		fmt.Printf("Encoded %d of %d tracks to %s\n", len(jobs)-len(failures), len(jobs), destDir)
+		fmt.Println("\nFailed:")
+		for _, f := range failures {
+			fmt.Printf("  %02d. %s: %v\n", f.Job.Tags.TrackNum, f.Job.Tags.Title, f.Err)
 		}
-
-		fmt.Println("OK")
-	}
-
-	if !*dryRun {
-		fmt.Printf("\n%s\n", strings.Repeat("=", 60))
-		fmt.Printf("Done! Encoded %d tracks to %s\n", len(wavFiles), destDir)
+		os.Exit(1)
 	}
+	fmt.Printf("Done! Encoded %d tracks to %s\n", len(jobs), destDir)
 }
 
-func findWAVFiles(dir string) ([]string, error) {
+// findWAVFiles lists the WAV files in dir in track order, leaving out the
+// named files.
+func findWAVFiles(dir string, exclude ...string) ([]string, error) {
 	entries, err := os.ReadDir(dir)
 	if err != nil {
 		return nil, err
@@ -359,6 +389,10 @@ func findWAVFiles(dir string) ([]string, error) {
 		if entry.IsDir() {
 			continue
 		}
+		// Hidden track one audio (cd-rip --htoa) isn't in the release's track list
+		if strings.EqualFold(entry.Name(), "track00.wav") || slices.Contains(exclude, entry.Name()) {
+			continue
+		}
 		if strings.HasSuffix(strings.ToLower(entry.Name()), ".wav") {
 			wavFiles = append(wavFiles, filepath.Join(dir, entry.Name()))
 		}
@@ -168,3 +168,20 @@ func TestMetadata_MalformedJSON(t *testing.T) {
 		t.Errorf("expected 'parse metadata' error message:\n%s", output)
 	}
 }
+
+func TestFindWAVFiles_SkipsHiddenTrack(t *testing.T) {
+	dir := t.TempDir()
+	for _, name := range []string{"track00.wav", "track02.wav", "track01.wav", "toc.json"} {
+		os.WriteFile(filepath.Join(dir, name), nil, 0644)
+	}
+
+	wavFiles, err := findWAVFiles(dir)
+	if err != nil {
+		t.Fatalf("findWAVFiles error: %v", err)
+	}
+
+	want := []string{filepath.Join(dir, "track01.wav"), filepath.Join(dir, "track02.wav")}
+	if strings.Join(wavFiles, ",") != strings.Join(want, ",") {
+		t.Errorf("findWAVFiles() = %v, want %v", wavFiles, want)
+	}
+}
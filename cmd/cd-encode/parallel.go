@@ -0,0 +1,55 @@
+package main
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+)
+
+// trackFailure is a track that failed to encode, tag or move
+type trackFailure struct {
+	Job encode.Job
+	Err error
+}
+
+// encodeAll runs jobs on up to workers workers. Progress is written to out
+// one whole line per track, in track order, as soon as each track and those
+// before it are done. Returns the failures in track order.
+func encodeAll(jobs []encode.Job, workers int, run func(encode.Job) error, out io.Writer) []trackFailure {
+	queue := make(chan encode.Job)
+	go func() {
+		defer close(queue)
+		for _, j := range jobs {
+			queue <- j
+		}
+	}()
+
+	index := make(map[string]int, len(jobs)) // By WAV path
+	for i, j := range jobs {
+		index[j.WAV] = i
+	}
+
+	// Hold results back until every earlier track has been reported
+	errs := make([]error, len(jobs))
+	done := make([]bool, len(jobs))
+	next := 0
+	var failures []trackFailure
+
+	for r := range encode.RunJobs(queue, workers, run) {
+		i := index[r.Job.WAV]
+		errs[i], done[i] = r.Err, true
+
+		for ; next < len(jobs) && done[next]; next++ {
+			j := jobs[next]
+			status := "OK"
+			if errs[next] != nil {
+				status = "FAILED"
+				failures = append(failures, trackFailure{Job: j, Err: errs[next]})
+			}
+			fmt.Fprintf(out, "  %02d. %s... %s\n", j.Tags.TrackNum, j.Tags.Title, status)
+		}
+	}
+
+	return failures
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+)
+
+func testJobs(n int) []encode.Job {
+	jobs := make([]encode.Job, n)
+	for i := range jobs {
+		jobs[i] = encode.Job{
+			WAV:  fmt.Sprintf("/rip/track%02d.wav", i+1),
+			Tags: encode.TagSet{TrackNum: i + 1, Title: fmt.Sprintf("Song %d", i+1)},
+		}
+	}
+	return jobs
+}
+
+func TestEncodeAll_OrderedOutput(t *testing.T) {
+	jobs := testJobs(5)
+
+	// Later tracks finish first
+	run := func(j encode.Job) error {
+		time.Sleep(time.Duration(len(jobs)-j.Tags.TrackNum) * 5 * time.Millisecond)
+		return nil
+	}
+
+	var out bytes.Buffer
+	failures := encodeAll(jobs, len(jobs), run, &out)
+	if len(failures) != 0 {
+		t.Fatalf("failures = %v, want none", failures)
+	}
+
+	got := strings.Split(strings.TrimSuffix(out.String(), "\n"), "\n")
+	if len(got) != len(jobs) {
+		t.Fatalf("got %d lines, want %d:\n%s", len(got), len(jobs), out.String())
+	}
+	for i, line := range got {
+		want := fmt.Sprintf("  %02d. Song %d... OK", i+1, i+1)
+		if line != want {
+			t.Errorf("line %d = %q, want %q", i, line, want)
+		}
+	}
+}
+
+func TestEncodeAll_CollectsFailures(t *testing.T) {
+	jobs := testJobs(4)
+
+	run := func(j encode.Job) error {
+		if j.Tags.TrackNum%2 == 0 {
+			return errors.New("lame failed")
+		}
+		return nil
+	}
+
+	var out bytes.Buffer
+	failures := encodeAll(jobs, 2, run, &out)
+
+	if len(failures) != 2 || failures[0].Job.Tags.TrackNum != 2 || failures[1].Job.Tags.TrackNum != 4 {
+		t.Fatalf("failures = %+v, want tracks 2 and 4", failures)
+	}
+	if failures[0].Err == nil || failures[0].Err.Error() != "lame failed" {
+		t.Errorf("failure error = %v", failures[0].Err)
+	}
+	if strings.Contains(out.String(), "lame failed") {
+		t.Errorf("errors should be reported at the end, not in progress:\n%s", out.String())
+	}
+	if !strings.Contains(out.String(), "  02. Song 2... FAILED\n") {
+		t.Errorf("progress should mark track 2 as failed:\n%s", out.String())
+	}
+}
+
+func TestEncodeAll_BoundedWorkers(t *testing.T) {
+	jobs := testJobs(8)
+
+	var running, peak atomic.Int32
+	run := func(j encode.Job) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(2 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	}
+
+	encodeAll(jobs, 3, run, &bytes.Buffer{})
+	if p := peak.Load(); p > 3 {
+		t.Errorf("%d tracks encoded at once, want at most 3", p)
+	}
+}
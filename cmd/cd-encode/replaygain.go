@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/loudness"
+)
+
+// analyzeReplayGain measures every job's WAV file on up to workers
+// workers and sets its ReplayGain tags. The album gain treats the jobs as
+// one album, so all tracks must be measured before any is encoded.
+func analyzeReplayGain(jobs []encode.Job, workers int, analyze func(string) (loudness.Result, error)) error {
+	results := make([]loudness.Result, len(jobs))
+	errs := make([]error, len(jobs))
+
+	sem := make(chan struct{}, max(workers, 1))
+	var wg sync.WaitGroup
+	for i, j := range jobs {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer func() { <-sem; wg.Done() }()
+			results[i], errs[i] = analyze(j.WAV)
+		}()
+	}
+	wg.Wait()
+
+	for i, err := range errs {
+		if err != nil {
+			return fmt.Errorf("track %d: %w", jobs[i].Tags.TrackNum, err)
+		}
+	}
+
+	album := loudness.Album(results)
+	for i, r := range results {
+		jobs[i].Tags.ReplayGain = &encode.ReplayGain{
+			TrackGain: r.ReplayGain(),
+			TrackPeak: r.Peak,
+			AlbumGain: album.ReplayGain(),
+			AlbumPeak: album.Peak,
+		}
+	}
+	return nil
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"encoding/binary"
+	"errors"
+	"math"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/loudness"
+)
+
+// squareWave measures a second of a 16-bit square wave at amplitude amp
+func squareWave(amp int16) loudness.Result {
+	pcm := make([]byte, 4*44100)
+	for i := 0; i < len(pcm); i += 4 {
+		s := amp
+		if (i/4/50)%2 == 1 { // 441 Hz
+			s = -amp
+		}
+		binary.LittleEndian.PutUint16(pcm[i:], uint16(s))
+		binary.LittleEndian.PutUint16(pcm[i+2:], uint16(s))
+	}
+	a := loudness.NewAnalyzer()
+	a.Write(pcm)
+	return a.Result()
+}
+
+func TestAnalyzeReplayGain(t *testing.T) {
+	jobs := testJobs(2)
+	levels := map[string]int16{jobs[0].WAV: 3000, jobs[1].WAV: 12000}
+	analyze := func(wav string) (loudness.Result, error) {
+		return squareWave(levels[wav]), nil
+	}
+
+	if err := analyzeReplayGain(jobs, 2, analyze); err != nil {
+		t.Fatalf("analyzeReplayGain error: %v", err)
+	}
+
+	quiet, loud := jobs[0].Tags.ReplayGain, jobs[1].Tags.ReplayGain
+	if quiet == nil || loud == nil {
+		t.Fatal("ReplayGain not set")
+	}
+	// A quarter of the amplitude is 12 dB quieter
+	if diff := quiet.TrackGain - loud.TrackGain; math.Abs(diff-12.04) > 0.1 {
+		t.Errorf("track gain difference = %.2f dB, want 12.04", diff)
+	}
+	if quiet.AlbumGain != loud.AlbumGain || quiet.AlbumPeak != loud.AlbumPeak {
+		t.Errorf("album values differ: %+v, %+v", *quiet, *loud)
+	}
+	if quiet.AlbumPeak != loud.TrackPeak {
+		t.Errorf("AlbumPeak = %f, want the loud track's %f", quiet.AlbumPeak, loud.TrackPeak)
+	}
+	if quiet.AlbumGain <= loud.TrackGain || quiet.AlbumGain >= quiet.TrackGain {
+		t.Errorf("AlbumGain = %.2f, want between the track gains", quiet.AlbumGain)
+	}
+}
+
+func TestAnalyzeReplayGain_Error(t *testing.T) {
+	jobs := testJobs(3)
+	analyze := func(wav string) (loudness.Result, error) {
+		if wav == jobs[1].WAV {
+			return loudness.Result{}, errors.New("bad WAV")
+		}
+		return squareWave(1000), nil
+	}
+
+	if err := analyzeReplayGain(jobs, 2, analyze); err == nil {
+		t.Fatal("analyzeReplayGain succeeded with a bad track")
+	}
+	for _, j := range jobs {
+		if j.Tags.ReplayGain != nil {
+			t.Errorf("track %d has ReplayGain after an error", j.Tags.TrackNum)
+		}
+	}
+}
@@ -0,0 +1,133 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// wavHeaderScan is how much of a WAV file is read to find its data chunk
+const wavHeaderScan = 64 * 1024
+
+// splitImage splits a single-image rip (cd-rip --image) into trackNN.wav
+// files beside it, using disc.cue. Pregaps stay at the end of the previous
+// track, and hidden track one audio goes to track00.wav as in a per-track
+// rip. Returns the image file name and the track files, or "" if dir
+// doesn't hold an image rip. The tracks are written under temporary names
+// and renamed once all are done; a failed split removes every track file
+// it wrote. With dryRun nothing is written.
+func splitImage(dir string, dryRun bool) (string, []string, error) {
+	data, err := os.ReadFile(filepath.Join(dir, "disc.cue"))
+	if errors.Is(err, fs.ErrNotExist) {
+		return "", nil, nil
+	}
+	if err != nil {
+		return "", nil, fmt.Errorf("read cue sheet: %w", err)
+	}
+
+	sheet, err := cdda.ParseCUE(data)
+	if err != nil {
+		return "", nil, err
+	}
+	// A one-track disc's per-track cue also has a single FILE: only the
+	// image cd-rip writes is split
+	image := sheet.ImageFile()
+	if image != cdda.ImageName {
+		return "", nil, nil
+	}
+
+	var tracks []string
+	hiddenStart, hiddenEnd, hidden := cdda.ImageHiddenRange(sheet)
+	if hidden {
+		tracks = append(tracks, filepath.Join(dir, "track00.wav"))
+	}
+	for _, t := range sheet.Tracks {
+		name := fmt.Sprintf("track%02d.wav", t.Num)
+		if name == image {
+			return "", nil, fmt.Errorf("track %d would overwrite the image %s", t.Num, image)
+		}
+		tracks = append(tracks, filepath.Join(dir, name))
+	}
+
+	f, err := os.Open(filepath.Join(dir, image))
+	if err != nil {
+		return "", nil, fmt.Errorf("open image: %w", err)
+	}
+	defer f.Close()
+
+	header := make([]byte, wavHeaderScan)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return "", nil, fmt.Errorf("read image: %w", err)
+	}
+	offset, size, err := cdda.ParseWAVHeader(header[:n])
+	if err != nil {
+		return "", nil, fmt.Errorf("%s: %w", image, err)
+	}
+
+	ranges, err := cdda.ImageTrackRanges(sheet, size/cdda.BytesPerFrame)
+	if err != nil {
+		return "", nil, err
+	}
+	if hidden {
+		ranges = append([][2]int{{hiddenStart, hiddenEnd}}, ranges...)
+	}
+
+	if dryRun {
+		fmt.Printf("Would split %s into %d tracks using disc.cue\n", image, len(ranges))
+		return image, tracks, nil
+	}
+
+	temps := make([]string, len(tracks))
+	for i, r := range ranges {
+		temps[i] = tracks[i] + ".split"
+		pcm := io.NewSectionReader(f, int64(offset+r[0]*cdda.BytesPerFrame), int64((r[1]-r[0])*cdda.BytesPerFrame))
+		if err := writeWAVFile(temps[i], pcm); err != nil {
+			removeAll(temps[:i+1])
+			return "", nil, fmt.Errorf("write %s: %w", filepath.Base(tracks[i]), err)
+		}
+	}
+	for i := range temps {
+		if err := os.Rename(temps[i], tracks[i]); err != nil {
+			removeAll(tracks[:i])
+			removeAll(temps[i:])
+			return "", nil, fmt.Errorf("write %s: %w", filepath.Base(tracks[i]), err)
+		}
+	}
+
+	fmt.Printf("Split %s into %d tracks using disc.cue\n", image, len(ranges))
+	return image, tracks, nil
+}
+
+// removeAll removes the files, ignoring errors
+func removeAll(paths []string) {
+	for _, p := range paths {
+		os.Remove(p)
+	}
+}
+
+// writeWAVFile streams the samples from pcm into a WAV file at path
+func writeWAVFile(path string, pcm io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+
+	wav, err := cdda.NewWAVWriter(out)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(wav, pcm); err != nil {
+		return err
+	}
+	if err := wav.Close(); err != nil {
+		return err
+	}
+	return out.Close()
+}
@@ -0,0 +1,173 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func TestSplitImage(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{
+		LeadoutLBA: 30,
+		Tracks: []cdda.Track{
+			{Num: 1, LBA: 0},
+			{Num: 2, LBA: 10, Pregap: 3},
+			{Num: 3, LBA: 25},
+		},
+	}
+
+	pcm := make([]byte, 30*cdda.BytesPerFrame)
+	for i := range pcm {
+		pcm[i] = byte(i / cdda.BytesPerFrame)
+	}
+	os.WriteFile(filepath.Join(dir, "disc.wav"), cdda.WriteWAV(pcm), 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{ImageFile: "disc.wav"}), 0644)
+
+	image, tracks, err := splitImage(dir, false)
+	if err != nil {
+		t.Fatalf("splitImage error: %v", err)
+	}
+	if image != "disc.wav" || len(tracks) != 3 {
+		t.Errorf("image = %q, tracks = %v, want disc.wav and 3 tracks", image, tracks)
+	}
+
+	bounds := [][2]int{{0, 10}, {10, 25}, {25, 30}}
+	for i, b := range bounds {
+		got, _ := os.ReadFile(filepath.Join(dir, fmt.Sprintf("track%02d.wav", i+1)))
+		if !bytes.Equal(got, cdda.WriteWAV(pcm[b[0]*cdda.BytesPerFrame:b[1]*cdda.BytesPerFrame])) {
+			t.Errorf("track %d should hold frames %d-%d", i+1, b[0], b[1])
+		}
+	}
+
+	wavFiles, _ := findWAVFiles(dir, image)
+	if len(wavFiles) != 3 {
+		t.Errorf("findWAVFiles() = %v, want the 3 split tracks", wavFiles)
+	}
+}
+
+func TestSplitImage_NoCue(t *testing.T) {
+	image, _, err := splitImage(t.TempDir(), false)
+	if err != nil || image != "" {
+		t.Errorf("splitImage() = %q, %v, want \"\", nil", image, err)
+	}
+}
+
+func TestSplitImage_PerTrackCue(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 20, Tracks: []cdda.Track{{Num: 1, LBA: 0}, {Num: 2, LBA: 10}}}
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{}), 0644)
+
+	image, _, err := splitImage(dir, false)
+	if err != nil || image != "" {
+		t.Errorf("splitImage() = %q, %v, want \"\", nil", image, err)
+	}
+}
+
+func TestSplitImage_OneTrackPerTrackCue(t *testing.T) {
+	// A one-track disc's cue has a single FILE, but it isn't an image
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 10, Tracks: []cdda.Track{{Num: 1, LBA: 0}}}
+	wav := cdda.WriteWAV(make([]byte, 10*cdda.BytesPerFrame))
+	os.WriteFile(filepath.Join(dir, "track01.wav"), wav, 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{}), 0644)
+
+	image, _, err := splitImage(dir, false)
+	if err != nil || image != "" {
+		t.Errorf("splitImage() = %q, %v, want \"\", nil", image, err)
+	}
+	if got, _ := os.ReadFile(filepath.Join(dir, "track01.wav")); !bytes.Equal(got, wav) {
+		t.Errorf("track01.wav changed: %d bytes, want %d", len(got), len(wav))
+	}
+}
+
+func TestSplitImage_DryRun(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 20, Tracks: []cdda.Track{{Num: 1, LBA: 0}, {Num: 2, LBA: 10}}}
+	os.WriteFile(filepath.Join(dir, "disc.wav"), cdda.WriteWAV(make([]byte, 20*cdda.BytesPerFrame)), 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{ImageFile: "disc.wav"}), 0644)
+
+	image, tracks, err := splitImage(dir, true)
+	if err != nil || image != "disc.wav" {
+		t.Fatalf("splitImage() = %q, %v, want disc.wav", image, err)
+	}
+	want := []string{filepath.Join(dir, "track01.wav"), filepath.Join(dir, "track02.wav")}
+	if !slices.Equal(tracks, want) {
+		t.Errorf("tracks = %v, want %v", tracks, want)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 2 {
+		t.Errorf("dry run wrote files: %v", entries)
+	}
+}
+
+func TestSplitImage_FailureLeavesNoTracks(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 20, Tracks: []cdda.Track{{Num: 1, LBA: 0}, {Num: 2, LBA: 10}}}
+	os.WriteFile(filepath.Join(dir, "disc.wav"), cdda.WriteWAV(make([]byte, 20*cdda.BytesPerFrame)), 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{ImageFile: "disc.wav"}), 0644)
+
+	// Track 2 can't be written after track 1 was
+	os.Mkdir(filepath.Join(dir, "track02.wav.split"), 0755)
+
+	if _, _, err := splitImage(dir, false); err == nil {
+		t.Fatal("splitImage succeeded with an unwritable track")
+	}
+	for _, name := range []string{"track01.wav", "track01.wav.split", "track02.wav"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("failed split left %s", name)
+		}
+	}
+}
+
+func TestSplitImage_HiddenTrack(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 25, Tracks: []cdda.Track{{Num: 1, LBA: 5, Pregap: 5}, {Num: 2, LBA: 15}}}
+
+	pcm := make([]byte, 25*cdda.BytesPerFrame)
+	for i := range pcm {
+		pcm[i] = byte(i / cdda.BytesPerFrame)
+	}
+	os.WriteFile(filepath.Join(dir, "disc.wav"), cdda.WriteWAV(pcm), 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{ImageFile: "disc.wav", HiddenTrack: true}), 0644)
+
+	_, tracks, err := splitImage(dir, false)
+	if err != nil {
+		t.Fatalf("splitImage error: %v", err)
+	}
+	if len(tracks) != 3 {
+		t.Fatalf("tracks = %v, want track00.wav and 2 tracks", tracks)
+	}
+
+	bounds := [][2]int{{0, 5}, {5, 15}, {15, 25}}
+	for i, b := range bounds {
+		got, _ := os.ReadFile(filepath.Join(dir, fmt.Sprintf("track%02d.wav", i)))
+		if !bytes.Equal(got, cdda.WriteWAV(pcm[b[0]*cdda.BytesPerFrame:b[1]*cdda.BytesPerFrame])) {
+			t.Errorf("track%02d.wav should hold frames %d-%d", i, b[0], b[1])
+		}
+	}
+}
+
+func TestSplitImage_RenameFailureLeavesNoTracks(t *testing.T) {
+	dir := t.TempDir()
+	toc := cdda.TOC{LeadoutLBA: 20, Tracks: []cdda.Track{{Num: 1, LBA: 0}, {Num: 2, LBA: 10}}}
+	os.WriteFile(filepath.Join(dir, "disc.wav"), cdda.WriteWAV(make([]byte, 20*cdda.BytesPerFrame)), 0644)
+	os.WriteFile(filepath.Join(dir, "disc.cue"), cdda.WriteCUE(toc, cdda.CUEOptions{ImageFile: "disc.wav"}), 0644)
+
+	// Track 2 can't be renamed into place after track 1 was
+	os.MkdirAll(filepath.Join(dir, "track02.wav", "x"), 0755)
+
+	if _, _, err := splitImage(dir, false); err == nil {
+		t.Fatal("splitImage succeeded with an unrenameable track")
+	}
+	for _, name := range []string{"track01.wav", "track01.wav.split", "track02.wav.split"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err == nil {
+			t.Errorf("failed split left %s", name)
+		}
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/metadata"
+	"github.com/binaryphile/crostini-cd-rip/internal/musicbrainz"
+)
+
+// lookupOptions says where album metadata comes from
+type lookupOptions struct {
+	DiscID       string
+	Search       string // Manual MusicBrainz search instead of the disc ID
+	MetadataFile string // JSON metadata file (bypasses MusicBrainz)
+	WorkDir      string // Holds cdtext.json, the fallback if MusicBrainz has nothing
+	TrackCount   int
+	MCN          string
+	ISRCs        map[int]string
+}
+
+// lookupAlbum finds the album's metadata like cd-encode does, without
+// prompting: when MusicBrainz returns several releases, the best match
+// (see musicbrainz.SortReleasesByTrackMatch) is used.
+func lookupAlbum(opts lookupOptions) (encode.Album, error) {
+	album := encode.Album{ISRCs: opts.ISRCs}
+
+	if opts.MetadataFile != "" {
+		m, err := metadata.ParseJSON(opts.MetadataFile)
+		if err != nil {
+			return album, err
+		}
+		for _, e := range m.Validate(opts.TrackCount) {
+			fmt.Printf("Metadata warning: %v\n", e)
+		}
+		album.Release, album.DiscNum, album.Genre = m.ToRelease(), m.Disc, m.Genre
+		if m.CoverArt != "" {
+			if album.CoverArt, album.CoverMIME, err = m.LoadCoverArt(); err != nil {
+				fmt.Printf("Metadata warning: %v\n", err)
+			}
+		}
+		fmt.Printf("Metadata: %s - %s (%s)\n", album.Release.Artist, album.Release.Title, opts.MetadataFile)
+		return album, nil
+	}
+
+	client := musicbrainz.NewClient(appName, appVersion, appURL)
+	defer client.Close()
+
+	var releases []musicbrainz.Release
+	var err error
+	if opts.Search != "" {
+		releases, err = client.Search(opts.Search)
+	} else {
+		releases, err = client.LookupByDiscID(opts.DiscID)
+	}
+
+	// Fall back to the disc's CD-TEXT before giving up
+	if err != nil || len(releases) == 0 {
+		m, cdErr := metadata.ParseCDText(filepath.Join(opts.WorkDir, "cdtext.json"))
+		if cdErr != nil {
+			if err != nil {
+				return album, fmt.Errorf("MusicBrainz lookup failed: %w", err)
+			}
+			return album, errors.New("no releases found on MusicBrainz")
+		}
+		album.Release, album.Genre = m.ToRelease(), m.Genre
+		fmt.Printf("Metadata: %s - %s (CD-TEXT)\n", album.Release.Artist, album.Release.Title)
+		return album, nil
+	}
+
+	releases = musicbrainz.SortReleasesByTrackMatch(releases, opts.TrackCount, opts.MCN)
+	best := releases[0]
+	if len(releases) > 1 {
+		fmt.Printf("Metadata: %d releases found, using the best match\n", len(releases))
+	}
+
+	album.Release, err = client.GetReleaseTracks(best.MBID)
+	if err != nil {
+		return album, fmt.Errorf("get track info: %w", err)
+	}
+	album.Release.DiscID = best.DiscID
+	if album.CoverArt, album.CoverMIME, err = client.GetCoverArt(best.MBID); err != nil {
+		fmt.Printf("Metadata warning: cover art: %v\n", err)
+		album.CoverArt = nil
+	}
+	if n := len(album.Release.Tracks); n != opts.TrackCount {
+		fmt.Printf("Metadata warning: track count mismatch (%d on disc, %d in release)\n", opts.TrackCount, n)
+	}
+
+	fmt.Printf("Metadata: %s - %s (%d, %s)\n", album.Release.Artist, album.Release.Title, album.Release.Year, best.Country)
+	return album, nil
+}
@@ -0,0 +1,282 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+	"github.com/google/gousb"
+)
+
+const (
+	appName    = "cd-pipeline"
+	appVersion = "1.0"
+	appURL     = "https://github.com/binaryphile/crostini-cd-rip"
+)
+
+func main() {
+	// Parse flags
+	format := flag.String("format", "mp3", "Output format: "+strings.Join(encode.Formats(), ", "))
+
+	quality := flag.Int("q", 2, "VBR quality (0-9, lower is better; LAME -V scale, mapped for opus, ogg and m4a)")
+	flag.IntVar(quality, "quality", 2, "VBR quality")
+	bitrate := flag.Int("bitrate", 0, "Target kbps for opus, ogg and m4a (overrides -q)")
+
+	dest := flag.String("dest", "", "Destination directory (default: ~/Music)")
+	template := flag.String("template", "", "File layout: preset ("+strings.Join(encode.Presets(), ", ")+") or template like {albumartist}/{album}/{track:02} {title} (default: from config, else "+encode.DefaultPreset+")")
+	charset := flag.String("charset", "", "File name characters: ascii (shell-safe) or unicode (any script; only filesystem-illegal characters replaced) (default: from config, else ascii)")
+	transliterate := flag.Bool("transliterate", false, "Write kana and Cyrillic in file names as Latin letters instead of dropping them")
+	configPath := flag.String("config", encode.DefaultConfigPath(), "Settings file (JSON)")
+	search := flag.String("search", "", "Manual album search instead of disc ID")
+	metadataFile := flag.String("metadata", "", "JSON metadata file (bypasses MusicBrainz)")
+
+	workers := flag.Int("j", runtime.NumCPU(), "Tracks to encode at once")
+	keep := flag.Bool("keep", false, "Keep the ripped WAV files")
+	dryRun := flag.Bool("dry-run", false, "Rip and show the file names tracks would be encoded to, without encoding")
+
+	chunkSize := flag.Int("chunk-size", 75, "Frames per USB transfer")
+	secure := flag.Bool("secure", false, "Read every chunk at least twice and compare (slower)")
+	secureRereads := flag.Int("secure-rereads", 5, "Max extra reads of a chunk whose passes disagree (--secure)")
+	readOffset := flag.Int("read-offset", 0, "Drive read offset correction in samples (overrides drive config)")
+	driveConfigPath := flag.String("drive-config", rip.DefaultDriveConfigPath(), "Per-drive settings file (JSON)")
+
+	verbose := flag.Bool("v", false, "Verbose output")
+	flag.BoolVar(verbose, "verbose", false, "Verbose output")
+
+	vendorID := flag.String("vendor-id", "", "USB vendor ID (hex, e.g., 0x0e8d)")
+	productID := flag.String("product-id", "", "USB product ID (hex, e.g., 0x1887)")
+
+	simImage := flag.String("sim-image", "", "Rip from a disc image file instead of USB (requires --sim-toc)")
+	simTOC := flag.String("sim-toc", "", "Raw READ TOC response for --sim-image")
+
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s [flags]\n\n", os.Args[0])
+		fmt.Fprintf(os.Stderr, "Rip a CD and encode each track as soon as it is ripped.\n\n")
+		fmt.Fprintf(os.Stderr, "Flags:\n")
+		flag.PrintDefaults()
+	}
+
+	flag.Parse()
+
+	fmt.Println("cd-pipeline - rip, look up and encode in one pass")
+	fmt.Println(strings.Repeat("=", 60))
+
+	encoder, err := encode.EncoderFor(*format)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+
+	config, err := encode.LoadConfig(*configPath)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+	}
+	naming, err := config.Naming(encode.Config{Template: *template, Charset: *charset, Transliterate: *transliterate})
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+		os.Exit(1)
+	}
+	if !encode.Available(encoder) {
+		fmt.Fprintf(os.Stderr, "Error: %s not found. Install with: %s\n", encoder.Tool(), encode.InstallHint(encoder))
+		os.Exit(1)
+	}
+
+	// Parse vendor/product IDs
+	var vid, pid gousb.ID
+	if *vendorID != "" {
+		v, err := strconv.ParseUint(strings.TrimPrefix(*vendorID, "0x"), 16, 16)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid vendor ID: %s\n", *vendorID)
+			os.Exit(1)
+		}
+		vid = gousb.ID(v)
+	}
+	if *productID != "" {
+		p, err := strconv.ParseUint(strings.TrimPrefix(*productID, "0x"), 16, 16)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Invalid product ID: %s\n", *productID)
+			os.Exit(1)
+		}
+		pid = gousb.ID(p)
+	}
+
+	// Open device (or simulated drive)
+	var dev scsi.Drive
+	if *simImage != "" {
+		sim, err := scsi.OpenSimDrive(*simImage, *simTOC)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		dev = sim
+	} else {
+		usb, err := scsi.OpenDevice(vid, pid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Is the USB CD drive shared with Linux?")
+			os.Exit(1)
+		}
+		dev = usb
+	}
+	defer dev.Close()
+
+	info, err := dev.Inquiry()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "INQUIRY failed: %v\n", err)
+		os.Exit(1)
+	}
+	fmt.Printf("Device: %s %s (rev %s)\n", info.Vendor, info.Product, info.Revision)
+
+	// Resolve read offset: flag, then drive config
+	if !flagSet("read-offset") {
+		config, err := rip.LoadDriveConfig(*driveConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if s, ok := config.Lookup(*info); ok {
+			*readOffset = s.ReadOffset
+		}
+	}
+	fmt.Printf("Read offset: %+d samples\n", *readOffset)
+
+	if !dev.TestUnitReady() {
+		fmt.Fprintln(os.Stderr, "\nNo disc in drive or drive not ready")
+		os.Exit(1)
+	}
+
+	// Read the TOC, sessions, pregaps, codes and CD-TEXT up front: the drive
+	// is busy ripping from here on
+	tocRaw, err := dev.ReadTOCRaw()
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to read TOC: %v\n", err)
+		os.Exit(1)
+	}
+	toc, err := cdda.ParseTOC(tocRaw)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to parse TOC: %v\n", err)
+		os.Exit(1)
+	}
+	toc = rip.ReadSessions(dev, toc)
+	toc = rip.ScanIndexes(dev, toc)
+	toc = rip.ReadCodes(dev, toc)
+	cdText := rip.ReadCDText(dev)
+
+	discID := cdda.CalculateDiscID(toc)
+	audio := cdda.AudioTOC(toc)
+	fmt.Printf("Disc ID: %s (%d tracks)\n", discID, len(audio.Tracks))
+
+	// Work directory for the WAV files (respects TMPDIR)
+	workDir, err := os.MkdirTemp("", "cd-pipeline-")
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to create work directory: %v\n", err)
+		os.Exit(1)
+	}
+	// An interrupted run keeps the WAV files ripped so far; say where
+	interrupt := make(chan os.Signal, 1)
+	signal.Notify(interrupt, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-interrupt
+		fmt.Fprintf(os.Stderr, "\nInterrupted. WAVs preserved: %s\n", workDir)
+		os.Exit(1)
+	}()
+
+	os.WriteFile(filepath.Join(workDir, "discid.txt"), []byte(discID+"\n"), 0644)
+	if len(cdText) > 0 {
+		os.WriteFile(filepath.Join(workDir, "cdtext.json"), rip.CDTextJSON(cdText), 0644)
+	}
+
+	destDir := *dest
+	if destDir == "" {
+		home, _ := os.UserHomeDir()
+		destDir = filepath.Join(home, "Music")
+	}
+
+	isrcs := make(map[int]string)
+	for _, t := range toc.Tracks {
+		if t.ISRC != "" {
+			isrcs[t.Num] = t.ISRC
+		}
+	}
+
+	fmt.Printf("Ripping to: %s\n", workDir)
+	encodeOpts := encode.EncodeOptions{Quality: *quality, Bitrate: *bitrate, Verbose: *verbose}
+	if *dryRun {
+		fmt.Printf("[DRY RUN] Would encode to: %s (%s %s)\n\n", destDir, encoder.Format(), encode.QualityLabel(encoder, encodeOpts))
+	} else {
+		fmt.Printf("Encoding to: %s (%s %s, %d at a time)\n\n", destDir, encoder.Format(), encode.QualityLabel(encoder, encodeOpts), max(*workers, 1))
+	}
+	p := pipeline{
+		Rip: rip.Options{
+			OutputDir:  workDir,
+			ChunkSize:  *chunkSize,
+			Verbose:    *verbose,
+			Secure:     *secure,
+			MaxRereads: *secureRereads,
+			ReadOffset: *readOffset,
+		},
+		DestDir: destDir,
+		Workers: *workers,
+		Lookup: func() (encode.Album, error) {
+			album, err := lookupAlbum(lookupOptions{
+				DiscID:       discID,
+				Search:       *search,
+				MetadataFile: *metadataFile,
+				WorkDir:      workDir,
+				TrackCount:   len(audio.Tracks),
+				MCN:          toc.MCN,
+				ISRCs:        isrcs,
+			})
+			album.Encoder = encoder
+			album.Naming = &naming
+			return album, err
+		},
+		Encode: func(j encode.Job) error { return j.Run(encodeOpts) },
+		DryRun: *dryRun,
+	}
+	result := p.run(dev, toc)
+
+	fmt.Printf("\n%s\n", strings.Repeat("=", 60))
+	if result.MetadataErr != nil {
+		fmt.Fprintf(os.Stderr, "Metadata lookup failed: %v\n", result.MetadataErr)
+		fmt.Fprintf(os.Stderr, "WAVs preserved: %s\nEncode them with: cd-encode --search \"Artist Album\" %s\n", workDir, workDir)
+		os.Exit(1)
+	}
+	if result.RipErr != nil {
+		fmt.Fprintf(os.Stderr, "Rip stopped: %v\n", result.RipErr)
+	}
+	if !result.OK() {
+		fmt.Fprintf(os.Stderr, "Encoded %d of %d tracks (%d ripped). WAVs preserved: %s\n", result.Encoded, result.Tracks, result.Ripped, workDir)
+		os.Exit(1)
+	}
+
+	if *keep {
+		fmt.Printf("WAVs kept in: %s\n", workDir)
+	} else {
+		os.RemoveAll(workDir)
+	}
+	if *dryRun {
+		fmt.Printf("[DRY RUN] Ripped %d tracks; nothing encoded\n", result.Ripped)
+		return
+	}
+	fmt.Printf("Done! Encoded %d tracks to %s\n", result.Encoded, destDir)
+}
+
+// flagSet reports whether a flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
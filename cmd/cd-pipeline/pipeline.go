@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"path/filepath"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// pipeline rips a disc and encodes each track as soon as it is ripped.
+// Metadata is looked up while the first tracks are being read.
+type pipeline struct {
+	Rip     rip.Options
+	DestDir string
+	Workers int                          // Concurrent encoders
+	Lookup  func() (encode.Album, error) // Runs alongside the rip
+	Encode  func(encode.Job) error
+	DryRun  bool // Rip and show the file names, without encoding
+}
+
+// pipelineResult summarizes a pipeline run
+type pipelineResult struct {
+	Tracks      int // Audio tracks on the disc
+	Ripped      int
+	Encoded     int
+	Planned     int   // Tracks a dry run would have encoded
+	Failed      int   // Tracks not ripped or that failed to encode
+	MetadataErr error // Lookup failure; no track was encoded
+	RipErr      error // Disc ejected or changed mid-rip
+}
+
+// OK reports whether every audio track on the disc was ripped and encoded
+func (r pipelineResult) OK() bool {
+	return r.MetadataErr == nil && r.RipErr == nil && r.Failed == 0 && r.Encoded+r.Planned == r.Tracks
+}
+
+// run rips toc's audio tracks from dev into p.Rip.OutputDir and encodes
+// them on p.Workers workers as they finish. Ripping never waits for the
+// lookup or the encoders; finished tracks queue until both are ready.
+func (p pipeline) run(dev scsi.Drive, toc cdda.TOC) pipelineResult {
+	var result pipelineResult
+
+	// The index of each track among the disc's audio tracks, which is its
+	// position in the release's track list
+	index := make(map[int]int)
+	for _, t := range toc.Tracks {
+		if t.IsAudio() {
+			index[t.Num] = len(index)
+		}
+	}
+	result.Tracks = len(index)
+
+	type lookupResult struct {
+		album encode.Album
+		err   error
+	}
+	lookup := make(chan lookupResult, 1)
+	go func() {
+		album, err := p.Lookup()
+		lookup <- lookupResult{album, err}
+	}()
+
+	// Rip, handing over each track as it is written
+	ripped := make(chan rip.Track, len(toc.Tracks)+1)
+	ripDone := make(chan error, 1)
+	go func() {
+		opts := p.Rip
+		opts.OnTrack = func(t rip.Track) { ripped <- t }
+		_, err := rip.Tracks(dev, toc, nil, opts)
+		close(ripped)
+		ripDone <- err
+	}()
+
+	// Turn ripped tracks into encoder jobs once the metadata is in
+	jobs := make(chan encode.Job)
+	go func() {
+		defer close(jobs)
+		l := <-lookup
+		result.MetadataErr = l.err
+		for t := range ripped {
+			result.Ripped++
+			if l.err != nil {
+				continue
+			}
+			job := l.album.TrackJob(index[t.CRC.Num], t.Path, p.DestDir)
+			if p.DryRun {
+				rel, _ := filepath.Rel(p.DestDir, job.Dest)
+				fmt.Printf("\n  Would encode %s -> %s\n", filepath.Base(job.WAV), rel)
+				result.Planned++
+				continue
+			}
+			jobs <- job
+		}
+	}()
+
+	for r := range encode.RunJobs(jobs, p.Workers, p.Encode) {
+		if r.Err != nil {
+			result.Failed++
+			fmt.Printf("\n  Track %02d. %s: ERROR: %v\n", r.Job.Tags.TrackNum, r.Job.Tags.Title, r.Err)
+			continue
+		}
+		result.Encoded++
+		fmt.Printf("\n  Encoded %02d. %s\n", r.Job.Tags.TrackNum, r.Job.Tags.Title)
+	}
+	result.RipErr = <-ripDone
+
+	// Tracks skipped after read errors, or never reached
+	result.Failed += result.Tracks - result.Ripped
+
+	return result
+}
@@ -0,0 +1,197 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/encode"
+	"github.com/binaryphile/crostini-cd-rip/internal/musicbrainz"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// simDisc returns a simulated 3-track disc of silence and its parsed TOC
+func simDisc(t *testing.T) (*scsi.SimDrive, cdda.TOC) {
+	t.Helper()
+	raw := scsi.SimTOC([]int{150, 200, 310}, 400)
+	toc, err := cdda.ParseTOC(raw)
+	if err != nil {
+		t.Fatalf("ParseTOC error: %v", err)
+	}
+	return scsi.NewSimDrive(raw, make([]byte, 400*scsi.FrameSize)), toc
+}
+
+func testAlbum() encode.Album {
+	return encode.Album{Release: &musicbrainz.Release{
+		Artist: "Artist",
+		Title:  "Album",
+		Tracks: []musicbrainz.Track{{Num: 1, Title: "One"}, {Num: 2, Title: "Two"}, {Num: 3, Title: "Three"}},
+	}}
+}
+
+func TestPipeline_LookupRunsAlongsideRip(t *testing.T) {
+	dev, toc := simDisc(t)
+	dir := t.TempDir()
+
+	var mu sync.Mutex
+	var encoded []encode.Job
+	p := pipeline{
+		Rip:     rip.Options{OutputDir: dir, ChunkSize: 25},
+		DestDir: "/music",
+		Workers: 2,
+		Lookup: func() (encode.Album, error) {
+			// Ripping must not wait for the lookup: hold it until the last track is on disk
+			deadline := time.Now().Add(5 * time.Second)
+			for {
+				if _, err := os.Stat(filepath.Join(dir, "track03.wav")); err == nil {
+					return testAlbum(), nil
+				}
+				if time.Now().After(deadline) {
+					return encode.Album{}, errors.New("rip waited for the lookup")
+				}
+				time.Sleep(time.Millisecond)
+			}
+		},
+		Encode: func(j encode.Job) error {
+			mu.Lock()
+			defer mu.Unlock()
+			encoded = append(encoded, j)
+			return nil
+		},
+	}
+
+	result := p.run(dev, toc)
+	if !result.OK() || result.Ripped != 3 || result.Encoded != 3 {
+		t.Fatalf("result = %+v, want 3 tracks ripped and encoded", result)
+	}
+
+	byTrack := make(map[int]encode.Job)
+	for _, j := range encoded {
+		byTrack[j.Tags.TrackNum] = j
+	}
+	for n, title := range map[int]string{1: "One", 2: "Two", 3: "Three"} {
+		j, ok := byTrack[n]
+		if !ok {
+			t.Errorf("track %d not encoded", n)
+			continue
+		}
+		if j.Tags.Title != title || j.WAV != filepath.Join(dir, fmt.Sprintf("track%02d.wav", n)) {
+			t.Errorf("track %d job = %+v", n, j)
+		}
+	}
+}
+
+// gatedDrive holds reads from gateLBA on until open is closed
+type gatedDrive struct {
+	scsi.Drive
+	gateLBA int
+	open    <-chan struct{}
+}
+
+func (d gatedDrive) ReadCDFrames(startLBA, numFrames int) ([]byte, error) {
+	if startLBA >= d.gateLBA {
+		select {
+		case <-d.open:
+		case <-time.After(5 * time.Second):
+			return nil, errors.New("gate never opened")
+		}
+	}
+	return d.Drive.ReadCDFrames(startLBA, numFrames)
+}
+
+func TestPipeline_EncodesWhileRipping(t *testing.T) {
+	dev, toc := simDisc(t)
+
+	// Track 3 can't be read until track 1 is being encoded
+	started := make(chan struct{})
+	var once sync.Once
+	p := pipeline{
+		Rip:     rip.Options{OutputDir: t.TempDir(), ChunkSize: 25},
+		DestDir: "/music",
+		Workers: 1,
+		Lookup:  func() (encode.Album, error) { return testAlbum(), nil },
+		Encode: func(j encode.Job) error {
+			if j.Tags.TrackNum == 1 {
+				once.Do(func() { close(started) })
+			}
+			return nil
+		},
+	}
+
+	result := p.run(gatedDrive{Drive: dev, gateLBA: 310, open: started}, toc)
+	if !result.OK() || result.Encoded != 3 {
+		t.Errorf("result = %+v, want all 3 tracks encoded with track 1 overlapping the rip", result)
+	}
+}
+
+func TestPipeline_MetadataFailureKeepsWAVs(t *testing.T) {
+	dev, toc := simDisc(t)
+	dir := t.TempDir()
+
+	p := pipeline{
+		Rip:     rip.Options{OutputDir: dir, ChunkSize: 75},
+		Workers: 2,
+		Lookup:  func() (encode.Album, error) { return encode.Album{}, errors.New("offline") },
+		Encode: func(j encode.Job) error {
+			t.Errorf("track %d encoded without metadata", j.Tags.TrackNum)
+			return nil
+		},
+	}
+
+	result := p.run(dev, toc)
+	if result.MetadataErr == nil || result.Ripped != 3 || result.Encoded != 0 {
+		t.Errorf("result = %+v, want 3 tracks ripped, none encoded", result)
+	}
+	for _, name := range []string{"track01.wav", "track02.wav", "track03.wav"} {
+		if _, err := os.Stat(filepath.Join(dir, name)); err != nil {
+			t.Errorf("%s should be kept: %v", name, err)
+		}
+	}
+}
+
+func TestPipeline_SkippedTrackFails(t *testing.T) {
+	dev, toc := simDisc(t)
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseIllegalRequest, ASC: scsi.ASCLBAOutOfRange}, 0)
+
+	p := pipeline{
+		Rip:     rip.Options{OutputDir: t.TempDir(), ChunkSize: 25},
+		DestDir: "/music",
+		Workers: 2,
+		Lookup:  func() (encode.Album, error) { return testAlbum(), nil },
+		Encode:  func(encode.Job) error { return nil },
+	}
+
+	// Track 2 is skipped by the rip, so the disc is not done even though
+	// every ripped track was encoded
+	result := p.run(dev, toc)
+	if result.OK() || result.Tracks != 3 || result.Ripped != 2 || result.Encoded != 2 || result.Failed != 1 {
+		t.Errorf("result = %+v, want 2 of 3 tracks encoded and 1 failed", result)
+	}
+}
+
+func TestPipeline_DryRun(t *testing.T) {
+	dev, toc := simDisc(t)
+
+	p := pipeline{
+		Rip:     rip.Options{OutputDir: t.TempDir(), ChunkSize: 75},
+		DestDir: "/music",
+		Workers: 2,
+		Lookup:  func() (encode.Album, error) { return testAlbum(), nil },
+		Encode: func(j encode.Job) error {
+			t.Errorf("track %d encoded on a dry run", j.Tags.TrackNum)
+			return nil
+		},
+		DryRun: true,
+	}
+
+	result := p.run(dev, toc)
+	if !result.OK() || result.Ripped != 3 || result.Planned != 3 || result.Encoded != 0 {
+		t.Errorf("result = %+v, want 3 tracks ripped and planned, none encoded", result)
+	}
+}
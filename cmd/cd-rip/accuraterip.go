@@ -0,0 +1,57 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+)
+
+// verifyAccurateRip checks ripped tracks against the AccurateRip database and
+// prints the confidence of each. Entries come from dbFile if set, otherwise
+// they are fetched from baseURL. Returns nil results if the disc isn't listed.
+func verifyAccurateRip(toc cdda.TOC, ripped []rip.Track, dbFile, baseURL string) ([]accuraterip.TrackResult, error) {
+	ids := accuraterip.CalculateDiscIDs(toc)
+
+	fmt.Printf("\nAccurateRip: %s\n", ids.Filename())
+
+	entries, err := loadEntries(ids, dbFile, baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		fmt.Println("  Disc not found in AccurateRip database")
+		return nil, nil
+	}
+
+	var crcs []accuraterip.TrackCRC
+	for _, t := range ripped {
+		if t.CRC.Num > 0 { // Hidden track one audio isn't in the database
+			crcs = append(crcs, t.CRC)
+		}
+	}
+
+	results := accuraterip.Verify(ids, crcs, entries)
+	accurate := 0
+	for _, r := range results {
+		if r.Accurate() {
+			accurate++
+			fmt.Printf("  Track %2d: accurate (confidence %d, v%d)\n", r.Num, r.Confidence, r.Version)
+		} else {
+			fmt.Printf("  Track %2d: NOT accurate (v1 %08x, v2 %08x)\n", r.Num, r.V1, r.V2)
+		}
+	}
+	fmt.Printf("  %d/%d tracks accurately ripped\n", accurate, len(results))
+
+	return results, nil
+}
+
+// loadEntries reads AccurateRip entries from dbFile if set, otherwise fetches
+// them from baseURL. Returns (nil, nil) if the disc isn't in the database.
+func loadEntries(ids accuraterip.DiscIDs, dbFile, baseURL string) ([]accuraterip.Entry, error) {
+	if dbFile != "" {
+		return accuraterip.Load(dbFile)
+	}
+	return accuraterip.Fetch(baseURL, ids)
+}
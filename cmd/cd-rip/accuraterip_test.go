@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// simDisc returns a simulated 3-track disc, its parsed TOC and its image
+func simDisc(t *testing.T) (*scsi.SimDrive, cdda.TOC, []byte) {
+	t.Helper()
+	raw, pcm := scsi.SimTOC([]int{150, 200, 310}, 400), scsi.SimImage(400)
+	toc, err := cdda.ParseTOC(raw)
+	if err != nil {
+		t.Fatalf("ParseTOC error: %v", err)
+	}
+	return scsi.NewSimDrive(raw, pcm), toc, pcm
+}
+
+// dBARFor encodes a one-pressing dBAR file listing the CRCs of the sim disc image
+func dBARFor(ids accuraterip.DiscIDs, pcm []byte, bounds [][2]int) []byte {
+	data := []byte{byte(ids.TrackCount)}
+	data = binary.LittleEndian.AppendUint32(data, ids.ID1)
+	data = binary.LittleEndian.AppendUint32(data, ids.ID2)
+	data = binary.LittleEndian.AppendUint32(data, ids.CDDB)
+	for i, b := range bounds {
+		_, v2 := accuraterip.TrackCRCs(pcm[b[0]*scsi.FrameSize:b[1]*scsi.FrameSize], i == 0, i == len(bounds)-1)
+		data = append(data, 7)
+		data = binary.LittleEndian.AppendUint32(data, v2)
+		data = binary.LittleEndian.AppendUint32(data, 0)
+	}
+	return data
+}
+
+func TestVerifyAccurateRip(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	ids := accuraterip.CalculateDiscIDs(toc)
+	db := dBARFor(ids, pcm, [][2]int{{150, 200}, {200, 310}, {310, 400}})
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/"+ids.Path() {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(db)
+	}))
+	defer server.Close()
+
+	// Track 2 loses a frame to a scratch
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseMediumError, ASC: scsi.ASCUnrecoveredReadError}, 0)
+	ripped, err := rip.Tracks(dev, toc, nil, rip.Options{OutputDir: t.TempDir(), ChunkSize: 75})
+	if err != nil {
+		t.Fatalf("ripTracks error: %v", err)
+	}
+
+	results, err := verifyAccurateRip(toc, ripped, "", server.URL)
+	if err != nil {
+		t.Fatalf("verifyAccurateRip error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("got %d results, want 3", len(results))
+	}
+
+	wantAccurate := []bool{true, false, true}
+	for i, r := range results {
+		if r.Accurate() != wantAccurate[i] {
+			t.Errorf("track %d accurate = %v, want %v", r.Num, r.Accurate(), wantAccurate[i])
+		}
+	}
+	if results[0].Confidence != 7 || results[0].Version != 2 {
+		t.Errorf("track 1 = confidence %d v%d, want 7 v2", results[0].Confidence, results[0].Version)
+	}
+}
+
+func TestVerifyAccurateRip_NotInDatabase(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	ripped, _ := rip.Tracks(dev, toc, parseTrackList("1"), rip.Options{OutputDir: t.TempDir(), ChunkSize: 75})
+
+	results, err := verifyAccurateRip(toc, ripped, "", server.URL)
+	if err != nil || results != nil {
+		t.Errorf("verifyAccurateRip() = %v, %v, want nil, nil", results, err)
+	}
+}
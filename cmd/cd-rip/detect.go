@@ -0,0 +1,129 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// maxDetectTracks limits how many tracks are sampled when detecting the offset
+const maxDetectTracks = 3
+
+// detectOffset finds the drive's read offset by reading a few frames around
+// the AccurateRip offset frame of up to maxDetectTracks tracks and searching
+// for the database's offset CRCs.
+// Returns (offset, tracksMatched, error); the offset is the one matched by the
+// most tracks.
+func detectOffset(dev scsi.Drive, toc cdda.TOC, entries []accuraterip.Entry) (int, int, error) {
+	ids := accuraterip.CalculateDiscIDs(toc)
+	half := accuraterip.OffsetSearchFrames / 2
+
+	votes := make(map[int]int)
+	sampled := 0
+
+	for i, track := range toc.Tracks {
+		if !track.IsAudio() || sampled >= maxDetectTracks {
+			continue
+		}
+
+		_, endLBA := cdda.TrackRange(toc, i, false)
+		center := track.LBA + accuraterip.OffsetFrame
+		if center+half+1 > endLBA {
+			continue // Track too short
+		}
+
+		var crcs []uint32
+		for _, e := range entries {
+			if e.DiscIDs == ids && track.Num-1 < len(e.Tracks) {
+				crcs = append(crcs, e.Tracks[track.Num-1].Frame450CRC)
+			}
+		}
+		if len(crcs) == 0 {
+			continue
+		}
+
+		pcm, _, err := rip.ReadChunk(dev, center-half, accuraterip.OffsetSearchFrames)
+		if err != nil {
+			return 0, 0, err
+		}
+		sampled++
+
+		offsets := accuraterip.MatchOffsets(pcm, crcs)
+		fmt.Printf("  Track %2d: %s\n", track.Num, formatOffsets(offsets))
+		for _, o := range offsets {
+			votes[o]++
+		}
+	}
+
+	if sampled == 0 {
+		return 0, 0, errors.New("no tracks long enough to detect the offset")
+	}
+
+	best, bestVotes := 0, 0
+	for o, n := range votes {
+		if n > bestVotes || (n == bestVotes && abs(o) < abs(best)) {
+			best, bestVotes = o, n
+		}
+	}
+	if bestVotes == 0 {
+		return 0, 0, errors.New("no offset matched the AccurateRip database")
+	}
+
+	return best, bestVotes, nil
+}
+
+// runDetectOffset detects the drive's read offset from a disc in the
+// AccurateRip database and saves it to the drive config.
+func runDetectOffset(dev scsi.Drive, toc cdda.TOC, info scsi.InquiryData, dbFile, baseURL, configPath string) error {
+	ids := accuraterip.CalculateDiscIDs(toc)
+	fmt.Printf("\nDetecting read offset using AccurateRip: %s\n", ids.Filename())
+
+	entries, err := loadEntries(ids, dbFile, baseURL)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return errors.New("disc not found in AccurateRip database; try another disc")
+	}
+
+	offset, matched, err := detectOffset(dev, toc, entries)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("\nRead offset: %+d samples (matched %d tracks)\n", offset, matched)
+
+	config, err := rip.LoadDriveConfig(configPath)
+	if err != nil {
+		return err
+	}
+	config.Set(info, rip.DriveSettings{ReadOffset: offset})
+	if err := rip.SaveDriveConfig(configPath, config); err != nil {
+		return err
+	}
+	fmt.Printf("Saved for %s to %s\n", rip.DriveKeys(info)[0], configPath)
+
+	return nil
+}
+
+// formatOffsets describes the offsets matched on one track
+func formatOffsets(offsets []int) string {
+	if len(offsets) == 0 {
+		return "no match"
+	}
+	s := "offset"
+	for _, o := range offsets {
+		s += fmt.Sprintf(" %+d", o)
+	}
+	return s
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
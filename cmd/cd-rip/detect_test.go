@@ -0,0 +1,92 @@
+package main
+
+import (
+	"encoding/binary"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// offsetDisc returns a simulated disc of noise with three tracks long enough
+// for offset detection, and a dBAR file for it as ripped by a drive whose
+// read offset correction is trueOffset samples.
+func offsetDisc(t *testing.T, trueOffset int) (*scsi.SimDrive, cdda.TOC, []byte) {
+	t.Helper()
+	lbas, leadout := []int{150, 750, 1350}, 1950
+
+	pcm := make([]byte, leadout*scsi.FrameSize)
+	rand.New(rand.NewSource(7)).Read(pcm)
+	dev := scsi.NewSimDrive(scsi.SimTOC(lbas, leadout), pcm)
+
+	raw, _ := dev.ReadTOCRaw()
+	toc, err := cdda.ParseTOC(raw)
+	if err != nil {
+		t.Fatalf("ParseTOC error: %v", err)
+	}
+
+	ids := accuraterip.CalculateDiscIDs(toc)
+	db := []byte{byte(ids.TrackCount)}
+	db = binary.LittleEndian.AppendUint32(db, ids.ID1)
+	db = binary.LittleEndian.AppendUint32(db, ids.ID2)
+	db = binary.LittleEndian.AppendUint32(db, ids.CDDB)
+	for _, lba := range lbas {
+		start := (lba+accuraterip.OffsetFrame)*scsi.FrameSize + trueOffset*scsi.SampleSize
+		db = append(db, 5)
+		db = binary.LittleEndian.AppendUint32(db, 0)
+		db = binary.LittleEndian.AppendUint32(db, accuraterip.FrameCRC(pcm[start:start+scsi.FrameSize]))
+	}
+
+	return dev, toc, db
+}
+
+func TestDetectOffset(t *testing.T) {
+	dev, toc, db := offsetDisc(t, 667)
+	entries, _ := accuraterip.ParseResponse(db)
+
+	offset, matched, err := detectOffset(dev, toc, entries)
+	if err != nil {
+		t.Fatalf("detectOffset error: %v", err)
+	}
+	if offset != 667 || matched != 3 {
+		t.Errorf("detectOffset() = %d (%d tracks), want 667 (3 tracks)", offset, matched)
+	}
+}
+
+func TestDetectOffset_NoMatch(t *testing.T) {
+	dev, toc, db := offsetDisc(t, 667)
+	entries, _ := accuraterip.ParseResponse(db)
+	for i := range entries[0].Tracks {
+		entries[0].Tracks[i].Frame450CRC ^= 1
+	}
+
+	if _, _, err := detectOffset(dev, toc, entries); err == nil {
+		t.Error("detectOffset should fail when no offset matches")
+	}
+}
+
+func TestRunDetectOffset_SavesConfig(t *testing.T) {
+	dev, toc, db := offsetDisc(t, -48)
+	dir := t.TempDir()
+	dbFile := filepath.Join(dir, "dBAR.bin")
+	os.WriteFile(dbFile, db, 0644)
+	configPath := filepath.Join(dir, "config", "drives.json")
+	info := scsi.InquiryData{Vendor: "SIM", Product: "Simulated CD", Revision: "1.0"}
+
+	if err := runDetectOffset(dev, toc, info, dbFile, "", configPath); err != nil {
+		t.Fatalf("runDetectOffset error: %v", err)
+	}
+
+	config, err := rip.LoadDriveConfig(configPath)
+	if err != nil {
+		t.Fatalf("loadDriveConfig error: %v", err)
+	}
+	if s, ok := config["SIM Simulated CD 1.0"]; !ok || s.ReadOffset != -48 {
+		t.Errorf("saved config = %v, want SIM Simulated CD 1.0 at -48", config)
+	}
+}
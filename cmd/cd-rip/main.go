@@ -7,9 +7,10 @@ import (
 	"os"
 	"strconv"
 	"strings"
-	"time"
 
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
 	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/rip"
 	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
 	"github.com/google/gousb"
 )
@@ -23,15 +24,34 @@ func main() {
 	flag.StringVar(tracks, "tracks", "", "Tracks to rip (comma-separated, e.g., 1,3,5)")
 
 	tocOnly := flag.Bool("toc", false, "Show TOC only, don't rip")
+	detect := flag.Bool("detect-offset", false, "Detect and save the drive's read offset using a disc in AccurateRip, don't rip")
 
 	chunkSize := flag.Int("chunk-size", 75, "Frames per USB transfer")
 
+	image := flag.Bool("image", false, "Rip the whole disc to a single disc.wav with a cue sheet")
+	htoa := flag.Bool("htoa", false, "Extract hidden track one audio (before track 1) to track00.wav")
+	pregaps := flag.String("pregaps", "append", "Where pregaps go: append (end of previous track) or prepend (start of their track; breaks AccurateRip)")
+
+	resume := flag.Bool("resume", false, "Continue an interrupted rip of the same disc in the output directory")
+
+	secure := flag.Bool("secure", false, "Read every chunk at least twice and compare (slower)")
+	secureRereads := flag.Int("secure-rereads", 5, "Max extra reads of a chunk whose passes disagree (--secure)")
+
+	arVerify := flag.Bool("accuraterip", false, "Verify the rip against the AccurateRip database")
+	arFile := flag.String("accuraterip-file", "", "Verify against a saved AccurateRip dBAR file instead of fetching")
+
+	readOffset := flag.Int("read-offset", 0, "Drive read offset correction in samples (overrides drive config)")
+	driveConfigPath := flag.String("drive-config", rip.DefaultDriveConfigPath(), "Per-drive settings file (JSON)")
+
 	verbose := flag.Bool("v", false, "Verbose output")
 	flag.BoolVar(verbose, "verbose", false, "Verbose output")
 
 	vendorID := flag.String("vendor-id", "", "USB vendor ID (hex, e.g., 0x0e8d)")
 	productID := flag.String("product-id", "", "USB product ID (hex, e.g., 0x1887)")
 
+	simImage := flag.String("sim-image", "", "Rip from a disc image file instead of USB (requires --sim-toc)")
+	simTOC := flag.String("sim-toc", "", "Raw READ TOC response for --sim-image (e.g., a saved toc.bin)")
+
 	flag.Parse()
 
 	fmt.Println("cd-rip - USB CD Ripper for ChromeOS/Crostini")
@@ -56,12 +76,23 @@ func main() {
 		pid = gousb.ID(p)
 	}
 
-	// Open device
-	dev, err := scsi.OpenDevice(vid, pid)
-	if err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		fmt.Fprintln(os.Stderr, "Is the USB CD drive shared with Linux?")
-		os.Exit(1)
+	// Open device (or simulated drive)
+	var dev scsi.Drive
+	if *simImage != "" {
+		sim, err := scsi.OpenSimDrive(*simImage, *simTOC)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			os.Exit(1)
+		}
+		dev = sim
+	} else {
+		usb, err := scsi.OpenDevice(vid, pid)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Is the USB CD drive shared with Linux?")
+			os.Exit(1)
+		}
+		dev = usb
 	}
 	defer dev.Close()
 
@@ -79,6 +110,20 @@ func main() {
 	fmt.Printf("\nDevice: %s %s (rev %s)\n", info.Vendor, info.Product, info.Revision)
 	fmt.Printf("Type: %s\n", deviceType)
 
+	// Resolve read offset: flag, then drive config
+	offsetSource := "--read-offset"
+	if !flagSet("read-offset") {
+		offsetSource = "default"
+		config, err := rip.LoadDriveConfig(*driveConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: %v\n", err)
+		} else if s, ok := config.Lookup(*info); ok {
+			*readOffset = s.ReadOffset
+			offsetSource = *driveConfigPath
+		}
+	}
+	fmt.Printf("Read offset: %+d samples (%s)\n", *readOffset, offsetSource)
+
 	// Check if disc is ready
 	if !dev.TestUnitReady() {
 		fmt.Fprintln(os.Stderr, "\nNo disc in drive or drive not ready")
@@ -98,13 +143,42 @@ func main() {
 		os.Exit(1)
 	}
 
+	// Number the sessions (Enhanced CDs have a data session after the audio)
+	toc = rip.ReadSessions(dev, toc)
+
+	// Find pregaps and indexes
+	toc = rip.ScanIndexes(dev, toc)
+
+	// Read the catalog number and ISRCs
+	toc = rip.ReadCodes(dev, toc)
+
 	// Print TOC
 	printTOC(toc, *verbose)
 
+	// Read CD-TEXT (most discs have none)
+	cdText := rip.ReadCDText(dev)
+	if len(cdText) > 0 {
+		fmt.Printf("\nCD-TEXT: %s - %s\n", cdText[0].Album.Performer, cdText[0].Album.Title)
+	}
+
 	if *tocOnly {
 		return
 	}
 
+	if *detect {
+		err := runDetectOffset(dev, toc, *info, *arFile, accuraterip.DefaultBaseURL, *driveConfigPath)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nOffset detection failed: %v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	if *pregaps != "append" && *pregaps != "prepend" {
+		fmt.Fprintf(os.Stderr, "Invalid --pregaps %q (want append or prepend)\n", *pregaps)
+		os.Exit(1)
+	}
+
 	// Parse track selection
 	tracksToRip := parseTrackList(*tracks)
 
@@ -115,13 +189,57 @@ func main() {
 	}
 
 	fmt.Printf("\nRipping to: %s\n", *output)
-	fmt.Printf("Chunk size: %d frames\n\n", *chunkSize)
+	fmt.Printf("Chunk size: %d frames\n", *chunkSize)
+	if *secure {
+		fmt.Printf("Secure mode: 2+ passes per chunk, up to %d re-reads\n", *secureRereads)
+	}
+	fmt.Println()
 
 	// Rip tracks
-	wavFiles := ripTracks(dev, toc, *output, tracksToRip, *chunkSize, *verbose)
+	opts := rip.Options{
+		OutputDir:   *output,
+		ChunkSize:   *chunkSize,
+		Verbose:     *verbose,
+		Secure:      *secure,
+		MaxRereads:  *secureRereads,
+		ReadOffset:  *readOffset,
+		PrependGaps: *pregaps == "prepend",
+		HTOA:        *htoa,
+	}
 
-	// Calculate disc ID and save metadata
+	// Journal progress so an interrupted rip can be resumed
 	discID := cdda.CalculateDiscID(toc)
+	opts.Journal, err = rip.OpenJournal(*output, discID, opts, *resume)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Can't resume: %v\n", err)
+		os.Exit(1)
+	}
+
+	var ripped []rip.Track
+	if *image {
+		path, crcs, err := rip.Image(dev, toc, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nRip stopped: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Reinsert the disc and run again with --resume to continue")
+			os.Exit(1)
+		}
+		for _, c := range crcs {
+			ripped = append(ripped, rip.Track{Path: path, CRC: c})
+		}
+	} else {
+		ripped, err = rip.Tracks(dev, toc, tracksToRip, opts)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "\nRip stopped: %v\n", err)
+			fmt.Fprintln(os.Stderr, "Reinsert the disc and run again with --resume to continue")
+			os.Exit(1)
+		}
+	}
+
+	if *arVerify || *arFile != "" {
+		if _, err := verifyAccurateRip(toc, ripped, *arFile, accuraterip.DefaultBaseURL); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: AccurateRip verification failed: %v\n", err)
+		}
+	}
 
 	// Save disc ID
 	discIDPath := fmt.Sprintf("%s/discid.txt", *output)
@@ -134,16 +252,73 @@ func main() {
 
 	// Save TOC as JSON
 	tocPath := fmt.Sprintf("%s/toc.json", *output)
-	tocJSON := tocToJSON(toc)
+	tocJSON := tocToJSON(toc, *readOffset)
 	if err := os.WriteFile(tocPath, tocJSON, 0644); err != nil {
 		fmt.Fprintf(os.Stderr, "Warning: failed to save TOC: %v\n", err)
 	}
 
+	// Save raw TOC (replayable with --sim-toc)
+	tocRawPath := fmt.Sprintf("%s/toc.bin", *output)
+	if err := os.WriteFile(tocRawPath, tocRaw, 0644); err != nil {
+		fmt.Fprintf(os.Stderr, "Warning: failed to save raw TOC: %v\n", err)
+	}
+
+	// Save CD-TEXT (fallback metadata for cd-encode)
+	if len(cdText) > 0 {
+		cdTextPath := fmt.Sprintf("%s/cdtext.json", *output)
+		if err := os.WriteFile(cdTextPath, rip.CDTextJSON(cdText), 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save CD-TEXT: %v\n", err)
+		}
+	}
+
+	// Save cue sheet (only meaningful when every track was ripped)
+	if tracksToRip == nil || *image {
+		cuePath := fmt.Sprintf("%s/disc.cue", *output)
+		cueOpts := cdda.CUEOptions{
+			PrependGaps: opts.PrependGaps,
+			HiddenTrack: opts.HTOA,
+			Catalog:     toc.MCN,
+			Tracks:      make(map[int]cdda.CUETrack),
+		}
+		if *image {
+			cueOpts.ImageFile = rip.ImageName
+		}
+		if len(cdText) > 0 {
+			cueOpts.Title = cdText[0].Album.Title
+			cueOpts.Performer = cdText[0].Album.Performer
+			for n, e := range cdText[0].Tracks {
+				cueOpts.Tracks[n] = cdda.CUETrack{Title: e.Title, Performer: e.Performer}
+			}
+		}
+		for _, t := range toc.Tracks {
+			if t.ISRC != "" {
+				ct := cueOpts.Tracks[t.Num]
+				ct.ISRC = t.ISRC
+				cueOpts.Tracks[t.Num] = ct
+			}
+		}
+		cue := cdda.WriteCUE(toc, cueOpts)
+		if err := os.WriteFile(cuePath, cue, 0644); err != nil {
+			fmt.Fprintf(os.Stderr, "Warning: failed to save cue sheet: %v\n", err)
+		}
+	}
+
 	fmt.Printf("\n%s\n", strings.Repeat("=", 50))
-	fmt.Printf("Done! Ripped %d tracks to %s\n", len(wavFiles), *output)
+	fmt.Printf("Done! Ripped %d tracks to %s\n", len(ripped), *output)
 	fmt.Println("\nNext step: cd-encode", *output)
 }
 
+// flagSet reports whether a flag was given on the command line
+func flagSet(name string) bool {
+	set := false
+	flag.Visit(func(f *flag.Flag) {
+		if f.Name == name {
+			set = true
+		}
+	})
+	return set
+}
+
 func printTOC(toc cdda.TOC, verbose bool) {
 	fmt.Printf("\nTable of Contents:\n")
 	fmt.Printf("%6s %8s %10s %10s %10s\n", "Track", "Type", "LBA", "Length", "Duration")
@@ -155,19 +330,37 @@ func printTOC(toc cdda.TOC, verbose bool) {
 			trackType = "data"
 		}
 
-		var length int
-		if i+1 < len(toc.Tracks) {
-			length = toc.Tracks[i+1].LBA - track.LBA
-		} else {
-			length = toc.LeadoutLBA - track.LBA
+		if i > 0 && track.Session > toc.Tracks[i-1].Session {
+			fmt.Printf("%6s session %d\n", "", track.Session)
 		}
 
+		start, end := cdda.TrackRange(toc, i, false)
+		length := end - start
+
 		duration := float64(length) / float64(scsi.FramesPerSecond)
 		fmt.Printf("%6d %8s %10d %10d %9.1fs\n",
 			track.Num, trackType, track.LBA, length, duration)
+
+		if verbose {
+			if track.ISRC != "" {
+				fmt.Printf("%6s ISRC: %s\n", "", track.ISRC)
+			}
+			if track.Pregap > 0 {
+				fmt.Printf("%6s pregap: %d frames from LBA %d\n", "", track.Pregap, track.PregapLBA())
+			}
+			for n, lba := range track.Indexes {
+				fmt.Printf("%6s index %02d: LBA %d\n", "", n+2, lba)
+			}
+		}
 	}
 
 	fmt.Printf("%6s %8s %10d\n", "Lead-out", "-", toc.LeadoutLBA)
+	if audio := cdda.AudioTOC(toc); audio.LastTrack != toc.LastTrack {
+		fmt.Printf("Enhanced CD: audio session ends at LBA %d\n", audio.LeadoutLBA)
+	}
+	if toc.MCN != "" {
+		fmt.Printf("Catalog number: %s\n", toc.MCN)
+	}
 }
 
 func parseTrackList(tracks string) map[int]bool {
@@ -185,105 +378,15 @@ func parseTrackList(tracks string) map[int]bool {
 	return result
 }
 
-func ripTracks(dev *scsi.Device, toc cdda.TOC, outputDir string, tracksToRip map[int]bool, chunkSize int, verbose bool) []string {
-	var wavFiles []string
-
-	for i, track := range toc.Tracks {
-		// Skip if not in selection
-		if tracksToRip != nil && !tracksToRip[track.Num] {
-			continue
-		}
-
-		// Skip data tracks
-		if track.Type == cdda.TrackTypeData {
-			fmt.Printf("Track %d: Skipping (data track)\n", track.Num)
-			continue
-		}
-
-		// Calculate end LBA
-		var endLBA int
-		if i+1 < len(toc.Tracks) {
-			endLBA = toc.Tracks[i+1].LBA
-		} else {
-			endLBA = toc.LeadoutLBA
-		}
-
-		wavFile := ripTrack(dev, track.Num, track.LBA, endLBA, outputDir, chunkSize, verbose)
-		if wavFile != "" {
-			wavFiles = append(wavFiles, wavFile)
-		}
-	}
-
-	return wavFiles
-}
-
-func ripTrack(dev *scsi.Device, trackNum, startLBA, endLBA int, outputDir string, chunkSize int, verbose bool) string {
-	totalFrames := endLBA - startLBA
-	durationSec := float64(totalFrames) / float64(scsi.FramesPerSecond)
-
-	fmt.Printf("Track %d: %d frames (%.1fs / %.1fm)\n",
-		trackNum, totalFrames, durationSec, durationSec/60)
-
-	filename := fmt.Sprintf("%s/track%02d.wav", outputDir, trackNum)
-	audioData := make([]byte, 0, totalFrames*scsi.FrameSize)
-
-	currentLBA := startLBA
-	startTime := time.Now()
-	errors := 0
-
-	for currentLBA < endLBA {
-		framesToRead := chunkSize
-		if currentLBA+framesToRead > endLBA {
-			framesToRead = endLBA - currentLBA
-		}
-
-		data, err := dev.ReadCDFrames(currentLBA, framesToRead)
-		if err != nil {
-			errors++
-			if errors > 10 {
-				fmt.Printf("\n  Too many errors at LBA %d, aborting track\n", currentLBA)
-				break
-			}
-			fmt.Printf("\n  Error at LBA %d, retrying...\n", currentLBA)
-			time.Sleep(100 * time.Millisecond)
-			continue
-		}
-
-		audioData = append(audioData, data...)
-		currentLBA += framesToRead
-		errors = 0
-
-		// Progress
-		done := currentLBA - startLBA
-		progress := done * 100 / totalFrames
-		elapsed := time.Since(startTime).Seconds()
-		speed := float64(done) / elapsed
-		eta := float64(totalFrames-done) / speed
-
-		fmt.Printf("\r  %3d%% | %d/%d frames | %.0f frames/s | ETA: %.0fs   ",
-			progress, done, totalFrames, speed, eta)
-	}
-
-	fmt.Println()
-
-	// Write WAV file
-	wav := cdda.WriteWAV(audioData)
-	if err := os.WriteFile(filename, wav, 0644); err != nil {
-		fmt.Printf("  Error writing %s: %v\n", filename, err)
-		return ""
-	}
-
-	fileSize := len(wav)
-	fmt.Printf("  Saved: %s (%.1f MB)\n", filename, float64(fileSize)/1024/1024)
-
-	return filename
-}
-
-func tocToJSON(toc cdda.TOC) []byte {
+func tocToJSON(toc cdda.TOC, readOffset int) []byte {
 	type jsonTrack struct {
-		Num  int    `json:"num"`
-		LBA  int    `json:"lba"`
-		Type string `json:"type"`
+		Num     int    `json:"num"`
+		LBA     int    `json:"lba"`
+		Type    string `json:"type"`
+		Session int    `json:"session,omitempty"`
+		Pregap  int    `json:"pregap,omitempty"`  // Frames of INDEX 00
+		Indexes []int  `json:"indexes,omitempty"` // LBAs of INDEX 02+
+		ISRC    string `json:"isrc,omitempty"`
 	}
 
 	type jsonTOC struct {
@@ -291,6 +394,8 @@ func tocToJSON(toc cdda.TOC) []byte {
 		LastTrack  int         `json:"last_track"`
 		Tracks     []jsonTrack `json:"tracks"`
 		LeadoutLBA int         `json:"leadout_lba"`
+		ReadOffset int         `json:"read_offset"`   // Samples
+		MCN        string      `json:"mcn,omitempty"` // UPC/EAN
 	}
 
 	tracks := make([]jsonTrack, len(toc.Tracks))
@@ -300,9 +405,13 @@ func tocToJSON(toc cdda.TOC) []byte {
 			trackType = "data"
 		}
 		tracks[i] = jsonTrack{
-			Num:  t.Num,
-			LBA:  t.LBA,
-			Type: trackType,
+			Num:     t.Num,
+			LBA:     t.LBA,
+			Type:    trackType,
+			Session: t.Session,
+			Pregap:  t.Pregap,
+			Indexes: t.Indexes,
+			ISRC:    t.ISRC,
 		}
 	}
 
@@ -311,6 +420,8 @@ func tocToJSON(toc cdda.TOC) []byte {
 		LastTrack:  toc.LastTrack,
 		Tracks:     tracks,
 		LeadoutLBA: toc.LeadoutLBA,
+		ReadOffset: readOffset,
+		MCN:        toc.MCN,
 	}
 
 	data, _ := json.MarshalIndent(j, "", "  ")
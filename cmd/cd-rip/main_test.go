@@ -0,0 +1,39 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// testTOC is a 3-track disc
+func testTOC() cdda.TOC {
+	return cdda.TOC{FirstTrack: 1, LastTrack: 3, LeadoutLBA: 400, Tracks: []cdda.Track{
+		{Num: 1, LBA: 150},
+		{Num: 2, LBA: 200},
+		{Num: 3, LBA: 310},
+	}}
+}
+
+func TestTOCToJSON_ReadOffset(t *testing.T) {
+	toc := testTOC()
+
+	if !bytes.Contains(tocToJSON(toc, -48), []byte(`"read_offset": -48`)) {
+		t.Error("toc.json should record the read offset")
+	}
+}
+
+func TestTOCToJSON_CodesAndSessions(t *testing.T) {
+	toc := testTOC()
+	toc.MCN = "0724384260729"
+	toc.Tracks[1].ISRC = "USEE10001993"
+	toc.Tracks[2].Session = 2
+
+	j := tocToJSON(toc, 0)
+	for _, want := range []string{`"mcn": "0724384260729"`, `"isrc": "USEE10001993"`, `"session": 2`} {
+		if !bytes.Contains(j, []byte(want)) {
+			t.Errorf("toc.json should contain %s:\n%s", want, j)
+		}
+	}
+}
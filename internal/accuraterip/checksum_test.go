@@ -0,0 +1,76 @@
+package accuraterip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+// constantPCM builds n frames where every stereo sample equals sample
+func constantPCM(n int, sample uint32) []byte {
+	pcm := make([]byte, n*SamplesPerFrame*BytesPerSample)
+	for i := 0; i < len(pcm); i += BytesPerSample {
+		binary.LittleEndian.PutUint32(pcm[i:], sample)
+	}
+	return pcm
+}
+
+func TestTrackCRCs_MiddleTrack(t *testing.T) {
+	v1, v2 := TrackCRCs(constantPCM(1, 1), false, false)
+
+	// Sum of multipliers 1..588
+	if v1 != 173166 || v2 != 173166 {
+		t.Errorf("CRCs = %d/%d, want 173166/173166", v1, v2)
+	}
+}
+
+func TestTrackCRCs_FirstTrackSkipsLeadIn(t *testing.T) {
+	v1, _ := TrackCRCs(constantPCM(10, 1), true, false)
+
+	// Sum of multipliers 2940..5880: the first 5 frames minus one sample are skipped
+	if v1 != 12969810 {
+		t.Errorf("v1 = %d, want 12969810", v1)
+	}
+}
+
+func TestTrackCRCs_LastTrackSkipsLeadOut(t *testing.T) {
+	v1, _ := TrackCRCs(constantPCM(10, 1), false, true)
+
+	// Sum of multipliers 1..2940: the last 5 frames are skipped
+	if v1 != 4323270 {
+		t.Errorf("v1 = %d, want 4323270", v1)
+	}
+}
+
+func TestTrackCRCs_V2FoldsHighBits(t *testing.T) {
+	v1, v2 := TrackCRCs(constantPCM(1, 0xFFFFFFFF), false, false)
+
+	// Each product m*(2^32-1) has low word -m and high word m-1:
+	// v1 sums the low words, v2 sums both (-1 per sample).
+	if v1 != uint32(-173166+(1<<32)) {
+		t.Errorf("v1 = %#x, want %#x", v1, uint32(-173166+(1<<32)))
+	}
+	if v2 != uint32(-588+(1<<32)) {
+		t.Errorf("v2 = %#x, want %#x", v2, uint32(-588+(1<<32)))
+	}
+}
+
+func TestTrackChecksum_ChunkedWrites(t *testing.T) {
+	pcm := constantPCM(12, 0)
+	for i := range pcm {
+		pcm[i] = byte(i * 7)
+	}
+	want1, want2 := TrackCRCs(pcm, true, true)
+
+	// Uneven chunks split samples across writes
+	c := NewTrackChecksum(12, true, true)
+	for r := bytes.NewReader(pcm); r.Len() > 0; {
+		buf := make([]byte, 1001)
+		n, _ := r.Read(buf)
+		c.Write(buf[:n])
+	}
+
+	if v1, v2 := c.Sums(); v1 != want1 || v2 != want2 {
+		t.Errorf("chunked = %#x/%#x, want %#x/%#x", v1, v2, want1, want2)
+	}
+}
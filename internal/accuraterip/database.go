@@ -0,0 +1,111 @@
+package accuraterip
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"time"
+)
+
+// DefaultBaseURL is the AccurateRip database root (HTTP only)
+const DefaultBaseURL = "http://www.accuraterip.com/accuraterip"
+
+var httpClient = &http.Client{
+	Timeout: 10 * time.Second,
+}
+
+// Entry is one pressing of a disc as submitted to AccurateRip
+type Entry struct {
+	DiscIDs
+	Tracks []TrackEntry
+}
+
+// TrackEntry is the database record for one track of a pressing
+type TrackEntry struct {
+	Confidence  int    // Number of matching submissions
+	CRC         uint32 // v1 or v2 track CRC
+	Frame450CRC uint32 // CRC of frame 450, used for offset detection
+}
+
+// Size of dBAR records
+const (
+	headerSize     = 13 // Track count + 3 disc IDs
+	trackEntrySize = 9  // Confidence + 2 CRCs
+)
+
+// ParseResponse parses a dBAR database file, which holds one or more
+// pressings back to back.
+// This is a pure function: bytes → []Entry.
+func ParseResponse(data []byte) ([]Entry, error) {
+	var entries []Entry
+
+	for len(data) > 0 {
+		if len(data) < headerSize {
+			return nil, errors.New("dBAR: truncated header")
+		}
+
+		e := Entry{DiscIDs: DiscIDs{
+			TrackCount: int(data[0]),
+			ID1:        binary.LittleEndian.Uint32(data[1:5]),
+			ID2:        binary.LittleEndian.Uint32(data[5:9]),
+			CDDB:       binary.LittleEndian.Uint32(data[9:13]),
+		}}
+		data = data[headerSize:]
+
+		if len(data) < e.TrackCount*trackEntrySize {
+			return nil, fmt.Errorf("dBAR: truncated entry for %d tracks", e.TrackCount)
+		}
+		for i := 0; i < e.TrackCount; i++ {
+			e.Tracks = append(e.Tracks, TrackEntry{
+				Confidence:  int(data[0]),
+				CRC:         binary.LittleEndian.Uint32(data[1:5]),
+				Frame450CRC: binary.LittleEndian.Uint32(data[5:9]),
+			})
+			data = data[trackEntrySize:]
+		}
+
+		entries = append(entries, e)
+	}
+
+	return entries, nil
+}
+
+// Fetch downloads and parses the database entries for a disc.
+// Returns (entries, nil) on success.
+// Returns (nil, nil) if the disc is not in the database (404).
+// Returns (nil, error) on network errors or a malformed response.
+func Fetch(baseURL string, ids DiscIDs) ([]Entry, error) {
+	url := baseURL + "/" + ids.Path()
+
+	resp, err := httpClient.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("AccurateRip fetch: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == 404 {
+		return nil, nil // Not found, not an error
+	}
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("AccurateRip: HTTP %d", resp.StatusCode)
+	}
+
+	data, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("AccurateRip read: %w", err)
+	}
+
+	return ParseResponse(data)
+}
+
+// Load reads and parses a dBAR file saved on disk
+func Load(path string) ([]Entry, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("AccurateRip load: %w", err)
+	}
+	return ParseResponse(data)
+}
@@ -0,0 +1,104 @@
+package accuraterip
+
+import (
+	"encoding/binary"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// buildResponse encodes entries as a dBAR file (the inverse of ParseResponse)
+func buildResponse(entries ...Entry) []byte {
+	var data []byte
+	for _, e := range entries {
+		data = append(data, byte(e.TrackCount))
+		data = binary.LittleEndian.AppendUint32(data, e.ID1)
+		data = binary.LittleEndian.AppendUint32(data, e.ID2)
+		data = binary.LittleEndian.AppendUint32(data, e.CDDB)
+		for _, t := range e.Tracks {
+			data = append(data, byte(t.Confidence))
+			data = binary.LittleEndian.AppendUint32(data, t.CRC)
+			data = binary.LittleEndian.AppendUint32(data, t.Frame450CRC)
+		}
+	}
+	return data
+}
+
+var testIDs = DiscIDs{TrackCount: 2, ID1: 0x1234, ID2: 0x5678, CDDB: 0x9ABC}
+
+var testEntries = []Entry{
+	{DiscIDs: testIDs, Tracks: []TrackEntry{{12, 0xAAAA, 0x1111}, {11, 0xBBBB, 0x2222}}},
+	{DiscIDs: testIDs, Tracks: []TrackEntry{{3, 0xCCCC, 0x3333}, {3, 0xDDDD, 0x4444}}},
+}
+
+func TestParseResponse(t *testing.T) {
+	entries, err := ParseResponse(buildResponse(testEntries...))
+	if err != nil {
+		t.Fatalf("ParseResponse error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Fatalf("got %d entries, want 2", len(entries))
+	}
+	if entries[0].DiscIDs != testIDs {
+		t.Errorf("IDs = %+v, want %+v", entries[0].DiscIDs, testIDs)
+	}
+	if entries[1].Tracks[1] != (TrackEntry{3, 0xDDDD, 0x4444}) {
+		t.Errorf("entry 2 track 2 = %+v", entries[1].Tracks[1])
+	}
+}
+
+func TestParseResponse_Truncated(t *testing.T) {
+	data := buildResponse(testEntries[0])
+
+	if _, err := ParseResponse(data[:len(data)-1]); err == nil {
+		t.Error("ParseResponse should fail on truncated track data")
+	}
+	if _, err := ParseResponse(data[:5]); err == nil {
+		t.Error("ParseResponse should fail on truncated header")
+	}
+}
+
+func TestFetch(t *testing.T) {
+	data := buildResponse(testEntries...)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/accuraterip/4/3/2/dBAR-002-00001234-00005678-00009abc.bin" {
+			http.NotFound(w, r)
+			return
+		}
+		w.Write(data)
+	}))
+	defer server.Close()
+
+	entries, err := Fetch(server.URL+"/accuraterip", testIDs)
+	if err != nil {
+		t.Fatalf("Fetch error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(entries))
+	}
+}
+
+func TestFetch_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	entries, err := Fetch(server.URL, testIDs)
+	if err != nil || entries != nil {
+		t.Errorf("Fetch() = %v, %v, want nil, nil", entries, err)
+	}
+}
+
+func TestLoad(t *testing.T) {
+	path := filepath.Join(t.TempDir(), testIDs.Filename())
+	os.WriteFile(path, buildResponse(testEntries...), 0644)
+
+	entries, err := Load(path)
+	if err != nil {
+		t.Fatalf("Load error: %v", err)
+	}
+	if len(entries) != 2 {
+		t.Errorf("got %d entries, want 2", len(entries))
+	}
+}
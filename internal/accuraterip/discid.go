@@ -0,0 +1,85 @@
+package accuraterip
+
+import (
+	"fmt"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+const (
+	framesPerSecond = 75
+	leadInFrames    = 150 // 2-second pregap before LBA 0, which CDDB counts in its offsets
+)
+
+// DiscIDs identifies a disc in the AccurateRip database
+type DiscIDs struct {
+	TrackCount int    // Audio tracks on the disc
+	ID1        uint32 // Sum of track offsets
+	ID2        uint32 // Sum of track offsets weighted by track number
+	CDDB       uint32 // FreeDB disc ID
+}
+
+// CalculateDiscIDs computes the AccurateRip disc IDs from a TOC.
+// This is a pure function: TOC → DiscIDs.
+//
+// Track LBAs are taken as MMC addresses (track 1 normally at 0), the way
+// READ TOC reports them.
+func CalculateDiscIDs(toc cdda.TOC) DiscIDs {
+	var ids DiscIDs
+
+	for _, t := range toc.Tracks {
+		if !t.IsAudio() {
+			continue
+		}
+		ids.TrackCount++
+
+		offset := uint32(t.LBA)
+		ids.ID1 += offset
+		ids.ID2 += max(offset, 1) * uint32(t.Num)
+	}
+
+	leadout := uint32(toc.LeadoutLBA)
+	ids.ID1 += leadout
+	ids.ID2 += leadout * uint32(ids.TrackCount+1)
+
+	ids.CDDB = cddbID(toc)
+
+	return ids
+}
+
+// cddbID computes the FreeDB disc ID: checksum of track start seconds,
+// total playing time, and track count.
+func cddbID(toc cdda.TOC) uint32 {
+	digitSum := func(n int) int {
+		sum := 0
+		for ; n > 0; n /= 10 {
+			sum += n % 10
+		}
+		return sum
+	}
+
+	sum := 0
+	for _, t := range toc.Tracks {
+		sum += digitSum((t.LBA + leadInFrames) / framesPerSecond)
+	}
+
+	var length int
+	if len(toc.Tracks) > 0 {
+		length = (toc.LeadoutLBA+leadInFrames)/framesPerSecond -
+			(toc.Tracks[0].LBA+leadInFrames)/framesPerSecond
+	}
+
+	return uint32(sum%0xFF)<<24 | uint32(length)<<8 | uint32(len(toc.Tracks))
+}
+
+// Filename returns the database file name for a disc, e.g.
+// "dBAR-011-001b9178-014be24e-b40d2d0b.bin".
+func (ids DiscIDs) Filename() string {
+	return fmt.Sprintf("dBAR-%03d-%08x-%08x-%08x.bin", ids.TrackCount, ids.ID1, ids.ID2, ids.CDDB)
+}
+
+// Path returns the database path of a disc relative to the AccurateRip
+// base URL. Files are sharded by the last three hex digits of ID1.
+func (ids DiscIDs) Path() string {
+	return fmt.Sprintf("%x/%x/%x/%s", ids.ID1&0xF, ids.ID1>>4&0xF, ids.ID1>>8&0xF, ids.Filename())
+}
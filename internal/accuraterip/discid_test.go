@@ -0,0 +1,57 @@
+package accuraterip
+
+import (
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func threeTrackTOC() cdda.TOC {
+	return cdda.TOC{
+		FirstTrack: 1,
+		LastTrack:  3,
+		LeadoutLBA: 30000,
+		Tracks: []cdda.Track{
+			{Num: 1, LBA: 0},
+			{Num: 2, LBA: 10000},
+			{Num: 3, LBA: 20000},
+		},
+	}
+}
+
+func TestCalculateDiscIDs(t *testing.T) {
+	ids := CalculateDiscIDs(threeTrackTOC())
+
+	want := DiscIDs{
+		TrackCount: 3,
+		ID1:        60000,  // 0 + 10000 + 20000 + 30000
+		ID2:        200001, // 1*1 + 10000*2 + 20000*3 + 30000*4
+		CDDB:       0x1B019003,
+	}
+	if ids != want {
+		t.Errorf("CalculateDiscIDs() = %+v, want %+v", ids, want)
+	}
+}
+
+func TestCalculateDiscIDs_SkipsDataTrack(t *testing.T) {
+	toc := threeTrackTOC()
+	toc.Tracks[2].Type = cdda.TrackTypeData
+
+	ids := CalculateDiscIDs(toc)
+
+	if ids.TrackCount != 2 {
+		t.Errorf("TrackCount = %d, want 2", ids.TrackCount)
+	}
+	if ids.ID1 != 40000 {
+		t.Errorf("ID1 = %d, want 40000", ids.ID1)
+	}
+}
+
+func TestDiscIDs_Path(t *testing.T) {
+	ids := DiscIDs{TrackCount: 3, ID1: 0xEA60, ID2: 0x30D41, CDDB: 0x1B019003}
+
+	want := "0/6/a/dBAR-003-0000ea60-00030d41-1b019003.bin"
+	if got := ids.Path(); got != want {
+		t.Errorf("Path() = %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,48 @@
+package accuraterip
+
+// Offset detection constants
+const (
+	// OffsetFrame is the track-relative frame whose CRC the database
+	// stores for offset detection.
+	OffsetFrame = 450
+
+	// MaxOffset bounds the read offsets searched, in samples (±5 frames).
+	// Known drive offsets are well inside this range.
+	MaxOffset = 5 * SamplesPerFrame
+
+	// OffsetSearchFrames is how much audio MatchOffsets needs: the offset
+	// frame plus MaxOffset samples on either side.
+	OffsetSearchFrames = 2*MaxOffset/SamplesPerFrame + 1
+)
+
+// FrameCRC computes the offset-detection CRC of one frame: the v1 CRC of its
+// 588 samples with no lead-in or lead-out exclusion.
+// This is a pure function: frame bytes → CRC.
+func FrameCRC(frame []byte) uint32 {
+	v1, _ := TrackCRCs(frame, false, false)
+	return v1
+}
+
+// MatchOffsets finds the read offsets at which any of crcs appears in pcm.
+// pcm is OffsetSearchFrames frames read without offset correction, centred on
+// a track's OffsetFrame; crcs are the database's offset CRCs for that track.
+// This is a pure function: (PCM, CRCs) → offsets in samples, ascending.
+func MatchOffsets(pcm []byte, crcs []uint32) []int {
+	want := make(map[uint32]bool, len(crcs))
+	for _, c := range crcs {
+		want[c] = true
+	}
+
+	frameBytes := SamplesPerFrame * BytesPerSample
+	var offsets []int
+	for o := -MaxOffset; o <= MaxOffset; o++ {
+		start := (MaxOffset + o) * BytesPerSample
+		if start+frameBytes > len(pcm) {
+			break
+		}
+		if want[FrameCRC(pcm[start:start+frameBytes])] {
+			offsets = append(offsets, o)
+		}
+	}
+	return offsets
+}
@@ -0,0 +1,35 @@
+package accuraterip
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+)
+
+func TestMatchOffsets(t *testing.T) {
+	pcm := make([]byte, OffsetSearchFrames*SamplesPerFrame*BytesPerSample)
+	rand.New(rand.NewSource(1)).Read(pcm)
+
+	// The drive reads 102 samples early, so the true frame sits 102 samples in
+	start := (MaxOffset + 102) * BytesPerSample
+	crc := FrameCRC(pcm[start : start+SamplesPerFrame*BytesPerSample])
+
+	got := MatchOffsets(pcm, []uint32{0xDEADBEEF, crc})
+	if !reflect.DeepEqual(got, []int{102}) {
+		t.Errorf("MatchOffsets() = %v, want [102]", got)
+	}
+}
+
+func TestMatchOffsets_Edges(t *testing.T) {
+	pcm := make([]byte, OffsetSearchFrames*SamplesPerFrame*BytesPerSample)
+	rand.New(rand.NewSource(2)).Read(pcm)
+	frame := SamplesPerFrame * BytesPerSample
+
+	first := FrameCRC(pcm[:frame])
+	last := FrameCRC(pcm[len(pcm)-frame:])
+
+	got := MatchOffsets(pcm, []uint32{first, last})
+	if !reflect.DeepEqual(got, []int{-MaxOffset, MaxOffset}) {
+		t.Errorf("MatchOffsets() = %v, want [%d %d]", got, -MaxOffset, MaxOffset)
+	}
+}
@@ -0,0 +1,58 @@
+package accuraterip
+
+// TrackCRC holds a ripped track's checksums
+type TrackCRC struct {
+	Num int    // Track number
+	V1  uint32 // AccurateRip v1 CRC
+	V2  uint32 // AccurateRip v2 CRC
+}
+
+// TrackResult is the verification outcome for one track
+type TrackResult struct {
+	TrackCRC
+	Confidence int // Total confidence of matching pressings (0 = no match)
+	Version    int // CRC version that matched (1 or 2), 0 if none
+}
+
+// Accurate reports whether the track matched at least one submission
+func (r TrackResult) Accurate() bool {
+	return r.Confidence > 0
+}
+
+// Verify compares ripped track CRCs against database entries for the disc.
+// This is a pure function: (IDs, CRCs, entries) → []TrackResult.
+//
+// Entries for a different disc are ignored. Track numbers index into each
+// entry's track list (track 1 is the first entry). Confidence is summed
+// across every pressing whose CRC matches; v2 wins if both versions match.
+func Verify(ids DiscIDs, crcs []TrackCRC, entries []Entry) []TrackResult {
+	results := make([]TrackResult, len(crcs))
+
+	for i, c := range crcs {
+		results[i].TrackCRC = c
+
+		for _, e := range entries {
+			if e.DiscIDs != ids {
+				continue
+			}
+			idx := c.Num - 1
+			if idx < 0 || idx >= len(e.Tracks) {
+				continue
+			}
+
+			t := e.Tracks[idx]
+			switch t.CRC {
+			case c.V2:
+				results[i].Confidence += t.Confidence
+				results[i].Version = 2
+			case c.V1:
+				results[i].Confidence += t.Confidence
+				if results[i].Version == 0 {
+					results[i].Version = 1
+				}
+			}
+		}
+	}
+
+	return results
+}
@@ -0,0 +1,38 @@
+package accuraterip
+
+import "testing"
+
+func TestVerify(t *testing.T) {
+	crcs := []TrackCRC{
+		{Num: 1, V1: 0xAAAA, V2: 0xCCCC}, // v1 in one pressing, v2 in the other
+		{Num: 2, V1: 0xBBBB, V2: 0x0001}, // v1 only
+	}
+
+	results := Verify(testIDs, crcs, testEntries)
+
+	if results[0].Confidence != 15 || results[0].Version != 2 {
+		t.Errorf("track 1 = confidence %d v%d, want 15 v2", results[0].Confidence, results[0].Version)
+	}
+	if results[1].Confidence != 11 || results[1].Version != 1 {
+		t.Errorf("track 2 = confidence %d v%d, want 11 v1", results[1].Confidence, results[1].Version)
+	}
+}
+
+func TestVerify_Mismatch(t *testing.T) {
+	results := Verify(testIDs, []TrackCRC{{Num: 2, V1: 1, V2: 2}}, testEntries)
+
+	if results[0].Accurate() {
+		t.Errorf("track 2 should not be accurate: %+v", results[0])
+	}
+}
+
+func TestVerify_IgnoresOtherDisc(t *testing.T) {
+	other := testIDs
+	other.ID1++
+
+	results := Verify(other, []TrackCRC{{Num: 1, V1: 0xAAAA}}, testEntries)
+
+	if results[0].Accurate() {
+		t.Error("entries for a different disc should not match")
+	}
+}
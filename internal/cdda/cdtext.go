@@ -0,0 +1,319 @@
+package cdda
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+
+	"golang.org/x/text/encoding"
+	"golang.org/x/text/encoding/charmap"
+	"golang.org/x/text/encoding/japanese"
+	"golang.org/x/text/encoding/korean"
+	"golang.org/x/text/encoding/simplifiedchinese"
+)
+
+// CDTextPackSize is the length of one CD-TEXT pack: a 4-byte header,
+// 12 bytes of payload and a 2-byte CRC
+const CDTextPackSize = 18
+
+// CD-TEXT pack types
+const (
+	packTitle      = 0x80
+	packPerformer  = 0x81
+	packSongwriter = 0x82
+	packComposer   = 0x83
+	packArranger   = 0x84
+	packMessage    = 0x85
+	packGenre      = 0x87
+	packCode       = 0x8E // UPC/EAN for the disc, ISRC for tracks
+	packSizeInfo   = 0x8F
+)
+
+// CD-TEXT character codes from the size info pack
+const (
+	CharsetISO8859_1 = 0x00
+	CharsetASCII     = 0x01
+	CharsetMSJIS     = 0x80 // Shift-JIS (double byte)
+	CharsetKorean    = 0x81 // EUC-KR (double byte)
+	CharsetMandarin  = 0x82 // GB 2312 (double byte)
+)
+
+// ErrNoCDText means the response held no pack with a valid CRC
+var ErrNoCDText = errors.New("no CD-TEXT")
+
+// CDTextEntry is the text for the disc (track 0) or one track
+type CDTextEntry struct {
+	Title      string
+	Performer  string
+	Songwriter string
+	Composer   string
+	Arranger   string
+	Message    string
+	Code       string // UPC/EAN for the disc, ISRC for a track
+}
+
+// CDTextBlock is one language of CD-TEXT. A disc carries up to 8 blocks;
+// block 0 is the default.
+type CDTextBlock struct {
+	Number   int                 // Block number (0-7)
+	Language byte                // EBU Tech 3258 code (0x09 = English, 0x69 = Japanese)
+	Charset  byte                // Character code (CharsetISO8859_1 if the size info pack is missing)
+	Genre    string              // Disc genre (text if given, otherwise the name of the genre code)
+	Album    CDTextEntry         // Disc-level text
+	Tracks   map[int]CDTextEntry // Track text by track number
+}
+
+// ParseCDText decodes a READ TOC format 5 response into its blocks, in
+// block order.
+// This is a pure function: bytes → blocks.
+//
+// Packs with a bad CRC are dropped. Some drives return zero CRCs, so a
+// zero CRC field is accepted. Returns ErrNoCDText if no pack survives.
+func ParseCDText(data []byte) ([]CDTextBlock, error) {
+	if len(data) < 4 {
+		return nil, fmt.Errorf("CD-TEXT data too short: %d bytes", len(data))
+	}
+	end := 2 + int(binary.BigEndian.Uint16(data[0:2]))
+	if end > len(data) {
+		end = len(data)
+	}
+
+	// Packs grouped by block, then pack type, in sequence order
+	type typeText struct {
+		dbcs    bool
+		packs   []cdTextPack
+		payload []byte // All payloads joined
+	}
+	blocks := make(map[int]map[byte]*typeText)
+	var order []int
+
+	for off := 4; off+CDTextPackSize <= end; off += CDTextPackSize {
+		pack := data[off : off+CDTextPackSize]
+		if pack[0] < packTitle || pack[0] > packSizeInfo || !cdTextCRCValid(pack) {
+			continue
+		}
+		block := int(pack[3]>>4) & 0x07
+		types, ok := blocks[block]
+		if !ok {
+			types = make(map[byte]*typeText)
+			blocks[block] = types
+			order = append(order, block)
+		}
+		tt, ok := types[pack[0]]
+		if !ok {
+			tt = &typeText{dbcs: pack[3]&0x80 != 0}
+			types[pack[0]] = tt
+		}
+		tt.packs = append(tt.packs, cdTextPack{
+			track:   int(pack[1] & 0x7F),
+			pos:     int(pack[3] & 0x0F),
+			payload: pack[4:16],
+		})
+		tt.payload = append(tt.payload, pack[4:16]...)
+	}
+	if len(order) == 0 {
+		return nil, ErrNoCDText
+	}
+	sort.Ints(order)
+
+	result := make([]CDTextBlock, 0, len(order))
+	for _, num := range order {
+		types := blocks[num]
+		b := CDTextBlock{Number: num, Tracks: make(map[int]CDTextEntry)}
+
+		if info, ok := types[packSizeInfo]; ok && len(info.payload) >= 36 {
+			b.Charset = info.payload[0]
+			b.Language = info.payload[28+num]
+		}
+		dbcs := isDoubleByte(b.Charset)
+
+		fields := []struct {
+			packType byte
+			set      func(*CDTextEntry, string)
+		}{
+			{packTitle, func(e *CDTextEntry, s string) { e.Title = s }},
+			{packPerformer, func(e *CDTextEntry, s string) { e.Performer = s }},
+			{packSongwriter, func(e *CDTextEntry, s string) { e.Songwriter = s }},
+			{packComposer, func(e *CDTextEntry, s string) { e.Composer = s }},
+			{packArranger, func(e *CDTextEntry, s string) { e.Arranger = s }},
+			{packMessage, func(e *CDTextEntry, s string) { e.Message = s }},
+			{packCode, func(e *CDTextEntry, s string) { e.Code = s }},
+		}
+		for _, f := range fields {
+			tt, ok := types[f.packType]
+			if !ok {
+				continue
+			}
+			charset := b.Charset
+			wide := dbcs || tt.dbcs
+			if f.packType == packCode {
+				charset, wide = CharsetASCII, false // Codes are always ASCII
+			}
+			for track, s := range cdTextStrings(tt.packs, wide) {
+				text := decodeCDText(s, charset)
+				if track == 0 {
+					f.set(&b.Album, text)
+					continue
+				}
+				e := b.Tracks[track]
+				f.set(&e, text)
+				b.Tracks[track] = e
+			}
+		}
+
+		if tt, ok := types[packGenre]; ok && len(tt.payload) >= 2 {
+			code := int(binary.BigEndian.Uint16(tt.payload[0:2]))
+			if strs := splitCDTextStrings(tt.payload[2:], false); len(strs) > 0 && len(strs[0]) > 0 {
+				b.Genre = decodeCDText(strs[0], b.Charset)
+			} else if code > 1 && code < len(cdTextGenres) {
+				b.Genre = cdTextGenres[code]
+			}
+		}
+
+		result = append(result, b)
+	}
+	return result, nil
+}
+
+// cdTextPack is the header and payload of one pack of text
+type cdTextPack struct {
+	track   int // Track of the first character in the payload
+	pos     int // Characters of that track's string in earlier packs (15 = 15 or more)
+	payload []byte
+}
+
+// cdTextStrings splits the packs of one type into a string per track.
+// Each pack's header says where its text belongs, so a string is only
+// kept if every pack holding it survived: after a dropped pack, the text
+// resyncs on the next pack's header instead of shifting later strings to
+// the wrong tracks. A TAB string repeats the previous track's string.
+// Double-byte positions are accepted in characters or bytes.
+func cdTextStrings(packs []cdTextPack, wide bool) map[int][]byte {
+	width := 1
+	if wide {
+		width = 2
+	}
+
+	strs := make(map[int][]byte)
+	track, chars := 0, 0
+	var cur []byte
+	whole := false // cur holds the string from its start
+	for _, p := range packs {
+		continued := p.track == track && (p.pos == min(chars, 15) || p.pos == min(chars*width, 15))
+		if !whole || !continued {
+			track, chars, cur, whole = p.track, 0, nil, p.pos == 0
+		}
+
+		for i := 0; i+width <= len(p.payload); i += width {
+			ch := p.payload[i : i+width]
+			if ch[0] != 0 || ch[width-1] != 0 {
+				cur = append(cur, ch...)
+				chars++
+				continue
+			}
+			if len(cur) == width && cur[0] == '\t' {
+				cur = strs[track-1]
+			}
+			if whole && len(cur) > 0 {
+				strs[track] = cur
+			}
+			track, chars, cur, whole = track+1, 0, nil, true
+		}
+	}
+	return strs
+}
+
+// splitCDTextStrings splits concatenated pack payloads into one string per
+// track. A TAB string repeats the previous track's string; nil marks an
+// empty string. Trailing padding is dropped.
+func splitCDTextStrings(payload []byte, wide bool) [][]byte {
+	width := 1
+	if wide {
+		width = 2
+	}
+
+	var strs [][]byte
+	var cur []byte
+	for i := 0; i+width <= len(payload); i += width {
+		ch := payload[i : i+width]
+		if ch[0] != 0 || ch[width-1] != 0 {
+			cur = append(cur, ch...)
+			continue
+		}
+		if len(cur) == width && cur[0] == '\t' && len(strs) > 0 {
+			cur = strs[len(strs)-1]
+		}
+		strs = append(strs, cur)
+		cur = nil
+	}
+
+	// Drop trailing empty strings from the zero padding of the last pack
+	for len(strs) > 0 && strs[len(strs)-1] == nil {
+		strs = strs[:len(strs)-1]
+	}
+	return strs
+}
+
+// decodeCDText converts text in a CD-TEXT character code to UTF-8.
+// Undecodable text falls back to ISO-8859-1.
+func decodeCDText(b []byte, charset byte) string {
+	var enc encoding.Encoding
+	switch charset {
+	case CharsetASCII:
+		return string(b)
+	case CharsetMSJIS:
+		enc = japanese.ShiftJIS
+	case CharsetKorean:
+		enc = korean.EUCKR
+	case CharsetMandarin:
+		enc = simplifiedchinese.GBK
+	default:
+		enc = charmap.ISO8859_1
+	}
+	s, err := enc.NewDecoder().Bytes(b)
+	if err != nil {
+		s, _ = charmap.ISO8859_1.NewDecoder().Bytes(b)
+	}
+	return string(s)
+}
+
+// isDoubleByte reports whether the character code uses two bytes per character
+func isDoubleByte(charset byte) bool {
+	return charset == CharsetMSJIS || charset == CharsetKorean || charset == CharsetMandarin
+}
+
+// cdTextCRCValid checks the pack's CRC-16/CCITT, which is stored inverted
+// and big-endian in the last two bytes
+func cdTextCRCValid(pack []byte) bool {
+	stored := binary.BigEndian.Uint16(pack[16:18])
+	return stored == 0 || stored == CDTextCRC(pack[:16])
+}
+
+// CDTextCRC computes the CRC of a pack's first 16 bytes as stored in its
+// last two: CRC-16/CCITT (polynomial 0x1021, initial 0), inverted.
+// This is a pure function: bytes → CRC.
+func CDTextCRC(data []byte) uint16 {
+	var crc uint16
+	for _, b := range data {
+		crc ^= uint16(b) << 8
+		for i := 0; i < 8; i++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return ^crc
+}
+
+// cdTextGenres names the CD-TEXT genre codes (0 = unused, 1 = undefined)
+var cdTextGenres = []string{
+	"", "",
+	"Adult Contemporary", "Alternative Rock", "Childrens Music", "Classical",
+	"Contemporary Christian", "Country", "Dance", "Easy Listening", "Erotic",
+	"Folk", "Gospel", "Hip Hop", "Jazz", "Latin", "Musical", "New Age",
+	"Opera", "Operetta", "Pop Music", "Rap", "Reggae", "Rock Music",
+	"Rhythm & Blues", "Sound Effects", "Spoken Word", "World Music",
+}
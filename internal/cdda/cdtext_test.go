@@ -0,0 +1,204 @@
+package cdda
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"testing"
+)
+
+// cdTextPacks encodes null-terminated single-byte strings for consecutive
+// tracks as packs of one type in one block, with valid CRCs and each
+// pack's track number and character position
+func cdTextPacks(block int, packType byte, firstTrack int, text string) [][]byte {
+	payload := []byte(text)
+	for len(payload)%12 != 0 {
+		payload = append(payload, 0)
+	}
+
+	var packs [][]byte
+	for i := 0; i < len(payload); i += 12 {
+		done := payload[:i]
+		track := firstTrack + bytes.Count(done, []byte{0})
+		pos := len(done) - (bytes.LastIndexByte(done, 0) + 1)
+
+		pack := make([]byte, CDTextPackSize)
+		pack[0] = packType
+		pack[1] = byte(track)
+		pack[2] = byte(len(packs))
+		pack[3] = byte(block<<4 | min(pos, 15))
+		copy(pack[4:16], payload[i:i+12])
+		binary.BigEndian.PutUint16(pack[16:18], CDTextCRC(pack[:16]))
+		packs = append(packs, pack)
+	}
+	return packs
+}
+
+// cdTextSizeInfo encodes the three size info packs of a block
+func cdTextSizeInfo(block int, charset byte, languages ...byte) [][]byte {
+	info := make([]byte, 36)
+	info[0] = charset
+	copy(info[28:], languages)
+	return cdTextPacks(block, packSizeInfo, 0, string(info))
+}
+
+// cdTextResponse wraps packs in a READ TOC format 5 header
+func cdTextResponse(groups ...[][]byte) []byte {
+	data := make([]byte, 4)
+	for _, packs := range groups {
+		for _, p := range packs {
+			data = append(data, p...)
+		}
+	}
+	binary.BigEndian.PutUint16(data[0:2], uint16(len(data)-2))
+	return data
+}
+
+func TestCDTextCRC(t *testing.T) {
+	// CRC-16/XMODEM("123456789") = 0x31C3, stored inverted
+	if got := CDTextCRC([]byte("123456789")); got != ^uint16(0x31C3) {
+		t.Errorf("CDTextCRC = 0x%04X, want 0x%04X", got, ^uint16(0x31C3))
+	}
+}
+
+func TestParseCDText_TitlesAndPerformers(t *testing.T) {
+	data := cdTextResponse(
+		cdTextPacks(0, packTitle, 0, "Album Title\x00First Song\x00A Much Longer Second Song Title\x00"),
+		cdTextPacks(0, packPerformer, 0, "The Band\x00\t\x00Guest Singer\x00"),
+		cdTextSizeInfo(0, CharsetISO8859_1, 0x09),
+	)
+
+	blocks, err := ParseCDText(data)
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if len(blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(blocks))
+	}
+
+	b := blocks[0]
+	if b.Language != 0x09 {
+		t.Errorf("Language = 0x%02X, want 0x09", b.Language)
+	}
+	if b.Album.Title != "Album Title" || b.Album.Performer != "The Band" {
+		t.Errorf("Album = %+v", b.Album)
+	}
+	if b.Tracks[1].Title != "First Song" || b.Tracks[1].Performer != "The Band" {
+		t.Errorf("track 1 = %+v, want TAB to repeat the disc performer", b.Tracks[1])
+	}
+	if b.Tracks[2].Title != "A Much Longer Second Song Title" || b.Tracks[2].Performer != "Guest Singer" {
+		t.Errorf("track 2 = %+v", b.Tracks[2])
+	}
+	if len(b.Tracks) != 2 {
+		t.Errorf("got %d tracks, want 2 (padding is not a track)", len(b.Tracks))
+	}
+}
+
+func TestParseCDText_BadCRCDropped(t *testing.T) {
+	titles := cdTextPacks(0, packTitle, 0, "Album\x00Song\x00")
+	titles[0][16] ^= 0xFF
+
+	blocks, err := ParseCDText(cdTextResponse(titles, cdTextPacks(0, packPerformer, 0, "Band\x00")))
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if blocks[0].Album.Title != "" || blocks[0].Album.Performer != "Band" {
+		t.Errorf("Album = %+v, want only the performer", blocks[0].Album)
+	}
+}
+
+func TestParseCDText_BadCRCMidString(t *testing.T) {
+	// The second pack holds "First Song" and the start of "Second Song"
+	titles := cdTextPacks(0, packTitle, 0, "Album Title\x00First Song\x00Second Song\x00Third Song\x00")
+	titles[1][16] ^= 0xFF
+
+	blocks, err := ParseCDText(cdTextResponse(titles))
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+
+	// The strings the lost pack touched are dropped; the rest stay on their tracks
+	b := blocks[0]
+	if b.Album.Title != "Album Title" {
+		t.Errorf("Album.Title = %q, want %q", b.Album.Title, "Album Title")
+	}
+	for track, want := range map[int]string{1: "", 2: "", 3: "Third Song"} {
+		if got := b.Tracks[track].Title; got != want {
+			t.Errorf("track %d title = %q, want %q", track, got, want)
+		}
+	}
+}
+
+func TestParseCDText_ZeroCRCAccepted(t *testing.T) {
+	titles := cdTextPacks(0, packTitle, 0, "Album\x00")
+	titles[0][16], titles[0][17] = 0, 0
+
+	blocks, err := ParseCDText(cdTextResponse(titles))
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if blocks[0].Album.Title != "Album" {
+		t.Errorf("Album.Title = %q, want %q", blocks[0].Album.Title, "Album")
+	}
+}
+
+func TestParseCDText_MultipleBlocks(t *testing.T) {
+	data := cdTextResponse(
+		cdTextPacks(0, packTitle, 0, "Winter\x00Snow\x00"),
+		cdTextSizeInfo(0, CharsetISO8859_1, 0x09, 0x69),
+		cdTextPacks(1, packTitle, 0, "\x93\x7e\x00\x00\x90\xe1\x00\x00"), // 冬, 雪
+		cdTextSizeInfo(1, CharsetMSJIS, 0x09, 0x69),
+	)
+
+	blocks, err := ParseCDText(data)
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if len(blocks) != 2 {
+		t.Fatalf("got %d blocks, want 2", len(blocks))
+	}
+	if blocks[0].Album.Title != "Winter" || blocks[0].Language != 0x09 {
+		t.Errorf("block 0 = %q (0x%02X), want Winter (English)", blocks[0].Album.Title, blocks[0].Language)
+	}
+	if blocks[1].Album.Title != "冬" || blocks[1].Tracks[1].Title != "雪" || blocks[1].Language != 0x69 {
+		t.Errorf("block 1 = %q/%q (0x%02X), want 冬/雪 (Japanese)",
+			blocks[1].Album.Title, blocks[1].Tracks[1].Title, blocks[1].Language)
+	}
+}
+
+func TestParseCDText_ISO8859_1(t *testing.T) {
+	blocks, err := ParseCDText(cdTextResponse(cdTextPacks(0, packTitle, 0, "Caf\xe9\x00")))
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if blocks[0].Album.Title != "Café" {
+		t.Errorf("Album.Title = %q, want %q", blocks[0].Album.Title, "Café")
+	}
+}
+
+func TestParseCDText_CodesAndGenre(t *testing.T) {
+	data := cdTextResponse(
+		cdTextPacks(0, packCode, 0, "0724384260729\x00USEE10001993\x00"),
+		cdTextPacks(0, packGenre, 0, "\x00\x0e\x00"),
+	)
+
+	blocks, err := ParseCDText(data)
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if blocks[0].Album.Code != "0724384260729" || blocks[0].Tracks[1].Code != "USEE10001993" {
+		t.Errorf("codes = %q/%q", blocks[0].Album.Code, blocks[0].Tracks[1].Code)
+	}
+	if blocks[0].Genre != "Jazz" {
+		t.Errorf("Genre = %q, want Jazz", blocks[0].Genre)
+	}
+}
+
+func TestParseCDText_Empty(t *testing.T) {
+	if _, err := ParseCDText(cdTextResponse()); !errors.Is(err, ErrNoCDText) {
+		t.Errorf("error = %v, want ErrNoCDText", err)
+	}
+	if _, err := ParseCDText([]byte{0}); err == nil {
+		t.Error("expected error for truncated header")
+	}
+}
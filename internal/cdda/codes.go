@@ -0,0 +1,92 @@
+package cdda
+
+// MCNLength and ISRCLength are the lengths of a media catalog number and an ISRC
+const (
+	MCNLength  = 13
+	ISRCLength = 12
+)
+
+// ParseMCN extracts the media catalog number from a READ SUB-CHANNEL
+// (format 02h) response.
+// This is a pure function: bytes → MCN.
+// Returns "" if the drive found none (MCVal clear) or it is all zeros,
+// which is how many discs mark a missing catalog number.
+func ParseMCN(data []byte) string {
+	if len(data) < 9+MCNLength || data[8]&0x80 == 0 {
+		return ""
+	}
+	mcn := string(data[9 : 9+MCNLength])
+	for _, c := range mcn {
+		if c < '0' || c > '9' {
+			return ""
+		}
+	}
+	if mcn == "0000000000000" {
+		return ""
+	}
+	return mcn
+}
+
+// ParseISRC extracts a track's ISRC from a READ SUB-CHANNEL (format 03h)
+// response.
+// This is a pure function: bytes → ISRC.
+// Returns "" if the drive found none (TCVal clear) or it is malformed.
+func ParseISRC(data []byte) string {
+	if len(data) < 9+ISRCLength || data[8]&0x80 == 0 {
+		return ""
+	}
+	isrc := string(data[9 : 9+ISRCLength])
+	if !ValidISRC(isrc) {
+		return ""
+	}
+	return isrc
+}
+
+// ValidISRC reports whether s looks like an ISRC: a 2-letter country code,
+// a 3-character registrant code, then 7 digits (year and designation), not
+// all zeros.
+// This is a pure function: string → bool.
+func ValidISRC(s string) bool {
+	if len(s) != ISRCLength || s[5:] == "0000000" {
+		return false
+	}
+	for i := 0; i < ISRCLength; i++ {
+		c := s[i]
+		letter := c >= 'A' && c <= 'Z'
+		digit := c >= '0' && c <= '9'
+		switch {
+		case i < 2 && !letter:
+			return false
+		case i >= 2 && i < 5 && !letter && !digit:
+			return false
+		case i >= 5 && !digit:
+			return false
+		}
+	}
+	return true
+}
+
+// ReadCodes fills in the TOC's MCN and the ISRC of each audio track using
+// readMCN and readISRC, which return raw READ SUB-CHANNEL responses.
+// Tracks whose ISRC can't be read are left without one; an error reading
+// the MCN is returned with the TOC unchanged.
+func ReadCodes(toc TOC, readMCN func() ([]byte, error), readISRC func(track int) ([]byte, error)) (TOC, error) {
+	data, err := readMCN()
+	if err != nil {
+		return toc, err
+	}
+
+	result := toc
+	result.MCN = ParseMCN(data)
+	result.Tracks = make([]Track, len(toc.Tracks))
+	copy(result.Tracks, toc.Tracks)
+	for i, t := range result.Tracks {
+		if !t.IsAudio() {
+			continue
+		}
+		if data, err := readISRC(t.Num); err == nil {
+			result.Tracks[i].ISRC = ParseISRC(data)
+		}
+	}
+	return result, nil
+}
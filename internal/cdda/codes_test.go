@@ -0,0 +1,112 @@
+package cdda
+
+import (
+	"errors"
+	"testing"
+)
+
+// subChannelResponse builds a READ SUB-CHANNEL MCN/ISRC response
+func subChannelResponse(format byte, code string) []byte {
+	data := make([]byte, 24)
+	data[3] = 20
+	data[4] = format
+	if code != "" {
+		data[8] = 0x80
+		copy(data[9:], code)
+	}
+	return data
+}
+
+func TestParseMCN(t *testing.T) {
+	tests := []struct {
+		name string
+		data []byte
+		want string
+	}{
+		{"valid", subChannelResponse(0x02, "0724384260729"), "0724384260729"},
+		{"not valid", subChannelResponse(0x02, ""), ""},
+		{"all zeros", subChannelResponse(0x02, "0000000000000"), ""},
+		{"not digits", subChannelResponse(0x02, "07243842607AB"), ""},
+		{"too short", []byte{0, 0, 0, 20, 2}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := ParseMCN(tt.data); got != tt.want {
+				t.Errorf("ParseMCN = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseISRC(t *testing.T) {
+	if got := ParseISRC(subChannelResponse(0x03, "USEE10001993")); got != "USEE10001993" {
+		t.Errorf("ParseISRC = %q, want USEE10001993", got)
+	}
+	if got := ParseISRC(subChannelResponse(0x03, "")); got != "" {
+		t.Errorf("ParseISRC without TCVal = %q, want empty", got)
+	}
+}
+
+func TestValidISRC(t *testing.T) {
+	tests := map[string]bool{
+		"USEE10001993": true,
+		"GBAYE6700012": true,
+		"usee10001993": false, // Lowercase country
+		"USEE1000199":  false, // Too short
+		"USEE1000199X": false, // Letter in designation
+		"US-E10001993": false,
+		"USEE10000000": false, // Zeroed
+	}
+	for s, want := range tests {
+		if got := ValidISRC(s); got != want {
+			t.Errorf("ValidISRC(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
+
+func TestReadCodes(t *testing.T) {
+	toc := TOC{Tracks: []Track{
+		{Num: 1, LBA: 0},
+		{Num: 2, LBA: 1000},
+		{Num: 3, LBA: 2000, Type: TrackTypeData},
+	}}
+	isrcs := map[int]string{1: "USEE10001993"}
+	var asked []int
+
+	got, err := ReadCodes(toc,
+		func() ([]byte, error) { return subChannelResponse(0x02, "0724384260729"), nil },
+		func(track int) ([]byte, error) {
+			asked = append(asked, track)
+			if track == 2 {
+				return nil, errors.New("read failed")
+			}
+			return subChannelResponse(0x03, isrcs[track]), nil
+		})
+	if err != nil {
+		t.Fatalf("ReadCodes error: %v", err)
+	}
+
+	if got.MCN != "0724384260729" {
+		t.Errorf("MCN = %q", got.MCN)
+	}
+	if got.Tracks[0].ISRC != "USEE10001993" || got.Tracks[1].ISRC != "" {
+		t.Errorf("ISRCs = %q, %q", got.Tracks[0].ISRC, got.Tracks[1].ISRC)
+	}
+	if len(asked) != 2 {
+		t.Errorf("asked for ISRCs of %v, want audio tracks 1 and 2 only", asked)
+	}
+	if toc.Tracks[0].ISRC != "" {
+		t.Error("ReadCodes should not modify its input")
+	}
+}
+
+func TestReadCodes_MCNError(t *testing.T) {
+	toc := TOC{Tracks: []Track{{Num: 1}}}
+
+	_, err := ReadCodes(toc,
+		func() ([]byte, error) { return nil, errors.New("illegal request") },
+		func(int) ([]byte, error) { t.Fatal("ISRC read after MCN failure"); return nil, nil })
+	if err == nil {
+		t.Error("expected error when the MCN can't be read")
+	}
+}
@@ -0,0 +1,399 @@
+package cdda
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// ImageName is the file name of a single-image rip (cd-rip --image)
+const ImageName = "disc.wav"
+
+// CUEOptions controls the layout and metadata of a cue sheet
+type CUEOptions struct {
+	// ImageFile names a single WAV holding the whole disc (see ImageRange).
+	// If empty, each track has its own file named by TrackFile.
+	ImageFile string
+
+	// TrackFile names the file for a track number (0 = hidden track one
+	// audio). Defaults to track%02d.wav.
+	TrackFile func(num int) string
+
+	// PrependGaps says each track's file starts at its INDEX 00, as ripped
+	// with TrackRange(..., true). By default pregaps are at the end of the
+	// previous track's file.
+	PrependGaps bool
+
+	// HiddenTrack says track 1's pregap audio was ripped: at the start of the
+	// image, or to TrackFile(0). Otherwise it is declared with PREGAP.
+	HiddenTrack bool
+
+	Catalog   string           // Media catalog number (CATALOG)
+	Title     string           // Disc title (CD-TEXT)
+	Performer string           // Disc performer (CD-TEXT)
+	Tracks    map[int]CUETrack // Per-track metadata by track number
+}
+
+// CUETrack holds optional per-track cue sheet metadata
+type CUETrack struct {
+	Title     string
+	Performer string
+	ISRC      string
+}
+
+// ImageRange returns the LBA range [start, end) of a single-image rip: from
+// the first audio track (including its pregap if withHidden) to the end of
+// the last audio track. ok is false if the disc has no audio.
+// This is a pure function.
+func ImageRange(toc TOC, withHidden bool) (int, int, bool) {
+	first, last := -1, -1
+	for i, t := range toc.Tracks {
+		if t.IsAudio() {
+			if first < 0 {
+				first = i
+			}
+			last = i
+		}
+	}
+	if first < 0 {
+		return 0, 0, false
+	}
+
+	start, _ := TrackRange(toc, first, false)
+	if withHidden && first == 0 {
+		start = toc.Tracks[0].PregapLBA()
+	}
+	_, end := TrackRange(toc, last, false)
+	return start, end, true
+}
+
+// WriteCUE creates a cue sheet for the audio tracks of a disc.
+// This is a pure function: (TOC, options) → cue sheet bytes.
+//
+// Index times are relative to the start of the file they fall in. Data
+// tracks are left out, since only audio is ripped.
+func WriteCUE(toc TOC, opts CUEOptions) []byte {
+	if opts.TrackFile == nil {
+		opts.TrackFile = func(num int) string { return fmt.Sprintf("track%02d.wav", num) }
+	}
+
+	var sb strings.Builder
+	line := func(indent int, format string, args ...any) {
+		sb.WriteString(strings.Repeat("  ", indent))
+		fmt.Fprintf(&sb, format, args...)
+		sb.WriteString("\r\n")
+	}
+
+	if opts.Catalog != "" {
+		line(0, "CATALOG %s", opts.Catalog)
+	}
+	if opts.Performer != "" {
+		line(0, "PERFORMER %s", cueQuote(opts.Performer))
+	}
+	if opts.Title != "" {
+		line(0, "TITLE %s", cueQuote(opts.Title))
+	}
+
+	fileStart := 0 // LBA where the current file begins
+	file := func(name string, start int) {
+		line(0, "FILE %s WAVE", cueQuote(name))
+		fileStart = start
+	}
+	index := func(n, lba int) {
+		line(2, "INDEX %02d %s", n, cueMSF(lba-fileStart))
+	}
+
+	imageStart, _, _ := ImageRange(toc, opts.HiddenTrack)
+	if opts.ImageFile != "" {
+		file(opts.ImageFile, imageStart)
+	}
+
+	prevAudio := false
+	for i, t := range toc.Tracks {
+		if !t.IsAudio() {
+			prevAudio = false
+			continue
+		}
+
+		// Where this track's pregap audio was ripped, if anywhere
+		var gapRipped, gapInPrevFile bool
+		switch {
+		case t.Pregap == 0:
+		case opts.ImageFile != "":
+			gapRipped = t.PregapLBA() >= imageStart
+		case i == 0:
+			gapRipped = opts.HiddenTrack // In TrackFile(0)
+		case opts.PrependGaps:
+			gapRipped = true // At the start of this track's file
+		default:
+			gapRipped = prevAudio
+			gapInPrevFile = prevAudio
+		}
+
+		if opts.ImageFile == "" && !gapInPrevFile {
+			switch {
+			case i == 0 && gapRipped:
+				file(opts.TrackFile(0), t.PregapLBA())
+			case gapRipped:
+				file(opts.TrackFile(t.Num), t.PregapLBA())
+			default:
+				file(opts.TrackFile(t.Num), t.LBA)
+			}
+		}
+
+		line(1, "TRACK %02d AUDIO", t.Num)
+		meta := opts.Tracks[t.Num]
+		if meta.Title != "" {
+			line(2, "TITLE %s", cueQuote(meta.Title))
+		}
+		if meta.Performer != "" {
+			line(2, "PERFORMER %s", cueQuote(meta.Performer))
+		}
+		if meta.ISRC != "" {
+			line(2, "ISRC %s", meta.ISRC)
+		}
+
+		if gapRipped {
+			index(0, t.PregapLBA())
+		} else if t.Pregap > 0 {
+			line(2, "PREGAP %s", cueMSF(t.Pregap))
+		}
+
+		// Hidden audio and appended gaps end in a different file than INDEX 01
+		if opts.ImageFile == "" && gapRipped && (i == 0 || gapInPrevFile) {
+			file(opts.TrackFile(t.Num), t.LBA)
+		}
+		index(1, t.LBA)
+		for k, lba := range t.Indexes {
+			index(k+2, lba)
+		}
+
+		prevAudio = true
+	}
+
+	return []byte(sb.String())
+}
+
+// cueMSF formats a frame count as mm:ss:ff
+func cueMSF(frames int) string {
+	return fmt.Sprintf("%02d:%02d:%02d", frames/75/60, frames/75%60, frames%75)
+}
+
+// cueQuote quotes a cue sheet string. The format has no escapes, so double
+// quotes become single quotes.
+func cueQuote(s string) string {
+	return `"` + strings.ReplaceAll(s, `"`, "'") + `"`
+}
+
+// CUESheet is a parsed cue sheet
+type CUESheet struct {
+	Catalog   string
+	Title     string
+	Performer string
+	Tracks    []CUESheetTrack
+}
+
+// CUESheetTrack is one TRACK entry of a cue sheet
+type CUESheetTrack struct {
+	Num int
+	CUETrack
+	Pregap  int        // PREGAP length in frames (audio not in any file)
+	Indexes []CUEIndex // In cue sheet order
+}
+
+// CUEIndex is an INDEX point: a position within a file
+type CUEIndex struct {
+	Num    int
+	File   string
+	Frames int // Offset from the start of File
+}
+
+// Index returns the index point numbered n, if present
+func (t CUESheetTrack) Index(n int) (CUEIndex, bool) {
+	for _, idx := range t.Indexes {
+		if idx.Num == n {
+			return idx, true
+		}
+	}
+	return CUEIndex{}, false
+}
+
+// ParseCUE parses a cue sheet as written by WriteCUE (and most rippers).
+// This is a pure function: bytes → CUESheet.
+// Unknown commands and REM lines are ignored.
+func ParseCUE(data []byte) (CUESheet, error) {
+	var sheet CUESheet
+	var file string
+	var track *CUESheetTrack
+
+	for n, raw := range strings.Split(string(data), "\n") {
+		fields := cueFields(strings.TrimSpace(raw))
+		if len(fields) == 0 {
+			continue
+		}
+		arg := func(i int) string {
+			if i < len(fields) {
+				return fields[i]
+			}
+			return ""
+		}
+		lineErr := func(format string, args ...any) error {
+			return fmt.Errorf("cue line %d: %s", n+1, fmt.Sprintf(format, args...))
+		}
+
+		switch strings.ToUpper(fields[0]) {
+		case "CATALOG":
+			sheet.Catalog = arg(1)
+		case "TITLE":
+			if track != nil {
+				track.Title = arg(1)
+			} else {
+				sheet.Title = arg(1)
+			}
+		case "PERFORMER":
+			if track != nil {
+				track.Performer = arg(1)
+			} else {
+				sheet.Performer = arg(1)
+			}
+		case "FILE":
+			file = arg(1)
+		case "TRACK":
+			num, err := strconv.Atoi(arg(1))
+			if err != nil {
+				return CUESheet{}, lineErr("bad track number %q", arg(1))
+			}
+			sheet.Tracks = append(sheet.Tracks, CUESheetTrack{Num: num})
+			track = &sheet.Tracks[len(sheet.Tracks)-1]
+		case "ISRC":
+			if track != nil {
+				track.ISRC = arg(1)
+			}
+		case "PREGAP", "INDEX":
+			if track == nil {
+				return CUESheet{}, lineErr("%s before TRACK", fields[0])
+			}
+			if strings.EqualFold(fields[0], "PREGAP") {
+				frames, err := parseCueMSF(arg(1))
+				if err != nil {
+					return CUESheet{}, lineErr("%v", err)
+				}
+				track.Pregap = frames
+				continue
+			}
+			num, err := strconv.Atoi(arg(1))
+			if err != nil {
+				return CUESheet{}, lineErr("bad index number %q", arg(1))
+			}
+			frames, err := parseCueMSF(arg(2))
+			if err != nil {
+				return CUESheet{}, lineErr("%v", err)
+			}
+			track.Indexes = append(track.Indexes, CUEIndex{Num: num, File: file, Frames: frames})
+		}
+	}
+
+	return sheet, nil
+}
+
+// ImageTrackRanges returns the [start, end) frame range of each track in a
+// single-image cue sheet, with pregaps at the end of the previous track (so
+// the tracks are contiguous). imageFrames is the length of the image.
+// This is a pure function.
+func ImageTrackRanges(sheet CUESheet, imageFrames int) ([][2]int, error) {
+	ranges := make([][2]int, len(sheet.Tracks))
+	var file string
+
+	for i, t := range sheet.Tracks {
+		idx, ok := t.Index(1)
+		if !ok {
+			return nil, fmt.Errorf("track %d has no INDEX 01", t.Num)
+		}
+		if i == 0 {
+			file = idx.File
+		} else if idx.File != file {
+			return nil, fmt.Errorf("track %d is in %q, not the image %q", t.Num, idx.File, file)
+		}
+		ranges[i][0] = idx.Frames
+		if i > 0 {
+			ranges[i-1][1] = idx.Frames
+		}
+	}
+
+	if len(ranges) > 0 {
+		ranges[len(ranges)-1][1] = imageFrames
+	}
+	for i, r := range ranges {
+		if r[0] > r[1] {
+			return nil, fmt.Errorf("track %d: index past end of image", sheet.Tracks[i].Num)
+		}
+	}
+	return ranges, nil
+}
+
+// ImageHiddenRange returns the frame range [start, end) of the hidden track
+// one audio in a single-image cue sheet: track 1's INDEX 00 up to its
+// INDEX 01, when both are in the same file (cd-rip --image --htoa).
+// This is a pure function.
+func ImageHiddenRange(sheet CUESheet) (int, int, bool) {
+	if len(sheet.Tracks) == 0 || sheet.Tracks[0].Num != 1 {
+		return 0, 0, false
+	}
+	idx0, ok0 := sheet.Tracks[0].Index(0)
+	idx1, ok1 := sheet.Tracks[0].Index(1)
+	if !ok0 || !ok1 || idx0.File != idx1.File || idx0.Frames >= idx1.Frames {
+		return 0, 0, false
+	}
+	return idx0.Frames, idx1.Frames, true
+}
+
+// ImageFile returns the file holding every track's INDEX 01, or "" if the
+// tracks are in separate files
+func (s CUESheet) ImageFile() string {
+	file := ""
+	for i, t := range s.Tracks {
+		idx, ok := t.Index(1)
+		if !ok || (i > 0 && idx.File != file) {
+			return ""
+		}
+		file = idx.File
+	}
+	return file
+}
+
+// cueFields splits a cue sheet line into words, keeping quoted strings whole
+func cueFields(line string) []string {
+	var fields []string
+	for line != "" {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			break
+		}
+		if line[0] == '"' {
+			end := strings.IndexByte(line[1:], '"')
+			if end < 0 {
+				fields = append(fields, line[1:])
+				break
+			}
+			fields = append(fields, line[1:end+1])
+			line = line[end+2:]
+			continue
+		}
+		end := strings.IndexAny(line, " \t")
+		if end < 0 {
+			end = len(line)
+		}
+		fields = append(fields, line[:end])
+		line = line[end:]
+	}
+	return fields
+}
+
+// parseCueMSF parses mm:ss:ff into frames
+func parseCueMSF(s string) (int, error) {
+	var m, sec, f int
+	if _, err := fmt.Sscanf(s, "%d:%d:%d", &m, &sec, &f); err != nil {
+		return 0, fmt.Errorf("bad time %q", s)
+	}
+	return (m*60+sec)*75 + f, nil
+}
@@ -0,0 +1,266 @@
+package cdda
+
+import (
+	"strings"
+	"testing"
+)
+
+// cueTOC has a 150-frame pregap before track 2 and an INDEX 02 inside it
+func cueTOC() TOC {
+	return TOC{
+		FirstTrack: 1,
+		LastTrack:  3,
+		LeadoutLBA: 3000,
+		Tracks: []Track{
+			{Num: 1, LBA: 0},
+			{Num: 2, LBA: 1000, Pregap: 150, Indexes: []int{1500}},
+			{Num: 3, LBA: 2000},
+		},
+	}
+}
+
+// cueLines joins lines with the CRLF line endings WriteCUE uses
+func cueLines(lines ...string) string {
+	return strings.Join(lines, "\r\n") + "\r\n"
+}
+
+func TestWriteCUE_TracksAppendedGaps(t *testing.T) {
+	got := string(WriteCUE(cueTOC(), CUEOptions{
+		Catalog:   "0724384260924",
+		Title:     "Album",
+		Performer: `The "Band"`,
+		Tracks:    map[int]CUETrack{1: {Title: "One", ISRC: "USRC17607839"}},
+	}))
+
+	want := cueLines(
+		`CATALOG 0724384260924`,
+		`PERFORMER "The 'Band'"`,
+		`TITLE "Album"`,
+		`FILE "track01.wav" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    TITLE "One"`,
+		`    ISRC USRC17607839`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    INDEX 00 00:11:25`,
+		`FILE "track02.wav" WAVE`,
+		`    INDEX 01 00:00:00`,
+		`    INDEX 02 00:06:50`,
+		`FILE "track03.wav" WAVE`,
+		`  TRACK 03 AUDIO`,
+		`    INDEX 01 00:00:00`,
+	)
+	if got != want {
+		t.Errorf("WriteCUE() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCUE_TracksPrependedGaps(t *testing.T) {
+	got := string(WriteCUE(cueTOC(), CUEOptions{PrependGaps: true}))
+
+	want := cueLines(
+		`FILE "track01.wav" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    INDEX 01 00:00:00`,
+		`FILE "track02.wav" WAVE`,
+		`  TRACK 02 AUDIO`,
+		`    INDEX 00 00:00:00`,
+		`    INDEX 01 00:02:00`,
+		`    INDEX 02 00:08:50`,
+		`FILE "track03.wav" WAVE`,
+		`  TRACK 03 AUDIO`,
+		`    INDEX 01 00:00:00`,
+	)
+	if got != want {
+		t.Errorf("WriteCUE() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCUE_Image(t *testing.T) {
+	got := string(WriteCUE(cueTOC(), CUEOptions{ImageFile: "disc.wav"}))
+
+	want := cueLines(
+		`FILE "disc.wav" WAVE`,
+		`  TRACK 01 AUDIO`,
+		`    INDEX 01 00:00:00`,
+		`  TRACK 02 AUDIO`,
+		`    INDEX 00 00:11:25`,
+		`    INDEX 01 00:13:25`,
+		`    INDEX 02 00:20:00`,
+		`  TRACK 03 AUDIO`,
+		`    INDEX 01 00:26:50`,
+	)
+	if got != want {
+		t.Errorf("WriteCUE() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestWriteCUE_HiddenTrack(t *testing.T) {
+	toc := cueTOC()
+	toc.Tracks[0] = Track{Num: 1, LBA: 300, Pregap: 300}
+
+	tests := []struct {
+		name string
+		opts CUEOptions
+		want []string
+	}{
+		{"not ripped", CUEOptions{}, []string{
+			`FILE "track01.wav" WAVE`,
+			`  TRACK 01 AUDIO`,
+			`    PREGAP 00:04:00`,
+			`    INDEX 01 00:00:00`,
+		}},
+		{"own file", CUEOptions{HiddenTrack: true}, []string{
+			`FILE "track00.wav" WAVE`,
+			`  TRACK 01 AUDIO`,
+			`    INDEX 00 00:00:00`,
+			`FILE "track01.wav" WAVE`,
+			`    INDEX 01 00:00:00`,
+		}},
+		{"in image", CUEOptions{ImageFile: "disc.wav", HiddenTrack: true}, []string{
+			`FILE "disc.wav" WAVE`,
+			`  TRACK 01 AUDIO`,
+			`    INDEX 00 00:00:00`,
+			`    INDEX 01 00:04:00`,
+		}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := string(WriteCUE(toc, tt.opts))
+			if !strings.HasPrefix(got, cueLines(tt.want...)) {
+				t.Errorf("WriteCUE() =\n%s\nwant prefix\n%s", got, cueLines(tt.want...))
+			}
+		})
+	}
+}
+
+func TestWriteCUE_SkipsDataTrack(t *testing.T) {
+	toc := cueTOC()
+	toc.Tracks[2].Type = TrackTypeData
+
+	got := string(WriteCUE(toc, CUEOptions{}))
+
+	if strings.Contains(got, "TRACK 03") {
+		t.Errorf("WriteCUE() should leave out the data track:\n%s", got)
+	}
+}
+
+func TestImageRange(t *testing.T) {
+	toc := cueTOC()
+	toc.Tracks[0] = Track{Num: 1, LBA: 300, Pregap: 300}
+	toc.Tracks[2].Type = TrackTypeData
+
+	start, end, ok := ImageRange(toc, false)
+	if !ok || start != 300 || end != 2000 {
+		t.Errorf("ImageRange(false) = %d, %d, %v, want 300, 2000, true", start, end, ok)
+	}
+	start, _, _ = ImageRange(toc, true)
+	if start != 0 {
+		t.Errorf("ImageRange(true) start = %d, want 0", start)
+	}
+}
+
+func TestParseCUE_RoundTrip(t *testing.T) {
+	data := WriteCUE(cueTOC(), CUEOptions{
+		Catalog: "0724384260924",
+		Title:   "Album",
+		Tracks:  map[int]CUETrack{2: {Title: "Two", Performer: "Guest", ISRC: "USRC17607839"}},
+	})
+
+	sheet, err := ParseCUE(data)
+	if err != nil {
+		t.Fatalf("ParseCUE error: %v", err)
+	}
+
+	if sheet.Catalog != "0724384260924" || sheet.Title != "Album" {
+		t.Errorf("disc = %q/%q, want catalog and title", sheet.Catalog, sheet.Title)
+	}
+	if len(sheet.Tracks) != 3 {
+		t.Fatalf("got %d tracks, want 3", len(sheet.Tracks))
+	}
+
+	two := sheet.Tracks[1]
+	if two.CUETrack != (CUETrack{Title: "Two", Performer: "Guest", ISRC: "USRC17607839"}) {
+		t.Errorf("track 2 metadata = %+v", two.CUETrack)
+	}
+	gap, _ := two.Index(0)
+	if gap != (CUEIndex{Num: 0, File: "track01.wav", Frames: 850}) {
+		t.Errorf("track 2 INDEX 00 = %+v, want 850 frames into track01.wav", gap)
+	}
+	start, _ := two.Index(1)
+	if start != (CUEIndex{Num: 1, File: "track02.wav", Frames: 0}) {
+		t.Errorf("track 2 INDEX 01 = %+v, want start of track02.wav", start)
+	}
+	if sheet.ImageFile() != "" {
+		t.Errorf("ImageFile() = %q for a per-track sheet", sheet.ImageFile())
+	}
+}
+
+func TestParseCUE_Pregap(t *testing.T) {
+	sheet, err := ParseCUE([]byte("FILE \"a b.wav\" WAVE\n  TRACK 01 AUDIO\n    PREGAP 00:02:00\n    INDEX 01 00:00:00\n"))
+	if err != nil {
+		t.Fatalf("ParseCUE error: %v", err)
+	}
+	if sheet.Tracks[0].Pregap != 150 {
+		t.Errorf("Pregap = %d, want 150", sheet.Tracks[0].Pregap)
+	}
+	if sheet.ImageFile() != "a b.wav" {
+		t.Errorf("ImageFile() = %q, want %q", sheet.ImageFile(), "a b.wav")
+	}
+}
+
+func TestParseCUE_IndexBeforeTrack(t *testing.T) {
+	if _, err := ParseCUE([]byte("INDEX 01 00:00:00\n")); err == nil {
+		t.Error("ParseCUE should reject INDEX before TRACK")
+	}
+}
+
+func TestImageTrackRanges(t *testing.T) {
+	sheet, _ := ParseCUE(WriteCUE(cueTOC(), CUEOptions{ImageFile: "disc.wav"}))
+
+	ranges, err := ImageTrackRanges(sheet, 3000)
+	if err != nil {
+		t.Fatalf("ImageTrackRanges error: %v", err)
+	}
+
+	want := [][2]int{{0, 1000}, {1000, 2000}, {2000, 3000}}
+	for i, r := range ranges {
+		if r != want[i] {
+			t.Errorf("track %d range = %v, want %v", i+1, r, want[i])
+		}
+	}
+}
+
+func TestImageHiddenRange(t *testing.T) {
+	toc := cueTOC()
+	toc.Tracks[0] = Track{Num: 1, LBA: 300, Pregap: 300}
+
+	tests := []struct {
+		name       string
+		opts       CUEOptions
+		start, end int
+		ok         bool
+	}{
+		{"in image", CUEOptions{ImageFile: "disc.wav", HiddenTrack: true}, 0, 300, true},
+		{"not ripped", CUEOptions{ImageFile: "disc.wav"}, 0, 0, false},
+		{"own file", CUEOptions{HiddenTrack: true}, 0, 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sheet, _ := ParseCUE(WriteCUE(toc, tt.opts))
+			start, end, ok := ImageHiddenRange(sheet)
+			if start != tt.start || end != tt.end || ok != tt.ok {
+				t.Errorf("ImageHiddenRange() = %d, %d, %t, want %d, %d, %t", start, end, ok, tt.start, tt.end, tt.ok)
+			}
+		})
+	}
+}
+
+func TestImageTrackRanges_NotImage(t *testing.T) {
+	sheet, _ := ParseCUE(WriteCUE(cueTOC(), CUEOptions{}))
+
+	if _, err := ImageTrackRanges(sheet, 3000); err == nil {
+		t.Error("ImageTrackRanges should reject a per-track cue sheet")
+	}
+}
@@ -14,7 +14,11 @@ import (
 // 1. Format track data as hex ASCII string
 // 2. SHA-1 hash the string
 // 3. Base64 encode with MusicBrainz URL-safe substitutions
+//
+// Enhanced CDs count only the audio session (see AudioTOC).
 func CalculateDiscID(toc TOC) string {
+	toc = AudioTOC(toc)
+
 	// Build the hex string that gets hashed
 	// Format: "%02X%02X" + "%08X" * 100
 	// - First track number (1 byte as 2 hex chars)
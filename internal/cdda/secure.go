@@ -0,0 +1,65 @@
+package cdda
+
+import (
+	"bytes"
+)
+
+// VoteResult is the outcome of comparing several reads of the same frames
+type VoteResult struct {
+	Data       []byte // Majority version of each frame
+	Disagreed  int    // Frames where the reads were not all identical
+	Suspicious int    // Frames where no version had a strict majority
+}
+
+// VoteFrames compares repeated reads of the same frame range and picks the
+// most common version of each 2352-byte frame (the earliest read wins ties).
+// This is a pure function: reads → VoteResult.
+//
+// All reads should be the same length; frames missing from a shorter read
+// count as a disagreement.
+func VoteFrames(reads [][]byte) VoteResult {
+	if len(reads) == 0 {
+		return VoteResult{}
+	}
+
+	size := len(reads[0])
+	result := VoteResult{Data: make([]byte, 0, size)}
+
+	for start := 0; start < size; start += BytesPerFrame {
+		end := start + BytesPerFrame
+		if end > size {
+			end = size
+		}
+
+		// Collect each read's version of this frame
+		versions := make([][]byte, 0, len(reads))
+		for _, r := range reads {
+			if len(r) >= end {
+				versions = append(versions, r[start:end])
+			}
+		}
+
+		best, bestCount := versions[0], 0
+		for i, v := range versions {
+			count := 0
+			for _, w := range versions[i:] {
+				if bytes.Equal(v, w) {
+					count++
+				}
+			}
+			if count > bestCount {
+				best, bestCount = v, count
+			}
+		}
+
+		if bestCount < len(reads) {
+			result.Disagreed++
+		}
+		if bestCount*2 <= len(reads) {
+			result.Suspicious++
+		}
+		result.Data = append(result.Data, best...)
+	}
+
+	return result
+}
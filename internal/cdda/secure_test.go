@@ -0,0 +1,81 @@
+package cdda
+
+import (
+	"bytes"
+	"testing"
+)
+
+// frames builds n frames where frame i is filled with vals[i]
+func frames(vals ...byte) []byte {
+	data := make([]byte, 0, len(vals)*BytesPerFrame)
+	for _, v := range vals {
+		data = append(data, bytes.Repeat([]byte{v}, BytesPerFrame)...)
+	}
+	return data
+}
+
+func TestVoteFrames_AllAgree(t *testing.T) {
+	read := frames(1, 2, 3)
+
+	result := VoteFrames([][]byte{read, frames(1, 2, 3)})
+
+	if !bytes.Equal(result.Data, read) {
+		t.Error("Data should equal the agreed reads")
+	}
+	if result.Disagreed != 0 || result.Suspicious != 0 {
+		t.Errorf("Disagreed/Suspicious = %d/%d, want 0/0", result.Disagreed, result.Suspicious)
+	}
+}
+
+func TestVoteFrames_MajorityWins(t *testing.T) {
+	// Frame 1 misread once out of three passes
+	reads := [][]byte{
+		frames(1, 9, 3),
+		frames(1, 2, 3),
+		frames(1, 2, 3),
+	}
+
+	result := VoteFrames(reads)
+
+	if !bytes.Equal(result.Data, frames(1, 2, 3)) {
+		t.Error("Data should take the majority version of frame 1")
+	}
+	if result.Disagreed != 1 {
+		t.Errorf("Disagreed = %d, want 1", result.Disagreed)
+	}
+	if result.Suspicious != 0 {
+		t.Errorf("Suspicious = %d, want 0 (2/3 is a majority)", result.Suspicious)
+	}
+}
+
+func TestVoteFrames_NoMajority(t *testing.T) {
+	// Two passes that disagree can't be settled
+	result := VoteFrames([][]byte{frames(1, 2), frames(1, 7)})
+
+	if result.Suspicious != 1 {
+		t.Errorf("Suspicious = %d, want 1", result.Suspicious)
+	}
+	// Earliest read wins the tie
+	if !bytes.Equal(result.Data, frames(1, 2)) {
+		t.Error("Data should take the first read on a tie")
+	}
+}
+
+func TestVoteFrames_ShortRead(t *testing.T) {
+	result := VoteFrames([][]byte{frames(1, 2), frames(1)})
+
+	if result.Disagreed != 1 {
+		t.Errorf("Disagreed = %d, want 1", result.Disagreed)
+	}
+	if !bytes.Equal(result.Data, frames(1, 2)) {
+		t.Error("Data should fall back to the complete read")
+	}
+}
+
+func TestVoteFrames_Empty(t *testing.T) {
+	result := VoteFrames(nil)
+
+	if result.Data != nil {
+		t.Errorf("Data = %v, want nil", result.Data)
+	}
+}
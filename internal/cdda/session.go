@@ -0,0 +1,164 @@
+package cdda
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// SessionGap is the number of frames between the last audio track of an
+// Enhanced CD and the data track of its second session: the first session's
+// lead-out (6750), the second session's lead-in (4500) and the data track's
+// pregap (150). MusicBrainz ends the audio session this far before the data
+// track.
+const SessionGap = 11400
+
+// SessionInfo is the response to READ TOC format 1
+type SessionInfo struct {
+	FirstSession int // First complete session (normally 1)
+	LastSession  int // Last complete session
+	FirstTrack   int // First track of the last session
+	FirstLBA     int // Start of that track
+}
+
+// ParseSessionInfo parses a READ TOC format 1 (multi-session) response.
+// This is a pure function: bytes → SessionInfo.
+func ParseSessionInfo(raw []byte) (SessionInfo, error) {
+	if len(raw) < 12 {
+		return SessionInfo{}, fmt.Errorf("session info too short: %d bytes", len(raw))
+	}
+	return SessionInfo{
+		FirstSession: int(raw[2]),
+		LastSession:  int(raw[3]),
+		FirstTrack:   int(raw[6]),
+		FirstLBA:     int(int32(binary.BigEndian.Uint32(raw[8:12]))),
+	}, nil
+}
+
+// SetSessions numbers the tracks' sessions from session info: tracks from
+// the first track of the last session on are in the last session, the rest
+// in the first. Returns a new TOC.
+// This is a pure function: (TOC, SessionInfo) → TOC.
+func SetSessions(toc TOC, info SessionInfo) TOC {
+	result := toc
+	result.Tracks = make([]Track, len(toc.Tracks))
+	copy(result.Tracks, toc.Tracks)
+	for i := range result.Tracks {
+		result.Tracks[i].Session = info.FirstSession
+		if result.Tracks[i].Num >= info.FirstTrack {
+			result.Tracks[i].Session = info.LastSession
+		}
+	}
+	return result
+}
+
+// fullTOCDescriptorSize is the length of one READ TOC format 2 descriptor
+const fullTOCDescriptorSize = 11
+
+// ParseFullTOC parses a READ TOC format 2 (full TOC) response into a TOC
+// with session numbers. The lead-out is that of the last session.
+// This is a pure function: bytes → TOC.
+//
+// Each descriptor is a lead-in Q subchannel entry: session, ADR/control,
+// TNO, POINT, MIN, SEC, FRAME, zero, PMIN, PSEC, PFRAME. Mode 1 points
+// 01-99 give track starts, A0/A1 the session's first/last track and A2 its
+// lead-out. Other modes (B0, C0, ...) are skipped.
+func ParseFullTOC(raw []byte) (TOC, error) {
+	if len(raw) < 4 {
+		return TOC{}, errors.New("full TOC too short: need at least 4 bytes")
+	}
+	end := 2 + int(binary.BigEndian.Uint16(raw[0:2]))
+	if end > len(raw) {
+		end = len(raw)
+	}
+
+	msfToLBA := func(m, s, f byte) int {
+		return (int(m)*60+int(s))*75 + int(f) - 150
+	}
+
+	var toc TOC
+	lastSession := 0
+	for off := 4; off+fullTOCDescriptorSize <= end; off += fullTOCDescriptorSize {
+		d := raw[off : off+fullTOCDescriptorSize]
+		session, adr, control, point := int(d[0]), d[1]>>4, d[1]&0x0F, d[3]
+		if adr != 1 {
+			continue
+		}
+
+		switch {
+		case point >= 1 && point <= 99:
+			trackType := TrackTypeAudio
+			if control&0x04 != 0 {
+				trackType = TrackTypeData
+			}
+			toc.Tracks = append(toc.Tracks, Track{
+				Num:     int(point),
+				LBA:     msfToLBA(d[8], d[9], d[10]),
+				Type:    trackType,
+				Session: session,
+			})
+		case point == 0xA0:
+			if toc.FirstTrack == 0 || int(d[8]) < toc.FirstTrack {
+				toc.FirstTrack = int(d[8])
+			}
+		case point == 0xA1:
+			toc.LastTrack = max(toc.LastTrack, int(d[8]))
+		case point == 0xA2:
+			if session >= lastSession {
+				lastSession = session
+				toc.LeadoutLBA = msfToLBA(d[8], d[9], d[10])
+			}
+		}
+	}
+	if len(toc.Tracks) == 0 {
+		return TOC{}, errors.New("full TOC has no tracks")
+	}
+
+	sort.Slice(toc.Tracks, func(i, j int) bool { return toc.Tracks[i].Num < toc.Tracks[j].Num })
+	return toc, nil
+}
+
+// CopySessions sets the session of each track in toc from the track with
+// the same number in full (from ParseFullTOC). Returns a new TOC.
+// This is a pure function: (TOC, TOC) → TOC.
+func CopySessions(toc, full TOC) TOC {
+	sessions := make(map[int]int)
+	for _, t := range full.Tracks {
+		sessions[t.Num] = t.Session
+	}
+
+	result := toc
+	result.Tracks = make([]Track, len(toc.Tracks))
+	copy(result.Tracks, toc.Tracks)
+	for i := range result.Tracks {
+		result.Tracks[i].Session = sessions[result.Tracks[i].Num]
+	}
+	return result
+}
+
+// AudioTOC returns the TOC of an Enhanced CD's audio session: without the
+// data tracks of later sessions, and with the lead-out SessionGap frames
+// before the first of them. Other discs are returned unchanged.
+// When sessions are unknown (the drive rejected READ TOC formats 1 and 2),
+// trailing data tracks are taken to be a later session, as libdiscid does.
+// This is a pure function: TOC → TOC.
+func AudioTOC(toc TOC) TOC {
+	last := len(toc.Tracks) - 1
+	for last >= 0 && !toc.Tracks[last].IsAudio() {
+		last--
+	}
+	if last < 0 || last == len(toc.Tracks)-1 {
+		return toc
+	}
+	audio, data := toc.Tracks[last].Session, toc.Tracks[last+1].Session
+	if data <= audio && (audio > 0 || data > 0) {
+		return toc
+	}
+
+	result := toc
+	result.Tracks = toc.Tracks[: last+1 : last+1]
+	result.LastTrack = toc.Tracks[last].Num
+	result.LeadoutLBA = toc.Tracks[last+1].LBA - SessionGap
+	return result
+}
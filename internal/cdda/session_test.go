@@ -0,0 +1,192 @@
+package cdda
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+// fullTOCDescriptor encodes one mode 1 full TOC entry with a PMSF address
+// (or PMIN = n for the A0/A1 points)
+func fullTOCDescriptor(session int, control byte, point byte, pmsf int) []byte {
+	d := make([]byte, fullTOCDescriptorSize)
+	d[0] = byte(session)
+	d[1] = 0x10 | control
+	d[3] = point
+	if point == 0xA0 || point == 0xA1 {
+		d[8] = byte(pmsf)
+		return d
+	}
+	frames := pmsf + 150
+	d[8], d[9], d[10] = byte(frames/75/60), byte(frames/75%60), byte(frames%75)
+	return d
+}
+
+// enhancedCDFullTOC is a 3-track audio session followed by a data session
+func enhancedCDFullTOC() []byte {
+	descriptors := [][]byte{
+		fullTOCDescriptor(1, 0x00, 0xA0, 1),
+		fullTOCDescriptor(1, 0x00, 0xA1, 3),
+		fullTOCDescriptor(1, 0x00, 0xA2, 60000),
+		fullTOCDescriptor(1, 0x00, 1, 0),
+		fullTOCDescriptor(1, 0x00, 2, 20000),
+		fullTOCDescriptor(1, 0x00, 3, 40000),
+		{1, 0x50, 0, 0xB0, 0, 0, 0, 0, 0, 0, 0}, // Mode 5: next session pointer (skipped)
+		fullTOCDescriptor(2, 0x04, 0xA0, 4),
+		fullTOCDescriptor(2, 0x04, 0xA1, 4),
+		fullTOCDescriptor(2, 0x04, 0xA2, 90000),
+		fullTOCDescriptor(2, 0x04, 4, 71400),
+	}
+
+	raw := []byte{0, 0, 1, 2}
+	for _, d := range descriptors {
+		raw = append(raw, d...)
+	}
+	binary.BigEndian.PutUint16(raw[0:2], uint16(len(raw)-2))
+	return raw
+}
+
+func TestParseFullTOC_EnhancedCD(t *testing.T) {
+	toc, err := ParseFullTOC(enhancedCDFullTOC())
+	if err != nil {
+		t.Fatalf("ParseFullTOC error: %v", err)
+	}
+
+	if toc.FirstTrack != 1 || toc.LastTrack != 4 {
+		t.Errorf("tracks %d-%d, want 1-4", toc.FirstTrack, toc.LastTrack)
+	}
+	if toc.LeadoutLBA != 90000 {
+		t.Errorf("LeadoutLBA = %d, want 90000 (last session)", toc.LeadoutLBA)
+	}
+	if len(toc.Tracks) != 4 {
+		t.Fatalf("got %d tracks, want 4", len(toc.Tracks))
+	}
+
+	want := []Track{
+		{Num: 1, LBA: 0, Session: 1},
+		{Num: 2, LBA: 20000, Session: 1},
+		{Num: 3, LBA: 40000, Session: 1},
+		{Num: 4, LBA: 71400, Type: TrackTypeData, Session: 2},
+	}
+	for i, w := range want {
+		got := toc.Tracks[i]
+		if got.Num != w.Num || got.LBA != w.LBA || got.Type != w.Type || got.Session != w.Session {
+			t.Errorf("track %d = %+v, want %+v", i+1, got, w)
+		}
+	}
+}
+
+func TestParseFullTOC_TooShort(t *testing.T) {
+	if _, err := ParseFullTOC([]byte{0, 2}); err == nil {
+		t.Error("expected error for short data")
+	}
+	if _, err := ParseFullTOC([]byte{0, 2, 1, 1}); err == nil {
+		t.Error("expected error for a full TOC without tracks")
+	}
+}
+
+func TestParseSessionInfo(t *testing.T) {
+	raw := []byte{0, 10, 1, 2, 0, 0x14, 4, 0, 0, 0x01, 0x16, 0xE8} // Track 4 at LBA 71400
+
+	info, err := ParseSessionInfo(raw)
+	if err != nil {
+		t.Fatalf("ParseSessionInfo error: %v", err)
+	}
+	if info != (SessionInfo{FirstSession: 1, LastSession: 2, FirstTrack: 4, FirstLBA: 71400}) {
+		t.Errorf("info = %+v", info)
+	}
+
+	toc := SetSessions(enhancedCDTOC(0), info)
+	if toc.Tracks[2].Session != 1 || toc.Tracks[3].Session != 2 {
+		t.Errorf("sessions = %d, %d, want 1, 2", toc.Tracks[2].Session, toc.Tracks[3].Session)
+	}
+}
+
+// enhancedCDTOC is the format 0 view of enhancedCDFullTOC, with sessions
+// set to session (0 = unknown)
+func enhancedCDTOC(session int) TOC {
+	toc := TOC{FirstTrack: 1, LastTrack: 4, LeadoutLBA: 90000, Tracks: []Track{
+		{Num: 1, LBA: 0},
+		{Num: 2, LBA: 20000},
+		{Num: 3, LBA: 40000},
+		{Num: 4, LBA: 71400, Type: TrackTypeData},
+	}}
+	if session > 0 {
+		for i := range toc.Tracks {
+			toc.Tracks[i].Session = 1
+		}
+		toc.Tracks[3].Session = 2
+	}
+	return toc
+}
+
+func TestCopySessions(t *testing.T) {
+	full, _ := ParseFullTOC(enhancedCDFullTOC())
+
+	toc := CopySessions(enhancedCDTOC(0), full)
+
+	for i, want := range []int{1, 1, 1, 2} {
+		if toc.Tracks[i].Session != want {
+			t.Errorf("track %d session = %d, want %d", i+1, toc.Tracks[i].Session, want)
+		}
+	}
+}
+
+func TestAudioTOC_EnhancedCD(t *testing.T) {
+	// Sessions 0 are unknown: the trailing data track still ends the audio
+	for _, session := range []int{1, 0} {
+		audio := AudioTOC(enhancedCDTOC(session))
+
+		if audio.LastTrack != 3 || len(audio.Tracks) != 3 {
+			t.Errorf("session %d: LastTrack = %d with %d tracks, want 3/3", session, audio.LastTrack, len(audio.Tracks))
+		}
+		if audio.LeadoutLBA != 71400-SessionGap {
+			t.Errorf("session %d: LeadoutLBA = %d, want %d", session, audio.LeadoutLBA, 71400-SessionGap)
+		}
+	}
+}
+
+func TestAudioTOC_Unchanged(t *testing.T) {
+	tests := map[string]TOC{
+		"mixed mode": {FirstTrack: 1, LastTrack: 2, LeadoutLBA: 5000, Tracks: []Track{
+			{Num: 1, LBA: 0, Type: TrackTypeData, Session: 1},
+			{Num: 2, LBA: 3000, Session: 1},
+		}},
+		"data track in the same session": {FirstTrack: 1, LastTrack: 2, LeadoutLBA: 5000, Tracks: []Track{
+			{Num: 1, LBA: 0, Session: 1},
+			{Num: 2, LBA: 3000, Type: TrackTypeData, Session: 1},
+		}},
+	}
+	for name, toc := range tests {
+		t.Run(name, func(t *testing.T) {
+			got := AudioTOC(toc)
+			if got.LeadoutLBA != toc.LeadoutLBA || len(got.Tracks) != len(toc.Tracks) {
+				t.Errorf("AudioTOC changed the TOC: %+v", got)
+			}
+		})
+	}
+}
+
+func TestCalculateDiscID_EnhancedCD(t *testing.T) {
+	// MusicBrainz ignores the data session and ends the audio at its start minus 11400
+	audioOnly := TOC{FirstTrack: 1, LastTrack: 3, LeadoutLBA: 60000, Tracks: []Track{
+		{Num: 1, LBA: 0},
+		{Num: 2, LBA: 20000},
+		{Num: 3, LBA: 40000},
+	}}
+
+	want := CalculateDiscID(audioOnly)
+	if got := CalculateDiscID(enhancedCDTOC(1)); got != want {
+		t.Errorf("Enhanced CD disc ID = %s, want %s (audio session only)", got, want)
+	}
+	if got := CalculateDiscID(enhancedCDTOC(0)); got != want {
+		t.Errorf("Enhanced CD disc ID without sessions = %s, want %s (trailing data track dropped)", got, want)
+	}
+}
+
+func TestTrackRange_SessionGap(t *testing.T) {
+	start, end := TrackRange(enhancedCDTOC(1), 2, false)
+
+	if start != 40000 || end != 60000 {
+		t.Errorf("last audio track range = [%d, %d), want [40000, 60000)", start, end)
+	}
+}
@@ -0,0 +1,174 @@
+package cdda
+
+import (
+	"fmt"
+)
+
+// SubQSize is the length of one frame of formatted Q subchannel data
+const SubQSize = 16
+
+// SubQ is one frame of decoded Q subchannel.
+// Only mode 1 frames (ADR 1) carry a position; the others carry the
+// catalog number or an ISRC instead.
+type SubQ struct {
+	Control  byte // Track control bits (bit 2 = data)
+	ADR      int  // Mode: 1 = position, 2 = catalog number, 3 = ISRC
+	Track    int  // Track number (0xAA in the lead-out)
+	Index    int  // Index within the track (0 = pregap)
+	Relative int  // Frames since INDEX 01 (counting down in the pregap)
+	Absolute int  // Frames since the start of the program area, including the 150-frame lead-in offset
+}
+
+// HasPosition reports whether the frame carries track/index information
+func (q SubQ) HasPosition() bool {
+	return q.ADR == 1
+}
+
+// ParseSubQ decodes formatted Q subchannel data as returned by READ CD with
+// sub-channel selection 010b (16 bytes, BCD fields).
+// This is a pure function: bytes → SubQ.
+// Returns the zero value (no position) if the data is too short.
+func ParseSubQ(data []byte) SubQ {
+	if len(data) < 12 {
+		return SubQ{}
+	}
+
+	msf := func(b []byte) int {
+		return (fromBCD(b[0])*60+fromBCD(b[1]))*75 + fromBCD(b[2])
+	}
+
+	return SubQ{
+		Control:  data[0] >> 4,
+		ADR:      int(data[0] & 0x0F),
+		Track:    fromBCD(data[1]),
+		Index:    fromBCD(data[2]),
+		Relative: msf(data[3:6]),
+		Absolute: msf(data[7:10]),
+	}
+}
+
+// fromBCD decodes a binary-coded decimal byte (0xAA is left as 0xAA)
+func fromBCD(b byte) int {
+	if b == 0xAA {
+		return 0xAA
+	}
+	return int(b>>4)*10 + int(b&0x0F)
+}
+
+// maxNonPosition bounds the run of Q frames without a position (ISRC or
+// catalog frames, or CRC failures) skipped when looking for one
+const maxNonPosition = 10
+
+// ScanIndexes fills in Pregap and Indexes for every audio track by
+// binary-searching the Q subchannel, reading one frame's Q per probe.
+// readQ returns the decoded Q subchannel for an LBA.
+//
+// Track 1's pregap is everything from LBA 0 to its INDEX 01: empty on most
+// discs, hidden track one audio on the rest. Other pregaps are only looked for
+// after an audio track, since data tracks and session gaps can't be read as
+// audio.
+//
+// Frames without a position are resolved by the next frame that has one, so a
+// boundary landing on an ISRC frame can come out one frame late.
+func ScanIndexes(toc TOC, readQ func(lba int) (SubQ, error)) (TOC, error) {
+	positionAt := func(lba int) (SubQ, error) {
+		for d := 0; d < maxNonPosition; d++ {
+			q, err := readQ(lba + d)
+			if err != nil {
+				return SubQ{}, err
+			}
+			if q.HasPosition() {
+				return q, nil
+			}
+		}
+		return SubQ{}, fmt.Errorf("no Q position near LBA %d", lba)
+	}
+
+	// firstWhere finds the first LBA in [lo, hi) where pred holds, or hi
+	firstWhere := func(lo, hi int, pred func(SubQ) bool) (int, error) {
+		for lo < hi {
+			mid := lo + (hi-lo)/2
+			q, err := positionAt(mid)
+			if err != nil {
+				return 0, err
+			}
+			if pred(q) {
+				hi = mid
+			} else {
+				lo = mid + 1
+			}
+		}
+		return lo, nil
+	}
+
+	tracks := make([]Track, len(toc.Tracks))
+	copy(tracks, toc.Tracks)
+	result := toc
+	result.Tracks = tracks
+
+	// Pregaps first, since they bound the index scan of the previous track
+	for i := range tracks {
+		t := &tracks[i]
+		if !t.IsAudio() {
+			continue
+		}
+		if i == 0 {
+			t.Pregap = t.LBA
+			continue
+		}
+		prev := tracks[i-1]
+		if !prev.IsAudio() {
+			continue
+		}
+
+		start, err := firstWhere(prev.LBA+1, t.LBA, func(q SubQ) bool {
+			return q.Track >= t.Num
+		})
+		if err != nil {
+			return toc, fmt.Errorf("track %d pregap: %w", t.Num, err)
+		}
+		t.Pregap = t.LBA - start
+	}
+
+	for i := range tracks {
+		t := &tracks[i]
+		if !t.IsAudio() {
+			continue
+		}
+		_, end := TrackRange(result, i, true)
+		if end <= t.LBA {
+			continue
+		}
+
+		last, err := lastIndex(readQ, t.Num, end)
+		if err != nil {
+			return toc, fmt.Errorf("track %d indexes: %w", t.Num, err)
+		}
+		for k := 2; k <= last; k++ {
+			lba, err := firstWhere(t.LBA, end, func(q SubQ) bool {
+				return q.Track != t.Num || q.Index >= k
+			})
+			if err != nil {
+				return toc, fmt.Errorf("track %d index %d: %w", t.Num, k, err)
+			}
+			t.Indexes = append(t.Indexes, lba)
+		}
+	}
+
+	return result, nil
+}
+
+// lastIndex returns the index of the last positioned frame of track num
+// before end, or 1 if none is found nearby
+func lastIndex(readQ func(lba int) (SubQ, error), num, end int) (int, error) {
+	for d := 1; d <= maxNonPosition; d++ {
+		q, err := readQ(end - d)
+		if err != nil {
+			return 0, err
+		}
+		if q.HasPosition() && q.Track == num {
+			return q.Index, nil
+		}
+	}
+	return 1, nil
+}
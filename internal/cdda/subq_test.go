@@ -0,0 +1,121 @@
+package cdda
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParseSubQ(t *testing.T) {
+	data := []byte{
+		0x01,             // Control 0 (audio), ADR 1
+		0x12,             // Track 12 (BCD)
+		0x01,             // Index 1
+		0x00, 0x02, 0x10, // Relative 00:02:10
+		0x00,
+		0x10, 0x20, 0x30, // Absolute 10:20:30
+		0, 0, 0, 0, 0, 0,
+	}
+
+	q := ParseSubQ(data)
+
+	want := SubQ{ADR: 1, Track: 12, Index: 1, Relative: 2*75 + 10, Absolute: (10*60+20)*75 + 30}
+	if q != want {
+		t.Errorf("ParseSubQ() = %+v, want %+v", q, want)
+	}
+	if !q.HasPosition() {
+		t.Error("mode 1 frame should have a position")
+	}
+}
+
+func TestParseSubQ_Short(t *testing.T) {
+	if q := ParseSubQ([]byte{0x01}); q.HasPosition() {
+		t.Errorf("ParseSubQ(short) = %+v, want no position", q)
+	}
+}
+
+// fakeDisc serves Q subchannel for index points {lba, track, index}, with an
+// ISRC frame (no position) every 50 frames
+func fakeDisc(points [][3]int) func(int) (SubQ, error) {
+	return func(lba int) (SubQ, error) {
+		if lba%50 == 0 {
+			return SubQ{ADR: 3}, nil
+		}
+		q := SubQ{ADR: 1, Track: 1}
+		for _, p := range points {
+			if p[0] <= lba {
+				q.Track, q.Index = p[1], p[2]
+			}
+		}
+		return q, nil
+	}
+}
+
+func scanTOC() TOC {
+	return TOC{
+		FirstTrack: 1,
+		LastTrack:  3,
+		LeadoutLBA: 3000,
+		Tracks: []Track{
+			{Num: 1, LBA: 0},
+			{Num: 2, LBA: 1000},
+			{Num: 3, LBA: 2000},
+		},
+	}
+}
+
+func TestScanIndexes(t *testing.T) {
+	readQ := fakeDisc([][3]int{
+		{0, 1, 1},
+		{913, 2, 0}, // 87-frame pregap
+		{1000, 2, 1},
+		{1517, 2, 2},
+		{1733, 2, 3},
+		{2000, 3, 1},
+		{3000, 0xAA, 1},
+	})
+
+	toc, err := ScanIndexes(scanTOC(), readQ)
+	if err != nil {
+		t.Fatalf("ScanIndexes error: %v", err)
+	}
+
+	if toc.Tracks[0].Pregap != 0 {
+		t.Errorf("track 1 pregap = %d, want 0", toc.Tracks[0].Pregap)
+	}
+	if toc.Tracks[1].Pregap != 87 {
+		t.Errorf("track 2 pregap = %d, want 87", toc.Tracks[1].Pregap)
+	}
+	if !reflect.DeepEqual(toc.Tracks[1].Indexes, []int{1517, 1733}) {
+		t.Errorf("track 2 indexes = %v, want [1517 1733]", toc.Tracks[1].Indexes)
+	}
+	if toc.Tracks[2].Pregap != 0 || toc.Tracks[2].Indexes != nil {
+		t.Errorf("track 3 = %+v, want no pregap or indexes", toc.Tracks[2])
+	}
+}
+
+func TestScanIndexes_HiddenTrack(t *testing.T) {
+	in := scanTOC()
+	in.Tracks[0].LBA = 300
+
+	toc, err := ScanIndexes(in, fakeDisc([][3]int{{0, 1, 0}, {300, 1, 1}, {1000, 2, 1}, {2000, 3, 1}}))
+	if err != nil {
+		t.Fatalf("ScanIndexes error: %v", err)
+	}
+
+	start, end, ok := HiddenTrackRange(toc)
+	if !ok || start != 0 || end != 300 {
+		t.Errorf("HiddenTrackRange() = %d, %d, %v, want 0, 300, true", start, end, ok)
+	}
+	if in.Tracks[0].Pregap != 0 {
+		t.Error("ScanIndexes should not modify its input")
+	}
+}
+
+func TestScanIndexes_ReadError(t *testing.T) {
+	readQ := func(int) (SubQ, error) { return SubQ{}, errors.New("boom") }
+
+	if _, err := ScanIndexes(scanTOC(), readQ); err == nil {
+		t.Error("ScanIndexes should fail when the subchannel can't be read")
+	}
+}
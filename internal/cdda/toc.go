@@ -15,9 +15,17 @@ const (
 
 // Track represents a single track from the CD TOC
 type Track struct {
-	Num  int
-	LBA  int
-	Type TrackType
+	Num     int
+	LBA     int // Start of INDEX 01, as listed in the TOC
+	Type    TrackType
+	Session int // Session number, filled in from READ TOC format 1 or 2 (0 if unknown)
+
+	// Filled in by ScanIndexes from the Q subchannel
+	Pregap  int   // Frames of INDEX 00 before LBA (0 if none)
+	Indexes []int // LBAs of INDEX 02, 03, ... in order
+
+	// Filled in by ReadCodes from READ SUB-CHANNEL
+	ISRC string // International Standard Recording Code ("" if none)
 }
 
 // IsAudio returns true if this is an audio track
@@ -25,12 +33,18 @@ func (t Track) IsAudio() bool {
 	return t.Type == TrackTypeAudio
 }
 
+// PregapLBA returns where the track's INDEX 00 starts (LBA if there is no pregap)
+func (t Track) PregapLBA() int {
+	return t.LBA - t.Pregap
+}
+
 // TOC represents a CD Table of Contents
 type TOC struct {
 	FirstTrack int
 	LastTrack  int
 	LeadoutLBA int
 	Tracks     []Track
+	MCN        string // Media catalog number (UPC/EAN), filled in by ReadCodes
 }
 
 // ParseTOC parses raw bytes from a SCSI READ TOC command (LBA format).
@@ -102,3 +116,46 @@ func ParseTOC(raw []byte) (TOC, error) {
 
 	return toc, nil
 }
+
+// TrackRange returns the LBA range [start, end) to extract for toc.Tracks[i].
+// This is a pure function: (TOC, index, mode) → range.
+//
+// By default each pregap stays at the end of the previous track, as the TOC
+// lays it out. With prependGaps, a track starts at its INDEX 00 instead and
+// the previous track ends there. Track 1's pregap is never included (see
+// HiddenTrackRange). The last track of a session ends SessionGap frames
+// before the next session's first track.
+func TrackRange(toc TOC, i int, prependGaps bool) (int, int) {
+	t := toc.Tracks[i]
+
+	start := t.LBA
+	if prependGaps && i > 0 {
+		start = t.PregapLBA()
+	}
+
+	end := toc.LeadoutLBA
+	if i+1 < len(toc.Tracks) {
+		next := toc.Tracks[i+1]
+		end = next.LBA
+		if prependGaps {
+			end = next.PregapLBA()
+		}
+		if next.Session > t.Session && t.Session > 0 {
+			end = next.LBA - SessionGap // Lead-out and lead-in between sessions
+		}
+	}
+
+	return start, end
+}
+
+// HiddenTrackRange returns the LBA range of hidden track one audio (HTOA):
+// audio in track 1's pregap, before its INDEX 01.
+// Returns ok = false if the first track isn't audio or has no pregap.
+// This is a pure function.
+func HiddenTrackRange(toc TOC) (int, int, bool) {
+	if len(toc.Tracks) == 0 || !toc.Tracks[0].IsAudio() || toc.Tracks[0].Pregap == 0 {
+		return 0, 0, false
+	}
+	t := toc.Tracks[0]
+	return t.PregapLBA(), t.LBA, true
+}
@@ -143,3 +143,45 @@ func TestParseTOC_Empty(t *testing.T) {
 		t.Error("ParseTOC should fail on empty input")
 	}
 }
+
+func TestTrackRange(t *testing.T) {
+	toc := TOC{
+		LeadoutLBA: 3000,
+		Tracks: []Track{
+			{Num: 1, LBA: 150, Pregap: 150},
+			{Num: 2, LBA: 1000, Pregap: 87},
+			{Num: 3, LBA: 2000},
+		},
+	}
+
+	tests := []struct {
+		name      string
+		i         int
+		prepend   bool
+		wantStart int
+		wantEnd   int
+	}{
+		{"append first", 0, false, 150, 1000},
+		{"append middle", 1, false, 1000, 2000},
+		{"append last", 2, false, 2000, 3000},
+		{"prepend first", 0, true, 150, 913},
+		{"prepend middle", 1, true, 913, 2000},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			start, end := TrackRange(toc, tt.i, tt.prepend)
+			if start != tt.wantStart || end != tt.wantEnd {
+				t.Errorf("TrackRange() = %d, %d, want %d, %d", start, end, tt.wantStart, tt.wantEnd)
+			}
+		})
+	}
+}
+
+func TestHiddenTrackRange_None(t *testing.T) {
+	toc := TOC{Tracks: []Track{{Num: 1, LBA: 0}}}
+
+	if _, _, ok := HiddenTrackRange(toc); ok {
+		t.Error("HiddenTrackRange() ok = true for a disc without a track 1 pregap")
+	}
+}
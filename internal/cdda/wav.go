@@ -2,6 +2,10 @@ package cdda
 
 import (
 	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 )
 
 // CD audio constants
@@ -12,21 +16,28 @@ const (
 	BytesPerFrame = 2352 // Raw CD-DA frame size
 )
 
+// WAVHeaderSize is the length of the header written by WriteWAV and WAVWriter
+const WAVHeaderSize = 44
+
 // WriteWAV creates a WAV file from raw CD audio samples.
 // This is a pure function: raw audio bytes → complete WAV file bytes.
 //
 // Input: raw 16-bit stereo PCM samples at 44.1kHz (CD-DA format)
 // Output: complete WAV file including header
 func WriteWAV(samples []byte) []byte {
-	if samples == nil {
-		samples = []byte{}
-	}
+	wav := make([]byte, WAVHeaderSize+len(samples))
+	copy(wav, wavHeader(uint32(len(samples))))
+	copy(wav[WAVHeaderSize:], samples)
+	return wav
+}
 
-	dataSize := uint32(len(samples))
+// wavHeader builds the 44-byte header of a CD audio WAV file holding
+// dataSize bytes of samples.
+// This is a pure function: data size → header bytes.
+func wavHeader(dataSize uint32) []byte {
 	fileSize := 36 + dataSize // Total - 8 bytes for RIFF header
 
-	// WAV header is 44 bytes
-	header := make([]byte, 44)
+	header := make([]byte, WAVHeaderSize)
 
 	// RIFF header
 	copy(header[0:4], "RIFF")
@@ -51,10 +62,105 @@ func WriteWAV(samples []byte) []byte {
 	copy(header[36:40], "data")
 	binary.LittleEndian.PutUint32(header[40:44], dataSize)
 
-	// Combine header and data
-	wav := make([]byte, 44+len(samples))
-	copy(wav[0:44], header)
-	copy(wav[44:], samples)
+	return header
+}
 
-	return wav
+// WAVWriter streams CD audio samples to a WAV file without holding them in
+// memory. It writes a placeholder header up front and patches the RIFF and
+// data sizes on Close.
+type WAVWriter struct {
+	w    io.WriteSeeker
+	size int64 // Sample bytes written
+}
+
+// NewWAVWriter writes a placeholder header to w and returns a writer for the
+// samples that follow it. w should be positioned at the start of the file.
+func NewWAVWriter(w io.WriteSeeker) (*WAVWriter, error) {
+	if _, err := w.Write(wavHeader(0)); err != nil {
+		return nil, fmt.Errorf("write WAV header: %w", err)
+	}
+	return &WAVWriter{w: w}, nil
+}
+
+// ResumeWAVWriter continues a WAV file left unfinished by a WAVWriter, whose
+// first size sample bytes are kept. Data past them should be truncated by
+// the caller.
+func ResumeWAVWriter(w io.WriteSeeker, size int64) (*WAVWriter, error) {
+	if _, err := w.Seek(WAVHeaderSize+size, io.SeekStart); err != nil {
+		return nil, fmt.Errorf("seek to WAV resume point: %w", err)
+	}
+	return &WAVWriter{w: w, size: size}, nil
+}
+
+// Write appends raw 16-bit stereo PCM samples
+func (ww *WAVWriter) Write(p []byte) (int, error) {
+	n, err := ww.w.Write(p)
+	ww.size += int64(n)
+	return n, err
+}
+
+// Size returns the number of sample bytes written so far
+func (ww *WAVWriter) Size() int64 {
+	return ww.size
+}
+
+// Close patches the header with the final sizes. It does not close the
+// underlying writer.
+func (ww *WAVWriter) Close() error {
+	if ww.size > math.MaxUint32-36 {
+		return fmt.Errorf("WAV data too large: %d bytes", ww.size)
+	}
+	if _, err := ww.w.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("seek to WAV header: %w", err)
+	}
+	if _, err := ww.w.Write(wavHeader(uint32(ww.size))); err != nil {
+		return fmt.Errorf("patch WAV header: %w", err)
+	}
+	if _, err := ww.w.Seek(0, io.SeekEnd); err != nil {
+		return fmt.Errorf("seek to WAV end: %w", err)
+	}
+	return nil
+}
+
+// ParseWAVHeader finds the PCM data in a WAV file and checks that it is CD
+// audio (16-bit stereo PCM at 44.1kHz). header is the start of the file; it
+// must reach the "data" chunk header.
+// This is a pure function: header bytes → (data offset, data size).
+func ParseWAVHeader(header []byte) (int, int, error) {
+	if len(header) < 12 || string(header[0:4]) != "RIFF" || string(header[8:12]) != "WAVE" {
+		return 0, 0, errors.New("not a WAV file")
+	}
+
+	sawFormat := false
+	for off := 12; off+8 <= len(header); {
+		id := string(header[off : off+4])
+		size := int(binary.LittleEndian.Uint32(header[off+4 : off+8]))
+		body := off + 8
+
+		switch id {
+		case "fmt ":
+			if body+16 > len(header) {
+				return 0, 0, errors.New("WAV fmt chunk truncated")
+			}
+			f := header[body:]
+			format := binary.LittleEndian.Uint16(f[0:2])
+			channels := binary.LittleEndian.Uint16(f[2:4])
+			rate := binary.LittleEndian.Uint32(f[4:8])
+			bits := binary.LittleEndian.Uint16(f[14:16])
+			if format != 1 || channels != Channels || rate != SampleRate || bits != BitsPerSample {
+				return 0, 0, fmt.Errorf("WAV is not CD audio (format %d, %d channels, %d Hz, %d bits)",
+					format, channels, rate, bits)
+			}
+			sawFormat = true
+		case "data":
+			if !sawFormat {
+				return 0, 0, errors.New("WAV data chunk before fmt chunk")
+			}
+			return body, size, nil
+		}
+
+		off = body + size + size%2 // Chunks are word-aligned
+	}
+
+	return 0, 0, errors.New("WAV data chunk not found")
 }
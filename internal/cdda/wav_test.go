@@ -3,6 +3,8 @@ package cdda
 import (
 	"bytes"
 	"encoding/binary"
+	"os"
+	"path/filepath"
 	"testing"
 )
 
@@ -120,3 +122,119 @@ func TestWriteWAV_EmptyData(t *testing.T) {
 		t.Errorf("Data size = %d, want 0", dataSize)
 	}
 }
+
+func TestWAVWriter_MatchesWriteWAV(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "stream.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	samples := make([]byte, 3*BytesPerFrame)
+	for i := range samples {
+		samples[i] = byte(i * 7)
+	}
+
+	ww, err := NewWAVWriter(f)
+	if err != nil {
+		t.Fatalf("NewWAVWriter error: %v", err)
+	}
+	for _, chunk := range [][]byte{samples[:100], samples[100:BytesPerFrame], samples[BytesPerFrame:]} {
+		if _, err := ww.Write(chunk); err != nil {
+			t.Fatalf("Write error: %v", err)
+		}
+	}
+	if err := ww.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+	if ww.Size() != int64(len(samples)) {
+		t.Errorf("Size = %d, want %d", ww.Size(), len(samples))
+	}
+
+	got, _ := os.ReadFile(f.Name())
+	if !bytes.Equal(got, WriteWAV(samples)) {
+		t.Error("streamed WAV should equal WriteWAV of the same samples")
+	}
+}
+
+func TestWAVWriter_Empty(t *testing.T) {
+	f, err := os.Create(filepath.Join(t.TempDir(), "empty.wav"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+
+	ww, _ := NewWAVWriter(f)
+	if err := ww.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	got, _ := os.ReadFile(f.Name())
+	if !bytes.Equal(got, WriteWAV(nil)) {
+		t.Error("empty streamed WAV should be a bare header")
+	}
+}
+
+func TestParseWAVHeader(t *testing.T) {
+	wav := WriteWAV(make([]byte, 2*BytesPerFrame))
+
+	offset, size, err := ParseWAVHeader(wav)
+	if err != nil {
+		t.Fatalf("ParseWAVHeader error: %v", err)
+	}
+	if offset != 44 || size != 2*BytesPerFrame {
+		t.Errorf("ParseWAVHeader() = %d, %d, want 44, %d", offset, size, 2*BytesPerFrame)
+	}
+}
+
+func TestParseWAVHeader_NotCDAudio(t *testing.T) {
+	wav := WriteWAV(nil)
+	wav[22] = 1 // Mono
+
+	if _, _, err := ParseWAVHeader(wav); err == nil {
+		t.Error("ParseWAVHeader should reject mono audio")
+	}
+	if _, _, err := ParseWAVHeader([]byte("not a wav file")); err == nil {
+		t.Error("ParseWAVHeader should reject non-WAV data")
+	}
+}
+
+func TestResumeWAVWriter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "resumed.wav")
+	f, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	samples := make([]byte, 2*BytesPerFrame)
+	for i := range samples {
+		samples[i] = byte(i * 3)
+	}
+
+	// Interrupted after one frame plus some data that didn't make it into the journal
+	ww, _ := NewWAVWriter(f)
+	ww.Write(samples[:BytesPerFrame+100])
+	f.Close()
+
+	f, err = os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	if err := f.Truncate(WAVHeaderSize + BytesPerFrame); err != nil {
+		t.Fatal(err)
+	}
+	ww, err = ResumeWAVWriter(f, BytesPerFrame)
+	if err != nil {
+		t.Fatalf("ResumeWAVWriter error: %v", err)
+	}
+	ww.Write(samples[BytesPerFrame:])
+	if err := ww.Close(); err != nil {
+		t.Fatalf("Close error: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	if !bytes.Equal(got, WriteWAV(samples)) {
+		t.Error("resumed WAV should equal WriteWAV of all the samples")
+	}
+}
@@ -0,0 +1,78 @@
+package encode
+
+import (
+	"cmp"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// Config holds encoding settings from the config file
+type Config struct {
+	Template      string `json:"template"`      // Naming preset or template
+	Charset       string `json:"charset"`       // File name characters: ascii or unicode
+	Transliterate bool   `json:"transliterate"` // Kana and Cyrillic to Latin letters
+}
+
+// DefaultConfigPath returns ~/.config/crostini-cd-rip/config.json (or the
+// platform equivalent), or "" if there is no config directory.
+func DefaultConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "crostini-cd-rip", "config.json")
+}
+
+// LoadConfig reads the config file.
+// A missing file is an empty config, not an error.
+func LoadConfig(path string) (Config, error) {
+	var config Config
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return Config{}, fmt.Errorf("read config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return Config{}, fmt.Errorf("parse config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// Naming returns the file layout and sanitizer: settings given in flags
+// (from --template, --charset and --transliterate) win over the config's,
+// then the defaults apply.
+// This is a pure function: (Config, flags) → Naming
+func (c Config) Naming(flags Config) (Naming, error) {
+	naming := presets[DefaultPreset]
+	var err error
+	switch {
+	case flags.Template != "":
+		naming, err = NamingFor(flags.Template)
+	case c.Template != "":
+		naming, err = NamingFor(c.Template)
+		if err != nil {
+			err = fmt.Errorf("config: %w", err)
+		}
+	}
+	if err != nil {
+		return Naming{}, err
+	}
+
+	charset := cmp.Or(flags.Charset, c.Charset)
+	naming.Sanitizer, err = SanitizerFor(charset, flags.Transliterate || c.Transliterate)
+	if err != nil {
+		return Naming{}, err
+	}
+	return naming, nil
+}
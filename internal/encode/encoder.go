@@ -0,0 +1,138 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"slices"
+	"strings"
+)
+
+// Encoder turns a WAV file into a tagged file in one output format
+type Encoder interface {
+	Format() string // Name given to --format
+	Ext() string    // File extension, with the dot
+	Tool() string   // External program the encoder runs
+
+	// Encode writes wav to out in the encoder's format, tagged with tags.
+	// A partial out is removed on failure.
+	Encode(wav, out string, tags TagSet, opts EncodeOptions) error
+}
+
+// encoders are the supported output formats, by name
+var encoders = map[string]Encoder{
+	"mp3":  lameEncoder{},
+	"flac": flacEncoder{},
+	"opus": opusEncoder{},
+	"ogg":  oggEncoder{},
+	"m4a":  m4aEncoder{},
+}
+
+// Formats lists the supported output format names, sorted
+func Formats() []string {
+	names := make([]string, 0, len(encoders))
+	for name := range encoders {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// EncoderFor returns the encoder for a format name such as "flac"
+func EncoderFor(format string) (Encoder, error) {
+	e, ok := encoders[strings.ToLower(format)]
+	if !ok {
+		return nil, fmt.Errorf("unknown format %q (supported: %s)", format, strings.Join(Formats(), ", "))
+	}
+	return e, nil
+}
+
+// Available reports whether the encoder's external program is installed
+func Available(e Encoder) bool {
+	_, err := exec.LookPath(e.Tool())
+	return err == nil
+}
+
+// toolPackages are the Nix packages of tools not named after their program
+var toolPackages = map[string]string{
+	"opusenc": "opus-tools",
+	"oggenc":  "vorbis-tools",
+}
+
+// InstallHint returns the command that installs the encoder's program
+func InstallHint(e Encoder) string {
+	pkg, ok := toolPackages[e.Tool()]
+	if !ok {
+		pkg = e.Tool()
+	}
+	return "nix-shell -p " + pkg
+}
+
+// QualityLabel describes the quality an encoder produces with opts, such
+// as "V2" for MP3 or "128 kbps" for Opus.
+// This is a pure function: (Encoder, EncodeOptions) → label
+func QualityLabel(e Encoder, opts EncodeOptions) string {
+	switch e.(type) {
+	case lameEncoder:
+		return fmt.Sprintf("V%d", opts.Quality)
+	case flacEncoder:
+		return "lossless"
+	case opusEncoder:
+		return fmt.Sprintf("%d kbps", opusBitrate(opts))
+	case oggEncoder:
+		if opts.Bitrate > 0 {
+			return fmt.Sprintf("%d kbps", opts.Bitrate)
+		}
+		return fmt.Sprintf("q%d", vorbisQuality(opts))
+	case m4aEncoder:
+		if e.Tool() == "fdkaac" && opts.Bitrate == 0 {
+			return fmt.Sprintf("VBR mode %d", fdkaacMode(opts))
+		}
+		return fmt.Sprintf("%d kbps", aacBitrate(opts))
+	}
+	return ""
+}
+
+// lameEncoder encodes MP3 with lame and tags it with ID3v2.4
+type lameEncoder struct{}
+
+func (lameEncoder) Format() string { return "mp3" }
+func (lameEncoder) Ext() string    { return ".mp3" }
+func (lameEncoder) Tool() string   { return "lame" }
+
+func (lameEncoder) Encode(wav, out string, tags TagSet, opts EncodeOptions) error {
+	if err := EncodeWAV(wav, out, opts); err != nil {
+		return err
+	}
+	if err := tags.Apply(out); err != nil {
+		os.Remove(out)
+		return fmt.Errorf("tag: %w", err)
+	}
+	return nil
+}
+
+// runEncoder runs an external encoder, showing its output when verbose.
+// On failure the partial output is removed; an empty output is an error.
+// This is boundary code - runs an external process.
+func runEncoder(name string, args []string, out string, verbose bool) error {
+	cmd := exec.Command(name, args...)
+	if verbose {
+		cmd.Stdout = os.Stdout
+		cmd.Stderr = os.Stderr
+	}
+
+	if err := cmd.Run(); err != nil {
+		os.Remove(out)
+		return fmt.Errorf("%s encoding failed: %w", name, err)
+	}
+
+	info, err := os.Stat(out)
+	if err != nil {
+		return fmt.Errorf("output file not created: %w", err)
+	}
+	if info.Size() == 0 {
+		os.Remove(out)
+		return fmt.Errorf("output file is empty")
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package encode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestEncoderFor(t *testing.T) {
+	for _, tt := range []struct {
+		format, ext, tool string
+	}{
+		{"mp3", ".mp3", "lame"},
+		{"flac", ".flac", "flac"},
+		{"FLAC", ".flac", "flac"},
+		{"opus", ".opus", "opusenc"},
+		{"ogg", ".ogg", "oggenc"},
+		{"m4a", ".m4a", m4aEncoder{}.Tool()},
+	} {
+		e, err := EncoderFor(tt.format)
+		if err != nil {
+			t.Errorf("EncoderFor(%q) error: %v", tt.format, err)
+			continue
+		}
+		if e.Ext() != tt.ext || e.Tool() != tt.tool {
+			t.Errorf("EncoderFor(%q) = %s/%s, want %s/%s", tt.format, e.Ext(), e.Tool(), tt.ext, tt.tool)
+		}
+	}
+}
+
+func TestEncoderFor_Unknown(t *testing.T) {
+	if _, err := EncoderFor("wma"); err == nil {
+		t.Error("EncoderFor(wma) should fail")
+	}
+}
+
+func TestInstallHint(t *testing.T) {
+	for format, want := range map[string]string{"mp3": "nix-shell -p lame", "opus": "nix-shell -p opus-tools"} {
+		e, _ := EncoderFor(format)
+		if got := InstallHint(e); got != want {
+			t.Errorf("InstallHint(%s) = %q, want %q", format, got, want)
+		}
+	}
+}
+
+func TestFormats(t *testing.T) {
+	got := Formats()
+	if !slices.IsSorted(got) || !slices.Contains(got, "mp3") || !slices.Contains(got, "flac") {
+		t.Errorf("Formats() = %v", got)
+	}
+}
+
+func TestQualityLabel(t *testing.T) {
+	opts := DefaultEncodeOptions()
+	for format, want := range map[string]string{"mp3": "V2", "flac": "lossless", "opus": "128 kbps", "ogg": "q6"} {
+		e, _ := EncoderFor(format)
+		if got := QualityLabel(e, opts); got != want {
+			t.Errorf("QualityLabel(%s) = %q, want %q", format, got, want)
+		}
+	}
+}
@@ -0,0 +1,41 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+)
+
+// flacEncoder encodes FLAC with the flac tool and tags it with ApplyFLAC,
+// so metaflac isn't needed
+type flacEncoder struct{}
+
+func (flacEncoder) Format() string { return "flac" }
+func (flacEncoder) Ext() string    { return ".flac" }
+func (flacEncoder) Tool() string   { return "flac" }
+
+// Encode encodes wav to a tagged FLAC file at out.
+// This is boundary code - calls flac and performs file I/O.
+func (flacEncoder) Encode(wav, out string, tags TagSet, opts EncodeOptions) error {
+	if _, err := os.Stat(wav); err != nil {
+		return fmt.Errorf("input file: %w", err)
+	}
+	if err := runEncoder("flac", flacArgs(wav, out, opts.Verbose), out, opts.Verbose); err != nil {
+		return err
+	}
+	if err := tags.ApplyFLAC(out); err != nil {
+		os.Remove(out)
+		return fmt.Errorf("tag: %w", err)
+	}
+	return nil
+}
+
+// flacArgs builds the flac command line: best compression, verified, with
+// flac's default padding so the tags are written in place.
+// This is a pure function: (paths, verbose) → args
+func flacArgs(wav, out string, verbose bool) []string {
+	args := []string{"-8", "--verify", "--force", "--output-name=" + out}
+	if !verbose {
+		args = append(args, "--silent")
+	}
+	return append(args, wav)
+}
@@ -0,0 +1,72 @@
+package encode
+
+import (
+	"bytes"
+	"image"
+	"image/png"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func TestFlacArgs(t *testing.T) {
+	got := flacArgs("in.wav", "out.flac", false)
+	want := []string{"-8", "--verify", "--force", "--output-name=out.flac", "--silent", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("flacArgs() = %q, want %q", got, want)
+	}
+
+	got = flacArgs("in.wav", "out.flac", true)
+	want = []string{"-8", "--verify", "--force", "--output-name=out.flac", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("flacArgs(verbose) = %q, want %q", got, want)
+	}
+}
+
+func TestFlacEncoder_Integration(t *testing.T) {
+	e, _ := EncoderFor("flac")
+	if !Available(e) {
+		t.Skip("flac not installed")
+	}
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "test.wav")
+	if err := os.WriteFile(wav, cdda.WriteWAV(make([]byte, 2352*75)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "test.flac")
+	tags := TagSet{Artist: "Artist", Title: "Song", TrackNum: 3, CoverArt: testPNG(), CoverArtMIME: "image/png"}
+	if err := e.Encode(wav, out, tags, DefaultEncodeOptions()); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	f, err := os.Open(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer f.Close()
+	blocks, _, err := readFLACBlocks(f)
+	if err != nil {
+		t.Fatalf("readFLACBlocks error: %v", err)
+	}
+	_, comments := flacComments(t, blocks)
+	for _, c := range []string{"ARTIST=Artist", "TITLE=Song", "TRACKNUMBER=3"} {
+		if !slices.Contains(comments, c) {
+			t.Errorf("comments = %q, missing %s", comments, c)
+		}
+	}
+	if n := countBlocks(blocks, flacPicture); n != 1 {
+		t.Errorf("%d PICTURE blocks, want 1", n)
+	}
+}
+
+// testPNG returns a small real PNG, so its dimensions can be read
+func testPNG() []byte {
+	var b bytes.Buffer
+	png.Encode(&b, image.NewGray(image.Rect(0, 0, 4, 4)))
+	return b.Bytes()
+}
@@ -0,0 +1,242 @@
+package encode
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+)
+
+// FLAC metadata block types
+const (
+	flacStreamInfo    = 0
+	flacPadding       = 1
+	flacVorbisComment = 4
+	flacPicture       = 6
+)
+
+// flacNewPadding is the padding left for later tag edits when the file has
+// to be rewritten (flac's own default)
+const flacNewPadding = 8192
+
+// flacMaxBlockSize bounds a metadata block's data: its length is 24 bits
+const flacMaxBlockSize = 1<<24 - 1
+
+// flacVendor is the vendor string of a new VORBIS_COMMENT block
+const flacVendor = "crostini-cd-rip"
+
+// flacBlock is a FLAC metadata block
+type flacBlock struct {
+	Type int
+	Data []byte
+}
+
+// ApplyFLAC writes the tags to a FLAC file as its VORBIS_COMMENT and
+// PICTURE blocks, replacing the old ones and keeping STREAMINFO and any
+// other blocks. When the new blocks fit in the old metadata's space,
+// padding included, only the metadata is rewritten; otherwise the file is
+// rewritten with fresh padding.
+// This is boundary code - performs file I/O.
+func (t TagSet) ApplyFLAC(path string) error {
+	f, err := os.OpenFile(path, os.O_RDWR, 0)
+	if err != nil {
+		return fmt.Errorf("open flac: %w", err)
+	}
+	defer f.Close()
+
+	blocks, size, err := readFLACBlocks(bufio.NewReader(f))
+	if err != nil {
+		return err
+	}
+
+	newBlocks := t.flacBlocks(blocks)
+	for _, b := range newBlocks {
+		if len(b.Data) > flacMaxBlockSize {
+			return fmt.Errorf("save tags: metadata block of %d bytes is over the FLAC limit of %d (cover art too large?)", len(b.Data), flacMaxBlockSize)
+		}
+	}
+
+	if meta, ok := encodeFLACBlocks(newBlocks, size); ok {
+		if _, err := f.WriteAt(meta, 4); err != nil {
+			return fmt.Errorf("save tags: %w", err)
+		}
+		return nil
+	}
+
+	// Doesn't fit: rewrite the file, copying the audio
+	meta, _ := encodeFLACBlocks(append(newBlocks, flacBlock{Type: flacPadding, Data: make([]byte, flacNewPadding)}), -1)
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := writeFLAC(tmp, meta, io.NewSectionReader(f, int64(4+size), 1<<62)); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save tags: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save tags: %w", err)
+	}
+	return nil
+}
+
+// writeFLAC writes a FLAC file from its metadata blocks and audio frames
+func writeFLAC(path string, meta []byte, audio io.Reader) error {
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	if _, err := out.Write(append([]byte("fLaC"), meta...)); err != nil {
+		out.Close()
+		return err
+	}
+	if _, err := io.Copy(out, audio); err != nil {
+		out.Close()
+		return err
+	}
+	return out.Close()
+}
+
+// readFLACBlocks reads the "fLaC" marker and the metadata blocks from the
+// start of a FLAC file. Returns the blocks and their total size in bytes,
+// headers included; the audio frames start 4+size bytes into the file.
+func readFLACBlocks(r io.Reader) ([]flacBlock, int, error) {
+	marker := make([]byte, 4)
+	if _, err := io.ReadFull(r, marker); err != nil || string(marker) != "fLaC" {
+		return nil, 0, errors.New("not a FLAC file")
+	}
+
+	var blocks []flacBlock
+	size := 0
+	for last := false; !last; {
+		header := make([]byte, 4)
+		if _, err := io.ReadFull(r, header); err != nil {
+			return nil, 0, fmt.Errorf("read metadata block: %w", err)
+		}
+		last = header[0]&0x80 != 0
+		n := int(header[1])<<16 | int(header[2])<<8 | int(header[3])
+
+		b := flacBlock{Type: int(header[0] & 0x7F), Data: make([]byte, n)}
+		if _, err := io.ReadFull(r, b.Data); err != nil {
+			return nil, 0, fmt.Errorf("read metadata block: %w", err)
+		}
+		blocks = append(blocks, b)
+		size += 4 + n
+	}
+
+	if len(blocks) == 0 || blocks[0].Type != flacStreamInfo {
+		return nil, 0, errors.New("FLAC file doesn't start with STREAMINFO")
+	}
+	return blocks, size, nil
+}
+
+// flacBlocks returns the file's metadata blocks with the tags in place of
+// its VORBIS_COMMENT, PICTURE and PADDING blocks. The old vendor string is
+// kept.
+// This is a pure function: (TagSet, blocks) → blocks
+func (t TagSet) flacBlocks(old []flacBlock) []flacBlock {
+	vendor := flacVendor
+	var blocks []flacBlock
+	for _, b := range old {
+		switch b.Type {
+		case flacVorbisComment:
+			if v, _, err := parseVorbisComment(b.Data); err == nil {
+				vendor = v
+			}
+		case flacPicture, flacPadding:
+		default:
+			blocks = append(blocks, b)
+		}
+	}
+
+	blocks = append(blocks, flacBlock{Type: flacVorbisComment, Data: vorbisCommentBlock(vendor, t.VorbisComments())})
+	if pic := t.PictureBlock(); pic != nil {
+		blocks = append(blocks, flacBlock{Type: flacPicture, Data: pic})
+	}
+	return blocks
+}
+
+// encodeFLACBlocks serializes metadata blocks, marking the last one. With
+// size >= 0 the result must be exactly size bytes: the gap is filled with
+// a PADDING block, and ok is false if the blocks don't fit or the gap is
+// too large for one. Every block must be at most flacMaxBlockSize bytes.
+// This is a pure function: blocks → bytes
+func encodeFLACBlocks(blocks []flacBlock, size int) (meta []byte, ok bool) {
+	if size >= 0 {
+		used := 0
+		for _, b := range blocks {
+			used += 4 + len(b.Data)
+		}
+		switch gap := size - used; {
+		case gap == 0:
+		case gap >= 4 && gap-4 <= flacMaxBlockSize:
+			blocks = append(blocks, flacBlock{Type: flacPadding, Data: make([]byte, gap-4)})
+		default:
+			return nil, false
+		}
+	}
+
+	var b bytes.Buffer
+	for i, block := range blocks {
+		header := []byte{byte(block.Type), byte(len(block.Data) >> 16), byte(len(block.Data) >> 8), byte(len(block.Data))}
+		if i == len(blocks)-1 {
+			header[0] |= 0x80
+		}
+		b.Write(header)
+		b.Write(block.Data)
+	}
+	return b.Bytes(), true
+}
+
+// vorbisCommentBlock builds a Vorbis comment header as stored in FLAC:
+// little-endian lengths, no framing bit.
+// This is a pure function: (vendor, comments) → bytes
+func vorbisCommentBlock(vendor string, comments []string) []byte {
+	var b bytes.Buffer
+	put := func(s string) {
+		binary.Write(&b, binary.LittleEndian, uint32(len(s)))
+		b.WriteString(s)
+	}
+
+	put(vendor)
+	binary.Write(&b, binary.LittleEndian, uint32(len(comments)))
+	for _, c := range comments {
+		put(c)
+	}
+	return b.Bytes()
+}
+
+// parseVorbisComment parses a Vorbis comment header as stored in FLAC.
+// This is a pure function: bytes → (vendor, comments)
+func parseVorbisComment(data []byte) (vendor string, comments []string, err error) {
+	r := bytes.NewReader(data)
+	get := func() (string, error) {
+		var n uint32
+		if err := binary.Read(r, binary.LittleEndian, &n); err != nil {
+			return "", err
+		}
+		if int64(n) > int64(r.Len()) {
+			return "", io.ErrUnexpectedEOF
+		}
+		s := make([]byte, n)
+		r.Read(s)
+		return string(s), nil
+	}
+
+	if vendor, err = get(); err != nil {
+		return "", nil, fmt.Errorf("vorbis comment vendor: %w", err)
+	}
+	var count uint32
+	if err := binary.Read(r, binary.LittleEndian, &count); err != nil {
+		return "", nil, fmt.Errorf("vorbis comment count: %w", err)
+	}
+	for range count {
+		c, err := get()
+		if err != nil {
+			return "", nil, fmt.Errorf("vorbis comment: %w", err)
+		}
+		comments = append(comments, c)
+	}
+	return vendor, comments, nil
+}
@@ -0,0 +1,252 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+// testFrames stands in for a FLAC file's audio frames
+var testFrames = append([]byte{0xFF, 0xF8, 0x69, 0x08}, bytes.Repeat([]byte("frame"), 200)...)
+
+// testFLAC builds a FLAC file: STREAMINFO, a SEEKTABLE, a VORBIS_COMMENT
+// from the reference encoder and a PADDING block of padding bytes (none if
+// 0), then testFrames
+func testFLAC(padding int) []byte {
+	streamInfo := make([]byte, 34)
+	binary.BigEndian.PutUint16(streamInfo[0:], 4096) // Min/max block size
+	binary.BigEndian.PutUint16(streamInfo[2:], 4096)
+	// 44100 Hz, 2 channels, 16 bits, 44100 samples
+	binary.BigEndian.PutUint64(streamInfo[10:], 44100<<44|1<<41|15<<36|44100)
+
+	blocks := []flacBlock{
+		{Type: flacStreamInfo, Data: streamInfo},
+		{Type: 3, Data: make([]byte, 18)}, // SEEKTABLE with one point
+		{Type: flacVorbisComment, Data: vorbisCommentBlock("reference libFLAC 1.4.3 20230623", []string{"ENCODER=test"})},
+	}
+	if padding > 0 {
+		blocks = append(blocks, flacBlock{Type: flacPadding, Data: make([]byte, padding)})
+	}
+	meta, _ := encodeFLACBlocks(blocks, -1)
+	return slices.Concat([]byte("fLaC"), meta, testFrames)
+}
+
+// readTestFLAC reads a FLAC file's metadata blocks and audio frames
+func readTestFLAC(t *testing.T, path string) ([]flacBlock, []byte) {
+	t.Helper()
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	blocks, size, err := readFLACBlocks(bytes.NewReader(data))
+	if err != nil {
+		t.Fatalf("readFLACBlocks error: %v", err)
+	}
+	return blocks, data[4+size:]
+}
+
+// flacComments returns the vendor and comments of the VORBIS_COMMENT block
+func flacComments(t *testing.T, blocks []flacBlock) (string, []string) {
+	t.Helper()
+	for _, b := range blocks {
+		if b.Type == flacVorbisComment {
+			vendor, comments, err := parseVorbisComment(b.Data)
+			if err != nil {
+				t.Fatalf("parseVorbisComment error: %v", err)
+			}
+			return vendor, comments
+		}
+	}
+	t.Fatal("no VORBIS_COMMENT block")
+	return "", nil
+}
+
+func countBlocks(blocks []flacBlock, typ int) int {
+	n := 0
+	for _, b := range blocks {
+		if b.Type == typ {
+			n++
+		}
+	}
+	return n
+}
+
+func blockTypes(blocks []flacBlock) []int {
+	var types []int
+	for _, b := range blocks {
+		types = append(types, b.Type)
+	}
+	return types
+}
+
+func writeTestFLAC(t *testing.T, padding int) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "test.flac")
+	if err := os.WriteFile(path, testFLAC(padding), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestApplyFLAC_InPlace(t *testing.T) {
+	path := writeTestFLAC(t, 8192)
+	before, _ := os.Stat(path)
+
+	tags := TagSet{Artist: "Artist", Title: "Song", TrackNum: 1, CoverArt: testPNG(), CoverArtMIME: "image/png"}
+	if err := tags.ApplyFLAC(path); err != nil {
+		t.Fatalf("ApplyFLAC error: %v", err)
+	}
+
+	blocks, frames := readTestFLAC(t, path)
+	if after, _ := os.Stat(path); after.Size() != before.Size() {
+		t.Errorf("size %d -> %d, want the padding reused", before.Size(), after.Size())
+	}
+	if !bytes.Equal(frames, testFrames) {
+		t.Error("audio frames changed")
+	}
+	if got, want := blockTypes(blocks), []int{flacStreamInfo, 3, flacVorbisComment, flacPicture, flacPadding}; !slices.Equal(got, want) {
+		t.Errorf("block types = %v, want %v", got, want)
+	}
+	if !bytes.Equal(blocks[0].Data, testFLAC(0)[8:42]) {
+		t.Error("STREAMINFO changed")
+	}
+
+	vendor, comments := flacComments(t, blocks)
+	if vendor != "reference libFLAC 1.4.3 20230623" {
+		t.Errorf("vendor = %q, want the encoder's kept", vendor)
+	}
+	if !slices.Equal(comments, tags.VorbisComments()) {
+		t.Errorf("comments = %q, want %q", comments, tags.VorbisComments())
+	}
+	if !bytes.Equal(blocks[3].Data, tags.PictureBlock()) {
+		t.Error("PICTURE block doesn't match the cover")
+	}
+}
+
+func TestApplyFLAC_Grows(t *testing.T) {
+	path := writeTestFLAC(t, 0)
+
+	tags := TagSet{Title: "Song", CoverArt: bytes.Repeat([]byte{0xAB}, 20000)}
+	if err := tags.ApplyFLAC(path); err != nil {
+		t.Fatalf("ApplyFLAC error: %v", err)
+	}
+
+	blocks, frames := readTestFLAC(t, path)
+	if !bytes.Equal(frames, testFrames) {
+		t.Error("audio frames changed")
+	}
+	last := blocks[len(blocks)-1]
+	if last.Type != flacPadding || len(last.Data) != flacNewPadding {
+		t.Errorf("last block = type %d, %d bytes, want %d bytes of padding", last.Type, len(last.Data), flacNewPadding)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
+
+func TestApplyFLAC_Retag(t *testing.T) {
+	path := writeTestFLAC(t, 0)
+
+	first := TagSet{Title: "First", Genre: "Rock", CoverArt: testPNG()}
+	if err := first.ApplyFLAC(path); err != nil {
+		t.Fatalf("ApplyFLAC error: %v", err)
+	}
+	grown, _ := os.Stat(path)
+
+	second := TagSet{Title: "Second"}
+	if err := second.ApplyFLAC(path); err != nil {
+		t.Fatalf("ApplyFLAC error: %v", err)
+	}
+
+	blocks, frames := readTestFLAC(t, path)
+	if after, _ := os.Stat(path); after.Size() != grown.Size() {
+		t.Errorf("size %d -> %d, want the retag in place", grown.Size(), after.Size())
+	}
+	if !bytes.Equal(frames, testFrames) {
+		t.Error("audio frames changed")
+	}
+	if _, comments := flacComments(t, blocks); !slices.Equal(comments, []string{"TITLE=Second"}) {
+		t.Errorf("comments = %q, want only the new tags", comments)
+	}
+	if n := countBlocks(blocks, flacVorbisComment) + countBlocks(blocks, flacPicture); n != 1 {
+		t.Errorf("%d VORBIS_COMMENT/PICTURE blocks, want the comment only", n)
+	}
+}
+
+func TestApplyFLAC_CoverTooLarge(t *testing.T) {
+	path := writeTestFLAC(t, 8192)
+	before, _ := os.ReadFile(path)
+
+	tags := TagSet{Title: "Song", CoverArt: make([]byte, 1<<24), CoverArtMIME: "image/png"}
+	if err := tags.ApplyFLAC(path); err == nil {
+		t.Fatal("ApplyFLAC succeeded with a 16 MiB cover")
+	}
+	if after, _ := os.ReadFile(path); !bytes.Equal(after, before) {
+		t.Error("file changed after a failed ApplyFLAC")
+	}
+}
+
+func TestApplyFLAC_NotFLAC(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.flac")
+	os.WriteFile(path, []byte("RIFF....WAVE"), 0644)
+
+	if err := (TagSet{}).ApplyFLAC(path); err == nil {
+		t.Error("ApplyFLAC should reject a non-FLAC file")
+	}
+}
+
+func TestEncodeFLACBlocks_Size(t *testing.T) {
+	blocks := []flacBlock{{Type: flacStreamInfo, Data: make([]byte, 34)}}
+
+	for _, tt := range []struct {
+		size    int
+		ok      bool
+		padding int // -1 = no PADDING block
+	}{
+		{38, true, -1},
+		{42, true, 0},
+		{100, true, 58},
+		{40, false, -1}, // Too small for a PADDING header
+		{30, false, -1},
+	} {
+		meta, ok := encodeFLACBlocks(blocks, tt.size)
+		if ok != tt.ok {
+			t.Errorf("size %d: ok = %v, want %v", tt.size, ok, tt.ok)
+			continue
+		}
+		if !ok {
+			continue
+		}
+		if len(meta) != tt.size {
+			t.Errorf("size %d: got %d bytes", tt.size, len(meta))
+		}
+		got, _, err := readFLACBlocks(bytes.NewReader(append([]byte("fLaC"), meta...)))
+		if err != nil {
+			t.Fatalf("size %d: readFLACBlocks error: %v", tt.size, err)
+		}
+		if tt.padding < 0 && len(got) != 1 || tt.padding >= 0 && (len(got) != 2 || len(got[1].Data) != tt.padding) {
+			t.Errorf("size %d: blocks = %v, want padding %d", tt.size, blockTypes(got), tt.padding)
+		}
+	}
+}
+
+func TestVorbisCommentBlock_RoundTrip(t *testing.T) {
+	comments := []string{"TITLE=Über", "ARTIST=Björk", "COMMENT="}
+	vendor, got, err := parseVorbisComment(vorbisCommentBlock("vendor", comments))
+	if err != nil {
+		t.Fatalf("parseVorbisComment error: %v", err)
+	}
+	if vendor != "vendor" || !slices.Equal(got, comments) {
+		t.Errorf("got %q %q, want vendor %q", vendor, got, comments)
+	}
+}
+
+func TestParseVorbisComment_Truncated(t *testing.T) {
+	data := vorbisCommentBlock("vendor", []string{"TITLE=Song"})
+	if _, _, err := parseVorbisComment(data[:len(data)-2]); err == nil {
+		t.Error("parseVorbisComment should reject a truncated comment")
+	}
+}
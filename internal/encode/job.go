@@ -0,0 +1,155 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/musicbrainz"
+)
+
+// Album holds the metadata shared by every track of a disc
+type Album struct {
+	Release   *musicbrainz.Release
+	DiscNum   int            // 0 = single disc or unknown
+	Genre     string         // Not in MusicBrainz releases
+	ISRCs     map[int]string // By track number, from the disc's subchannel
+	CoverArt  []byte
+	CoverMIME string
+	Encoder   Encoder // Output format; nil = MP3
+	Naming    *Naming // File layout; nil = the "flat" preset
+}
+
+// Job is one WAV file to encode, tag and move to its destination
+type Job struct {
+	WAV     string // Input WAV file
+	Temp    string // Where the file is encoded and tagged
+	Dest    string // Final path
+	Tags    TagSet
+	Encoder Encoder
+}
+
+// TrackJob builds the job for the i'th WAV file (0-based, in track order):
+// tags from the release's i'th track and a path in destDir from the
+// album's naming. WAV files beyond the release's track list become
+// "Track N" by the album artist.
+// The file is encoded next to the WAV file before moving.
+// This is a pure function: (Album, index, paths) → Job.
+func (a Album) TrackJob(i int, wav, destDir string) Job {
+	r := a.Release
+	enc := a.Encoder
+	if enc == nil {
+		enc = lameEncoder{}
+	}
+
+	var num int
+	var title, artist string
+	ids := MusicBrainzIDs{
+		ReleaseID:      r.MBID,
+		ReleaseGroupID: r.ReleaseGroupMBID,
+		AlbumArtistIDs: r.ArtistMBIDs,
+		DiscID:         r.DiscID,
+	}
+	if i < len(r.Tracks) {
+		t := r.Tracks[i]
+		num, title, artist = t.Num, t.Title, t.Artist
+		ids.TrackID, ids.RecordingID, ids.ArtistIDs = t.MBID, t.RecordingMBID, t.ArtistMBIDs
+	} else {
+		num = i + 1
+		title = fmt.Sprintf("Track %d", num)
+		artist = r.Artist
+	}
+
+	meta := TrackMeta{
+		Artist:       artist,
+		AlbumArtist:  r.Artist,
+		Album:        r.Title,
+		Title:        title,
+		TrackNum:     num,
+		TrackTotal:   len(r.Tracks),
+		DiscNum:      a.DiscNum,
+		DiscTotal:    r.DiscCount,
+		Year:         r.Year,
+		Genre:        a.Genre,
+		Compilation:  r.Compilation,
+		ISRC:         a.ISRCs[num],
+		CoverArt:     a.CoverArt,
+		CoverArtMIME: a.CoverMIME,
+		MusicBrainz:  ids,
+	}
+
+	naming := presets[DefaultPreset]
+	if a.Naming != nil {
+		naming = *a.Naming
+	}
+
+	return Job{
+		WAV:     wav,
+		Temp:    filepath.Join(filepath.Dir(wav), fmt.Sprintf("track%02d%s", num, enc.Ext())),
+		Dest:    filepath.Join(destDir, naming.Filename(meta, enc.Ext())),
+		Encoder: enc,
+		Tags:    BuildTags(meta),
+	}
+}
+
+// Run encodes and tags the job's WAV file and moves the result into place.
+// This is boundary code - calls the encoder and performs file I/O.
+func (j Job) Run(opts EncodeOptions) error {
+	if err := os.MkdirAll(filepath.Dir(j.Dest), 0755); err != nil {
+		return fmt.Errorf("create destination: %w", err)
+	}
+	enc := j.Encoder
+	if enc == nil {
+		enc = lameEncoder{}
+	}
+	if err := enc.Encode(j.WAV, j.Temp, j.Tags, opts); err != nil {
+		return err
+	}
+	return moveFile(j.Temp, j.Dest)
+}
+
+// moveFile renames src to dst, copying if they are on different devices
+func moveFile(src, dst string) error {
+	if err := os.Rename(src, dst); err == nil {
+		return nil
+	}
+	data, err := os.ReadFile(src)
+	if err != nil {
+		return fmt.Errorf("move %s: %w", src, err)
+	}
+	if err := os.WriteFile(dst, data, 0644); err != nil {
+		return fmt.Errorf("move %s: %w", src, err)
+	}
+	return os.Remove(src)
+}
+
+// Result is the outcome of a job
+type Result struct {
+	Job Job
+	Err error
+}
+
+// RunJobs runs jobs from the channel on the given number of workers until
+// it is closed, sending each result as its job finishes. The results
+// channel is closed once every job is done.
+func RunJobs(jobs <-chan Job, workers int, run func(Job) error) <-chan Result {
+	results := make(chan Result)
+
+	var wg sync.WaitGroup
+	for range max(workers, 1) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				results <- Result{Job: j, Err: run(j)}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	return results
+}
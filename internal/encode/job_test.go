@@ -0,0 +1,158 @@
+package encode
+
+import (
+	"reflect"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/musicbrainz"
+)
+
+func testAlbum() Album {
+	return Album{
+		Release: &musicbrainz.Release{
+			Title:     "Abbey Road",
+			Artist:    "The Beatles",
+			Year:      1969,
+			DiscCount: 1,
+			Tracks: []musicbrainz.Track{
+				{Num: 1, Title: "Come Together", Artist: "The Beatles"},
+				{Num: 2, Title: "Something", Artist: "The Beatles"},
+			},
+		},
+		Genre: "Rock",
+		ISRCs: map[int]string{2: "GBAYE0601690"},
+	}
+}
+
+func TestTrackJob(t *testing.T) {
+	job := testAlbum().TrackJob(1, "/tmp/rip/track02.wav", "/music")
+
+	if job.Dest != "/music/The_Beatles-Abbey_Road-02-Something.mp3" {
+		t.Errorf("Dest = %q", job.Dest)
+	}
+	if job.Temp != "/tmp/rip/track02.mp3" {
+		t.Errorf("Temp = %q, want next to the WAV", job.Temp)
+	}
+	if job.Tags.Title != "Something" || job.Tags.TrackNum != 2 || job.Tags.TrackTotal != 2 {
+		t.Errorf("Tags = %+v", job.Tags)
+	}
+	if job.Tags.Genre != "Rock" || job.Tags.ISRC != "GBAYE0601690" {
+		t.Errorf("Genre/ISRC = %q/%q, want Rock/GBAYE0601690", job.Tags.Genre, job.Tags.ISRC)
+	}
+}
+
+func TestTrackJob_MusicBrainzIDs(t *testing.T) {
+	album := testAlbum()
+	album.Release.MBID = "release"
+	album.Release.ReleaseGroupMBID = "group"
+	album.Release.ArtistMBIDs = []string{"beatles"}
+	album.Release.DiscID = "disc"
+	album.Release.Tracks[1].MBID = "track"
+	album.Release.Tracks[1].RecordingMBID = "recording"
+	album.Release.Tracks[1].ArtistMBIDs = []string{"beatles"}
+
+	got := album.TrackJob(1, "/tmp/rip/track02.wav", "/music").Tags.MusicBrainz
+	want := MusicBrainzIDs{
+		ReleaseID:      "release",
+		ReleaseGroupID: "group",
+		TrackID:        "track",
+		RecordingID:    "recording",
+		ArtistIDs:      []string{"beatles"},
+		AlbumArtistIDs: []string{"beatles"},
+		DiscID:         "disc",
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("MusicBrainz = %+v, want %+v", got, want)
+	}
+
+	// Beyond the release only the release's IDs apply
+	extra := album.TrackJob(2, "/tmp/rip/track03.wav", "/music").Tags.MusicBrainz
+	if extra.ReleaseID != "release" || extra.TrackID != "" || extra.RecordingID != "" {
+		t.Errorf("MusicBrainz = %+v, want release IDs only", extra)
+	}
+}
+
+func TestTrackJob_BeyondRelease(t *testing.T) {
+	job := testAlbum().TrackJob(2, "/tmp/rip/track03.wav", "/music")
+
+	if job.Tags.Title != "Track 3" || job.Tags.Artist != "The Beatles" || job.Tags.TrackNum != 3 {
+		t.Errorf("Tags = %+v, want Track 3 by the album artist", job.Tags)
+	}
+}
+
+func TestTrackJob_Compilation(t *testing.T) {
+	album := testAlbum()
+	album.Release.Compilation = true
+	album.Release.Title = "Now 1"
+	album.Release.Tracks[0].Artist = "A-ha"
+
+	job := album.TrackJob(0, "/tmp/rip/track01.wav", "/music")
+	if job.Dest != "/music/Now_1-01-A-ha-Come_Together.mp3" {
+		t.Errorf("Dest = %q", job.Dest)
+	}
+}
+
+func TestTrackJob_Format(t *testing.T) {
+	album := testAlbum()
+	album.Encoder, _ = EncoderFor("flac")
+
+	job := album.TrackJob(0, "/tmp/rip/track01.wav", "/music")
+	if job.Dest != "/music/The_Beatles-Abbey_Road-01-Come_Together.flac" {
+		t.Errorf("Dest = %q", job.Dest)
+	}
+	if job.Temp != "/tmp/rip/track01.flac" || job.Encoder.Format() != "flac" {
+		t.Errorf("Temp = %q, Encoder = %v, want a FLAC job", job.Temp, job.Encoder)
+	}
+}
+
+func TestTrackJob_Naming(t *testing.T) {
+	album := testAlbum()
+	naming, _ := NamingFor("library")
+	album.Naming = &naming
+
+	job := album.TrackJob(1, "/tmp/rip/track02.wav", "/music")
+	if job.Dest != "/music/The_Beatles/1969-Abbey_Road/02_Something.mp3" {
+		t.Errorf("Dest = %q", job.Dest)
+	}
+}
+
+func TestRunJobs(t *testing.T) {
+	jobs := make(chan Job)
+	var running, peak atomic.Int32
+
+	results := RunJobs(jobs, 3, func(j Job) error {
+		n := running.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		time.Sleep(10 * time.Millisecond)
+		running.Add(-1)
+		return nil
+	})
+
+	go func() {
+		for i := range 6 {
+			jobs <- Job{Tags: TagSet{TrackNum: i + 1}}
+		}
+		close(jobs)
+	}()
+
+	done := make(map[int]bool)
+	for r := range results {
+		if r.Err != nil {
+			t.Errorf("track %d: %v", r.Job.Tags.TrackNum, r.Err)
+		}
+		done[r.Job.Tags.TrackNum] = true
+	}
+	if len(done) != 6 {
+		t.Errorf("got results for %d jobs, want 6", len(done))
+	}
+	if p := peak.Load(); p < 2 || p > 3 {
+		t.Errorf("peak concurrency = %d, want 2-3 with 3 workers", p)
+	}
+}
@@ -6,10 +6,11 @@ import (
 	"os/exec"
 )
 
-// EncodeOptions configures the lame encoder
+// EncodeOptions configures the encoder
 type EncodeOptions struct {
-	Quality int  // VBR quality (0-9, lower is better, default 2)
-	Verbose bool // Show lame output
+	Quality int  // VBR quality (0-9, lower is better, default 2); LAME's scale, mapped for the other formats
+	Bitrate int  // Target kbps for Opus, Vorbis and AAC (0 = from Quality)
+	Verbose bool // Show encoder output
 }
 
 // DefaultEncodeOptions returns sensible defaults for encoding
@@ -0,0 +1,94 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+	"os/exec"
+	"strconv"
+)
+
+// m4aEncoder encodes AAC in an M4A file with fdkaac, or ffmpeg's AAC
+// encoder when fdkaac isn't installed, and tags it with ApplyMP4
+type m4aEncoder struct{}
+
+func (m4aEncoder) Format() string { return "m4a" }
+func (m4aEncoder) Ext() string    { return ".m4a" }
+
+// Tool returns fdkaac if it is installed, otherwise ffmpeg
+func (m4aEncoder) Tool() string {
+	if _, err := exec.LookPath("fdkaac"); err == nil {
+		return "fdkaac"
+	}
+	return "ffmpeg"
+}
+
+// Encode encodes wav to a tagged M4A file at out.
+// This is boundary code - calls fdkaac or ffmpeg and performs file I/O.
+func (e m4aEncoder) Encode(wav, out string, tags TagSet, opts EncodeOptions) error {
+	if _, err := os.Stat(wav); err != nil {
+		return fmt.Errorf("input file: %w", err)
+	}
+
+	tool := e.Tool()
+	args := ffmpegAACArgs(wav, out, opts)
+	if tool == "fdkaac" {
+		args = fdkaacArgs(wav, out, opts)
+	}
+	if err := runEncoder(tool, args, out, opts.Verbose); err != nil {
+		return err
+	}
+
+	if err := tags.ApplyMP4(out); err != nil {
+		os.Remove(out)
+		return fmt.Errorf("tag: %w", err)
+	}
+	return nil
+}
+
+// fdkaacArgs builds the fdkaac command line: the VBR mode matching the
+// LAME quality level, or a constant bitrate if opts.Bitrate is set.
+// This is a pure function: (paths, options) → args
+func fdkaacArgs(wav, out string, opts EncodeOptions) []string {
+	var args []string
+	if opts.Bitrate > 0 {
+		args = append(args, "--bitrate", strconv.Itoa(opts.Bitrate*1000))
+	} else {
+		args = append(args, "--bitrate-mode", strconv.Itoa(fdkaacMode(opts)))
+	}
+	if !opts.Verbose {
+		args = append(args, "--silent")
+	}
+	return append(args, "--output", out, wav)
+}
+
+// fdkaacMode returns the fdkaac VBR mode (1-5) matching the LAME quality
+// level: V2 maps to mode 4 (~160 kbps).
+// This is a pure function: EncodeOptions → mode
+func fdkaacMode(opts EncodeOptions) int {
+	// By LAME V level 0-9
+	modes := []int{5, 5, 4, 4, 3, 3, 2, 2, 1, 1}
+	return modes[min(max(opts.Quality, 0), len(modes)-1)]
+}
+
+// ffmpegAACArgs builds the ffmpeg command line for its native AAC encoder,
+// which is best used at a constant bitrate.
+// This is a pure function: (paths, options) → args
+func ffmpegAACArgs(wav, out string, opts EncodeOptions) []string {
+	args := []string{"-nostdin", "-y"}
+	if !opts.Verbose {
+		args = append(args, "-loglevel", "error")
+	}
+	return append(args, "-i", wav, "-c:a", "aac", "-b:a", fmt.Sprintf("%dk", aacBitrate(opts)), out)
+}
+
+// aacBitrate returns the AAC bitrate in kbps: opts.Bitrate if set,
+// otherwise one matching the LAME quality level (V2 maps to 192 kbps).
+// This is a pure function: EncodeOptions → kbps
+func aacBitrate(opts EncodeOptions) int {
+	if opts.Bitrate > 0 {
+		return opts.Bitrate
+	}
+	// By LAME V level 0-9
+	rates := []int{256, 224, 192, 176, 160, 144, 128, 112, 96, 80}
+	return rates[min(max(opts.Quality, 0), len(rates)-1)]
+}
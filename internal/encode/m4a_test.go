@@ -0,0 +1,75 @@
+package encode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func TestFdkaacArgs(t *testing.T) {
+	got := fdkaacArgs("in.wav", "out.m4a", DefaultEncodeOptions())
+	want := []string{"--bitrate-mode", "4", "--silent", "--output", "out.m4a", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("fdkaacArgs() = %q, want %q", got, want)
+	}
+
+	got = fdkaacArgs("in.wav", "out.m4a", EncodeOptions{Bitrate: 256, Verbose: true})
+	want = []string{"--bitrate", "256000", "--output", "out.m4a", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("fdkaacArgs(256 kbps) = %q, want %q", got, want)
+	}
+}
+
+func TestFfmpegAACArgs(t *testing.T) {
+	got := ffmpegAACArgs("in.wav", "out.m4a", DefaultEncodeOptions())
+	want := []string{"-nostdin", "-y", "-loglevel", "error", "-i", "in.wav", "-c:a", "aac", "-b:a", "192k", "out.m4a"}
+	if !slices.Equal(got, want) {
+		t.Errorf("ffmpegAACArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestAACBitrate(t *testing.T) {
+	for _, tt := range []struct {
+		opts EncodeOptions
+		want int
+	}{
+		{EncodeOptions{Quality: 0}, 256},
+		{EncodeOptions{Quality: 2}, 192},
+		{EncodeOptions{Quality: 9}, 80},
+		{EncodeOptions{Quality: 2, Bitrate: 128}, 128},
+	} {
+		if got := aacBitrate(tt.opts); got != tt.want {
+			t.Errorf("aacBitrate(%+v) = %d, want %d", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestM4AEncoder_Integration(t *testing.T) {
+	e, _ := EncoderFor("m4a")
+	if !Available(e) {
+		t.Skip("fdkaac and ffmpeg not installed")
+	}
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "test.wav")
+	if err := os.WriteFile(wav, cdda.WriteWAV(make([]byte, 2352*75)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "test.m4a")
+	tags := TagSet{Title: "Song", TrackNum: 1, TrackTotal: 2, CoverArt: testPNG(), CoverArtMIME: "image/png"}
+	if err := e.Encode(wav, out, tags, DefaultEncodeOptions()); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+
+	data, err := os.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if title := child(t, child(t, data, "moov", "udta", "meta", "ilst"), "\xa9nam", "data"); string(title[8:]) != "Song" {
+		t.Errorf("title = %q, want Song", title[8:])
+	}
+}
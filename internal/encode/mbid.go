@@ -0,0 +1,49 @@
+package encode
+
+// MusicBrainzIDs link a track back to MusicBrainz, so taggers such as
+// Picard and beets can match it again without a search
+type MusicBrainzIDs struct {
+	ReleaseID      string
+	ReleaseGroupID string
+	TrackID        string // The track on this release
+	RecordingID    string
+	ArtistIDs      []string // Track artists
+	AlbumArtistIDs []string
+	DiscID         string
+}
+
+// mbTag is one identifier with its tag name in each format, as written by
+// Picard
+type mbTag struct {
+	Name   string // ID3 TXXX description and MP4 freeform name
+	Vorbis string // Vorbis comment field
+	UFID   bool   // ID3 writes it as a UFID frame instead of TXXX
+	Values []string
+}
+
+// tags lists the identifiers that are set.
+// This is a pure function: MusicBrainzIDs → tags
+func (m MusicBrainzIDs) tags() []mbTag {
+	var tags []mbTag
+	add := func(name, vorbis string, ufid bool, values ...string) {
+		var set []string
+		for _, v := range values {
+			if v != "" {
+				set = append(set, v)
+			}
+		}
+		if len(set) > 0 {
+			tags = append(tags, mbTag{Name: name, Vorbis: vorbis, UFID: ufid, Values: set})
+		}
+	}
+
+	add("MusicBrainz Album Id", "MUSICBRAINZ_ALBUMID", false, m.ReleaseID)
+	add("MusicBrainz Release Group Id", "MUSICBRAINZ_RELEASEGROUPID", false, m.ReleaseGroupID)
+	add("MusicBrainz Release Track Id", "MUSICBRAINZ_RELEASETRACKID", false, m.TrackID)
+	add("MusicBrainz Track Id", "MUSICBRAINZ_TRACKID", true, m.RecordingID)
+	add("MusicBrainz Artist Id", "MUSICBRAINZ_ARTISTID", false, m.ArtistIDs...)
+	add("MusicBrainz Album Artist Id", "MUSICBRAINZ_ALBUMARTISTID", false, m.AlbumArtistIDs...)
+	add("MusicBrainz Disc Id", "MUSICBRAINZ_DISCID", false, m.DiscID)
+
+	return tags
+}
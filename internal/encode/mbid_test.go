@@ -0,0 +1,151 @@
+package encode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+func testIDs() MusicBrainzIDs {
+	return MusicBrainzIDs{
+		ReleaseID:      "b84ee12a-09ef-421b-82de-0441a926375b",
+		ReleaseGroupID: "9162580e-5df4-32de-80cc-f45a8d8a9b1d",
+		TrackID:        "ad5bdb6f-0f4f-3bfb-8a4c-2c7c1b9b4e5a",
+		RecordingID:    "a52a2b6b-5f69-4b6b-9e0e-30e2e3c3f3a1",
+		ArtistIDs:      []string{"0383dadf-2a4e-4d10-a46a-e9e041da8eb3", "5441c29d-3602-4898-b1a1-b77fa23b8e50"},
+		AlbumArtistIDs: []string{"0383dadf-2a4e-4d10-a46a-e9e041da8eb3"},
+		DiscID:         "xUp1F2NkfP8s8jaeFn_Av3jNEI4-",
+	}
+}
+
+func TestMusicBrainzIDs_Tags(t *testing.T) {
+	tags := testIDs().tags()
+
+	var names []string
+	for _, mb := range tags {
+		names = append(names, mb.Vorbis)
+	}
+	want := []string{
+		"MUSICBRAINZ_ALBUMID", "MUSICBRAINZ_RELEASEGROUPID", "MUSICBRAINZ_RELEASETRACKID", "MUSICBRAINZ_TRACKID",
+		"MUSICBRAINZ_ARTISTID", "MUSICBRAINZ_ALBUMARTISTID", "MUSICBRAINZ_DISCID",
+	}
+	if !slices.Equal(names, want) {
+		t.Errorf("tags = %q, want %q", names, want)
+	}
+	if len(tags[4].Values) != 2 {
+		t.Errorf("artist IDs = %q, want both", tags[4].Values)
+	}
+	for _, mb := range tags {
+		if mb.UFID != (mb.Name == "MusicBrainz Track Id") {
+			t.Errorf("%s: UFID = %v, want only the recording as UFID", mb.Name, mb.UFID)
+		}
+	}
+}
+
+func TestMusicBrainzIDs_TagsOmitEmpty(t *testing.T) {
+	tags := MusicBrainzIDs{ReleaseID: "b84ee12a-09ef-421b-82de-0441a926375b", ArtistIDs: []string{""}}.tags()
+	if len(tags) != 1 || tags[0].Name != "MusicBrainz Album Id" {
+		t.Errorf("tags = %+v, want the release ID only", tags)
+	}
+}
+
+func TestTagSet_Apply_MusicBrainzIDs(t *testing.T) {
+	// id3v2 tags any file; no MP3 encoder needed
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413)...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	ids := testIDs()
+	if err := (TagSet{Title: "Song", MusicBrainz: ids}).Apply(path); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+
+	txxx := make(map[string]string)
+	for _, f := range tag.GetFrames("TXXX") {
+		udtf := f.(id3v2.UserDefinedTextFrame)
+		txxx[udtf.Description] = udtf.Value
+	}
+	for name, want := range map[string]string{
+		"MusicBrainz Album Id":         ids.ReleaseID,
+		"MusicBrainz Release Group Id": ids.ReleaseGroupID,
+		"MusicBrainz Release Track Id": ids.TrackID,
+		"MusicBrainz Artist Id":        ids.ArtistIDs[0] + "\x00" + ids.ArtistIDs[1],
+		"MusicBrainz Album Artist Id":  ids.AlbumArtistIDs[0],
+		"MusicBrainz Disc Id":          ids.DiscID,
+	} {
+		if txxx[name] != want {
+			t.Errorf("TXXX %q = %q, want %q", name, txxx[name], want)
+		}
+	}
+	if _, ok := txxx["MusicBrainz Track Id"]; ok {
+		t.Error("the recording ID belongs in UFID, not TXXX")
+	}
+
+	frames := tag.GetFrames("UFID")
+	if len(frames) != 1 {
+		t.Fatalf("%d UFID frames, want 1", len(frames))
+	}
+	ufid := frames[0].(id3v2.UFIDFrame)
+	if ufid.OwnerIdentifier != "http://musicbrainz.org" || string(ufid.Identifier) != ids.RecordingID {
+		t.Errorf("UFID = %q %q, want the recording ID", ufid.OwnerIdentifier, ufid.Identifier)
+	}
+}
+
+func TestVorbisComments_MusicBrainzIDs(t *testing.T) {
+	ids := testIDs()
+	got := TagSet{MusicBrainz: ids}.VorbisComments()
+
+	for _, want := range []string{
+		"MUSICBRAINZ_ALBUMID=" + ids.ReleaseID,
+		"MUSICBRAINZ_TRACKID=" + ids.RecordingID,
+		"MUSICBRAINZ_RELEASETRACKID=" + ids.TrackID,
+		"MUSICBRAINZ_ARTISTID=" + ids.ArtistIDs[0],
+		"MUSICBRAINZ_ARTISTID=" + ids.ArtistIDs[1],
+		"MUSICBRAINZ_DISCID=" + ids.DiscID,
+	} {
+		if !slices.Contains(got, want) {
+			t.Errorf("VorbisComments() = %q, missing %s", got, want)
+		}
+	}
+}
+
+func TestMP4Ilst_MusicBrainzIDs(t *testing.T) {
+	ids := testIDs()
+	ilst := TagSet{MusicBrainz: ids}.mp4Ilst()
+
+	items, err := parseBoxes(child(t, ilst, "ilst"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	values := make(map[string][]string)
+	for _, item := range items {
+		parts, _ := parseBoxes(item.payload(child(t, ilst, "ilst")))
+		body := item.payload(child(t, ilst, "ilst"))
+		var name string
+		for _, p := range parts {
+			switch p.typ {
+			case "name":
+				name = string(p.payload(body)[4:])
+			case "data":
+				values[name] = append(values[name], string(p.payload(body)[8:]))
+			}
+		}
+	}
+
+	if got := values["MusicBrainz Track Id"]; !slices.Equal(got, []string{ids.RecordingID}) {
+		t.Errorf("MusicBrainz Track Id = %q, want the recording ID", got)
+	}
+	if got := values["MusicBrainz Artist Id"]; !slices.Equal(got, ids.ArtistIDs) {
+		t.Errorf("MusicBrainz Artist Id = %q, want %q", got, ids.ArtistIDs)
+	}
+}
@@ -0,0 +1,307 @@
+package encode
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// MP4 metadata data atom type codes (the "well-known types" of the
+// QuickTime spec)
+const (
+	mp4Implicit = 0  // trkn, disk
+	mp4UTF8     = 1  // Text
+	mp4JPEG     = 13 // covr
+	mp4PNG      = 14 // covr
+	mp4Integer  = 21 // cpil
+)
+
+// ApplyMP4 writes the tags to an MP4 (M4A) file as iTunes metadata atoms
+// in moov/udta/meta/ilst, replacing any existing metadata. Chunk offsets
+// are fixed up when the movie header precedes the media data.
+// This is boundary code - performs file I/O.
+func (t TagSet) ApplyMP4(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("open mp4: %w", err)
+	}
+
+	tagged, err := t.tagMP4(data)
+	if err != nil {
+		return err
+	}
+
+	tmp := filepath.Join(filepath.Dir(path), "."+filepath.Base(path)+".tmp")
+	if err := os.WriteFile(tmp, tagged, 0644); err != nil {
+		return fmt.Errorf("save tags: %w", err)
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("save tags: %w", err)
+	}
+	return nil
+}
+
+// tagMP4 returns the MP4 file data with its metadata replaced by the tags.
+// This is a pure function: (TagSet, file) → file
+func (t TagSet) tagMP4(data []byte) ([]byte, error) {
+	top, err := parseBoxes(data)
+	if err != nil {
+		return nil, err
+	}
+
+	moov, ok := findBox(top, "moov")
+	if !ok {
+		return nil, errors.New("no moov box")
+	}
+	children, err := parseBoxes(moov.payload(data))
+	if err != nil {
+		return nil, fmt.Errorf("moov: %w", err)
+	}
+
+	// Rebuild moov with a new udta/meta, keeping everything else
+	meta := t.mp4Meta()
+	var body []byte
+	hasUdta := false
+	for _, c := range children {
+		if c.typ != "udta" {
+			body = append(body, c.bytes(moov.payload(data))...)
+			continue
+		}
+		hasUdta = true
+		udta, err := replaceMeta(c.payload(moov.payload(data)), meta)
+		if err != nil {
+			return nil, fmt.Errorf("udta: %w", err)
+		}
+		body = append(body, udta...)
+	}
+	if !hasUdta {
+		body = append(body, mp4Box("udta", meta)...)
+	}
+	newMoov := mp4Box("moov", body)
+
+	// Media data after the movie header moves by the size difference
+	if _, ok := findBoxAfter(top, "mdat", moov.start); ok {
+		delta := int64(len(newMoov)) - int64(moov.end-moov.start)
+		if err := shiftChunkOffsets(newMoov[8:], int64(moov.end), delta); err != nil {
+			return nil, err
+		}
+	}
+
+	out := make([]byte, 0, len(data)+len(newMoov)-(moov.end-moov.start))
+	out = append(out, data[:moov.start]...)
+	out = append(out, newMoov...)
+	return append(out, data[moov.end:]...), nil
+}
+
+// replaceMeta returns a udta box with its meta box replaced by meta
+func replaceMeta(udta, meta []byte) ([]byte, error) {
+	children, err := parseBoxes(udta)
+	if err != nil {
+		return nil, err
+	}
+	var body []byte
+	for _, c := range children {
+		if c.typ != "meta" {
+			body = append(body, c.bytes(udta)...)
+		}
+	}
+	return mp4Box("udta", append(body, meta...)), nil
+}
+
+// shiftChunkOffsets adds delta to every stco/co64 chunk offset from
+// offset on, in the boxes of a moov payload
+func shiftChunkOffsets(moov []byte, offset, delta int64) error {
+	boxes, err := parseBoxes(moov)
+	if err != nil {
+		return err
+	}
+	for _, b := range boxes {
+		p := b.payload(moov)
+		switch b.typ {
+		case "trak", "mdia", "minf", "stbl":
+			if err := shiftChunkOffsets(p, offset, delta); err != nil {
+				return fmt.Errorf("%s: %w", b.typ, err)
+			}
+		case "stco", "co64":
+			size := 4
+			if b.typ == "co64" {
+				size = 8
+			}
+			if len(p) < 8 {
+				return fmt.Errorf("%s: truncated", b.typ)
+			}
+			n := int(binary.BigEndian.Uint32(p[4:8]))
+			if len(p) < 8+n*size {
+				return fmt.Errorf("%s: truncated", b.typ)
+			}
+			for i := range n {
+				e := p[8+i*size:]
+				if size == 4 {
+					if v := int64(binary.BigEndian.Uint32(e)); v >= offset {
+						binary.BigEndian.PutUint32(e, uint32(v+delta))
+					}
+				} else if v := int64(binary.BigEndian.Uint64(e)); v >= offset {
+					binary.BigEndian.PutUint64(e, uint64(v+delta))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// mp4Meta builds the meta box holding the tags as an iTunes item list.
+// This is a pure function: TagSet → box
+func (t TagSet) mp4Meta() []byte {
+	hdlr := mp4Box("hdlr",
+		make([]byte, 8), // Version, flags, pre-defined
+		[]byte("mdirappl"),
+		make([]byte, 9), // Reserved and an empty name
+	)
+	return mp4Box("meta", make([]byte, 4), hdlr, t.mp4Ilst())
+}
+
+// mp4Ilst builds the ilst box: one item per tag, as written by iTunes.
+// This is a pure function: TagSet → box
+func (t TagSet) mp4Ilst() []byte {
+	var items [][]byte
+	text := func(name, value string) {
+		if value != "" {
+			items = append(items, mp4Box(name, mp4Data(mp4UTF8, []byte(value))))
+		}
+	}
+	pair := func(name string, n, total, trailer int) {
+		if n > 0 {
+			v := make([]byte, 6+trailer)
+			binary.BigEndian.PutUint16(v[2:], uint16(n))
+			binary.BigEndian.PutUint16(v[4:], uint16(total))
+			items = append(items, mp4Box(name, mp4Data(mp4Implicit, v)))
+		}
+	}
+
+	text("\xa9nam", t.Title)
+	text("\xa9ART", t.Artist)
+	text("aART", t.AlbumArtist)
+	text("\xa9alb", t.Album)
+	pair("trkn", t.TrackNum, t.TrackTotal, 2)
+	pair("disk", t.DiscNum, t.DiscTotal, 0)
+	if t.Year > 0 {
+		text("\xa9day", strconv.Itoa(t.Year))
+	}
+	text("\xa9gen", t.Genre)
+	if t.Compilation {
+		items = append(items, mp4Box("cpil", mp4Data(mp4Integer, []byte{1})))
+	}
+	if t.ISRC != "" {
+		items = append(items, mp4Freeform("ISRC", t.ISRC))
+	}
+	for _, mb := range t.MusicBrainz.tags() {
+		items = append(items, mp4Freeform(mb.Name, mb.Values...))
+	}
+	for _, rg := range t.ReplayGain.tags() {
+		items = append(items, mp4Freeform(strings.ToLower(rg.Name), rg.Value))
+	}
+	if len(t.CoverArt) > 0 {
+		kind := mp4JPEG
+		if t.coverMIME() == "image/png" {
+			kind = mp4PNG
+		}
+		items = append(items, mp4Box("covr", mp4Data(kind, t.CoverArt)))
+	}
+
+	return mp4Box("ilst", items...)
+}
+
+// mp4Freeform builds a "----" item in the com.apple.iTunes namespace, the
+// form used for tags without an atom of their own, with a data box per
+// value
+func mp4Freeform(name string, values ...string) []byte {
+	boxes := [][]byte{
+		mp4Box("mean", make([]byte, 4), []byte("com.apple.iTunes")),
+		mp4Box("name", make([]byte, 4), []byte(name)),
+	}
+	for _, v := range values {
+		boxes = append(boxes, mp4Data(mp4UTF8, []byte(v)))
+	}
+	return mp4Box("----", boxes...)
+}
+
+// mp4Data builds an item's data box: type code, locale 0, then the value
+func mp4Data(kind int, value []byte) []byte {
+	head := make([]byte, 8)
+	binary.BigEndian.PutUint32(head, uint32(kind))
+	return mp4Box("data", head, value)
+}
+
+// mp4Box builds a box: 32-bit size, four-character type, then the payload
+func mp4Box(typ string, payload ...[]byte) []byte {
+	size := 8
+	for _, p := range payload {
+		size += len(p)
+	}
+	b := make([]byte, 8, size)
+	binary.BigEndian.PutUint32(b, uint32(size))
+	copy(b[4:], typ)
+	for _, p := range payload {
+		b = append(b, p...)
+	}
+	return b
+}
+
+// box is a parsed box's position within its parent's payload
+type box struct {
+	typ        string
+	start, end int // Whole box, header included
+	header     int // Header size (8, or 16 with a 64-bit size)
+}
+
+func (b box) bytes(data []byte) []byte   { return data[b.start:b.end] }
+func (b box) payload(data []byte) []byte { return data[b.start+b.header : b.end] }
+
+// parseBoxes splits data into consecutive boxes.
+// This is a pure function: bytes → boxes
+func parseBoxes(data []byte) ([]box, error) {
+	var boxes []box
+	for pos := 0; pos < len(data); {
+		if len(data)-pos < 8 {
+			return nil, fmt.Errorf("truncated box at offset %d", pos)
+		}
+		b := box{typ: string(data[pos+4 : pos+8]), start: pos, header: 8}
+		size := int64(binary.BigEndian.Uint32(data[pos:]))
+		switch size {
+		case 0: // To the end of the file
+			size = int64(len(data) - pos)
+		case 1: // 64-bit size follows the type
+			if len(data)-pos < 16 {
+				return nil, fmt.Errorf("truncated %s box", b.typ)
+			}
+			size, b.header = int64(binary.BigEndian.Uint64(data[pos+8:])), 16
+		}
+		if size < int64(b.header) || size > int64(len(data)-pos) {
+			return nil, fmt.Errorf("bad %s box size %d at offset %d", b.typ, size, pos)
+		}
+		b.end = pos + int(size)
+		boxes = append(boxes, b)
+		pos = b.end
+	}
+	return boxes, nil
+}
+
+// findBox returns the first box of a type
+func findBox(boxes []box, typ string) (box, bool) {
+	return findBoxAfter(boxes, typ, -1)
+}
+
+// findBoxAfter returns the first box of a type starting after offset
+func findBoxAfter(boxes []box, typ string, offset int) (box, bool) {
+	for _, b := range boxes {
+		if b.typ == typ && b.start > offset {
+			return b, true
+		}
+	}
+	return box{}, false
+}
@@ -0,0 +1,193 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+var testAudio = []byte("AUDIO-ONEAUDIO-TWO")
+
+// testMP4 builds a minimal M4A with two chunks in mdat and an encoder tag
+// in udta/meta, with moov before or after mdat
+func testMP4(moovFirst bool) []byte {
+	ftyp := mp4Box("ftyp", []byte("M4A \x00\x00\x00\x00M4A mp42isom"))
+	oldMeta := mp4Box("meta", make([]byte, 4), mp4Box("ilst", mp4Box("\xa9too", mp4Data(mp4UTF8, []byte("Lavf")))))
+	moov := func(first uint32) []byte {
+		stco := make([]byte, 16)
+		binary.BigEndian.PutUint32(stco[4:], 2)
+		binary.BigEndian.PutUint32(stco[8:], first)
+		binary.BigEndian.PutUint32(stco[12:], first+9)
+		stbl := mp4Box("stbl", mp4Box("stsd", make([]byte, 8)), mp4Box("stco", stco))
+		trak := mp4Box("trak", mp4Box("mdia", mp4Box("minf", stbl)))
+		udta := mp4Box("udta", mp4Box("XTRA", []byte("keep")), oldMeta)
+		return mp4Box("moov", mp4Box("mvhd", make([]byte, 100)), trak, udta)
+	}
+	mdat := mp4Box("mdat", testAudio)
+
+	if moovFirst {
+		m := moov(0)
+		first := uint32(len(ftyp) + len(m) + 8)
+		return bytes.Join([][]byte{ftyp, moov(first), mdat}, nil)
+	}
+	return bytes.Join([][]byte{ftyp, mdat, moov(uint32(len(ftyp) + 8))}, nil)
+}
+
+// child returns the payload of the first box of a type, following a path
+func child(t *testing.T, data []byte, path ...string) []byte {
+	t.Helper()
+	for _, typ := range path {
+		boxes, err := parseBoxes(data)
+		if err != nil {
+			t.Fatalf("parse %s: %v", typ, err)
+		}
+		b, ok := findBox(boxes, typ)
+		if !ok {
+			t.Fatalf("no %s box", typ)
+		}
+		data = b.payload(data)
+		if typ == "meta" {
+			data = data[4:] // Full box
+		}
+	}
+	return data
+}
+
+// chunks returns the data at each stco chunk offset
+func chunks(t *testing.T, file []byte) [][]byte {
+	stco := child(t, file, "moov", "trak", "mdia", "minf", "stbl", "stco")
+	var got [][]byte
+	for i := range int(binary.BigEndian.Uint32(stco[4:])) {
+		off := binary.BigEndian.Uint32(stco[8+4*i:])
+		got = append(got, file[off:off+9])
+	}
+	return got
+}
+
+func TestTagMP4(t *testing.T) {
+	tags := TagSet{
+		Artist:       "Queen",
+		AlbumArtist:  "Various Artists",
+		Album:        "Now 100",
+		Title:        "Bohemian Rhapsody",
+		TrackNum:     5,
+		TrackTotal:   20,
+		DiscNum:      2,
+		DiscTotal:    3,
+		Year:         2018,
+		Genre:        "Pop",
+		Compilation:  true,
+		ISRC:         "GBUM71029604",
+		CoverArt:     []byte{0x89, 'P', 'N', 'G'},
+		CoverArtMIME: "image/png",
+	}
+
+	got, err := tags.tagMP4(testMP4(true))
+	if err != nil {
+		t.Fatalf("tagMP4 error: %v", err)
+	}
+	ilst := child(t, got, "moov", "udta", "meta", "ilst")
+
+	// data payload: type code, locale, value
+	value := func(item string) (int, []byte) {
+		d := child(t, ilst, item, "data")
+		return int(binary.BigEndian.Uint32(d)), d[8:]
+	}
+	for item, want := range map[string]string{
+		"\xa9nam": "Bohemian Rhapsody",
+		"\xa9ART": "Queen",
+		"aART":    "Various Artists",
+		"\xa9alb": "Now 100",
+		"\xa9day": "2018",
+		"\xa9gen": "Pop",
+	} {
+		if kind, v := value(item); kind != mp4UTF8 || string(v) != want {
+			t.Errorf("%s = %d %q, want UTF-8 %q", item, kind, v, want)
+		}
+	}
+	if _, v := value("trkn"); !bytes.Equal(v, []byte{0, 0, 0, 5, 0, 20, 0, 0}) {
+		t.Errorf("trkn = % x, want 5 of 20", v)
+	}
+	if _, v := value("disk"); !bytes.Equal(v, []byte{0, 0, 0, 2, 0, 3}) {
+		t.Errorf("disk = % x, want 2 of 3", v)
+	}
+	if kind, v := value("cpil"); kind != mp4Integer || !bytes.Equal(v, []byte{1}) {
+		t.Errorf("cpil = %d % x, want integer 1", kind, v)
+	}
+	if kind, v := value("covr"); kind != mp4PNG || !bytes.Equal(v, tags.CoverArt) {
+		t.Errorf("covr = %d % x, want the PNG", kind, v)
+	}
+
+	isrc := child(t, ilst, "----")
+	if !bytes.Contains(isrc, []byte("com.apple.iTunes")) || !bytes.Contains(isrc, []byte("GBUM71029604")) {
+		t.Errorf("ISRC freeform item = %q", isrc)
+	}
+}
+
+func TestTagMP4_ReplacesMetadata(t *testing.T) {
+	got, err := TagSet{Title: "Song"}.tagMP4(testMP4(true))
+	if err != nil {
+		t.Fatalf("tagMP4 error: %v", err)
+	}
+
+	udta := child(t, got, "moov", "udta")
+	boxes, _ := parseBoxes(udta)
+	var types []string
+	for _, b := range boxes {
+		types = append(types, b.typ)
+	}
+	if len(types) != 2 || types[0] != "XTRA" || types[1] != "meta" {
+		t.Errorf("udta children = %q, want the XTRA box kept and one meta", types)
+	}
+	if bytes.Contains(got, []byte("Lavf")) {
+		t.Error("old metadata should be replaced")
+	}
+}
+
+func TestTagMP4_ChunkOffsets(t *testing.T) {
+	for _, moovFirst := range []bool{true, false} {
+		got, err := TagSet{Title: "A longer title that grows moov", CoverArt: make([]byte, 1000)}.tagMP4(testMP4(moovFirst))
+		if err != nil {
+			t.Fatalf("tagMP4 error: %v", err)
+		}
+		c := chunks(t, got)
+		if string(c[0]) != "AUDIO-ONE" || string(c[1]) != "AUDIO-TWO" {
+			t.Errorf("moov first = %v: chunks = %q, want the audio", moovFirst, c)
+		}
+	}
+}
+
+func TestTagMP4_NoMoov(t *testing.T) {
+	if _, err := (TagSet{}).tagMP4(mp4Box("ftyp", []byte("M4A "))); err == nil {
+		t.Error("tagMP4 should fail without a moov box")
+	}
+}
+
+func TestParseBoxes_BadSize(t *testing.T) {
+	data := mp4Box("free", make([]byte, 8))
+	binary.BigEndian.PutUint32(data, 100)
+	if _, err := parseBoxes(data); err == nil {
+		t.Error("parseBoxes should reject a box larger than its data")
+	}
+}
+
+func TestApplyMP4(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "test.m4a")
+	if err := os.WriteFile(path, testMP4(false), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (TagSet{Title: "Song"}).ApplyMP4(path); err != nil {
+		t.Fatalf("ApplyMP4 error: %v", err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if !bytes.Contains(child(t, data, "moov", "udta", "meta", "ilst"), []byte("Song")) {
+		t.Error("title not written")
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(path)); len(entries) != 1 {
+		t.Errorf("temp file left behind: %v", entries)
+	}
+}
@@ -4,17 +4,19 @@ import (
 	"fmt"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"golang.org/x/text/runes"
 	"golang.org/x/text/transform"
 	"golang.org/x/text/unicode/norm"
 )
 
-// GenerateFilename creates a filename from track metadata.
-// This is a pure function: (artist, album, disc, track, title) → filename
+// GenerateFilename creates a filename from track metadata with the "flat"
+// preset.
+// This is a pure function: (artist, album, disc, track, title, ext) → filename
 //
-// Format: Artist-Album-NN-Title.mp3
-// Multi-disc: Artist-Album-CDN-NN-Title.mp3
+// Format: Artist-Album-NN-Title.ext (ext includes the dot, e.g. ".mp3")
+// Multi-disc: Artist-Album-CDN-NN-Title.ext
 //
 // Character handling:
 // - Non-ASCII → normalized to ASCII equivalents (ō→o, é→e)
@@ -24,57 +26,56 @@ import (
 // - Quotes (' " `) → removed
 // - Multiple consecutive underscores → collapsed to single underscore
 // - Leading/trailing underscores → trimmed
-func GenerateFilename(artist, album string, disc, track int, title string) string {
-	// Sanitize each component
-	artist = sanitize(artist)
-	album = sanitize(album)
-	title = sanitize(title)
-
-	// Build filename
-	var parts []string
-	parts = append(parts, artist, album)
-
-	// Add disc number if multi-disc (disc > 0)
-	if disc > 0 {
-		parts = append(parts, fmt.Sprintf("CD%d", disc))
-	}
-
-	// Add track number (zero-padded)
-	parts = append(parts, fmt.Sprintf("%02d", track))
-
-	// Add title
-	parts = append(parts, title)
-
-	return strings.Join(parts, "-") + ".mp3"
+func GenerateFilename(artist, album string, disc, track int, title, ext string) string {
+	meta := TrackMeta{Artist: artist, Album: album, DiscNum: disc, TrackNum: track, Title: title}
+	return presets["flat"].Album.Filename(meta, ext, Sanitizer{})
 }
 
-// GenerateCompilationFilename creates a filename for compilation/various artists albums.
+// GenerateCompilationFilename creates a filename for compilation/various
+// artists albums with the "flat" preset.
 // This is a pure function.
 //
-// Format: Compilation-NN-TrackArtist-Title.mp3
-// Multi-disc: Compilation-CDN-NN-TrackArtist-Title.mp3
-func GenerateCompilationFilename(compilation string, disc, track int, trackArtist, title string) string {
-	// Sanitize each component
-	compilation = sanitize(compilation)
-	trackArtist = sanitize(trackArtist)
-	title = sanitize(title)
-
-	// Build filename
-	var parts []string
-	parts = append(parts, compilation)
-
-	// Add disc number if multi-disc (disc > 0)
-	if disc > 0 {
-		parts = append(parts, fmt.Sprintf("CD%d", disc))
-	}
+// Format: Compilation-NN-TrackArtist-Title.ext
+// Multi-disc: Compilation-CDN-NN-TrackArtist-Title.ext
+func GenerateCompilationFilename(compilation string, disc, track int, trackArtist, title, ext string) string {
+	meta := TrackMeta{Album: compilation, DiscNum: disc, TrackNum: track, Artist: trackArtist, Title: title, Compilation: true}
+	return presets["flat"].Compilation.Filename(meta, ext, Sanitizer{})
+}
 
-	// Add track number (zero-padded)
-	parts = append(parts, fmt.Sprintf("%02d", track))
+// File name character sets
+const (
+	CharsetASCII   = "ascii"   // Shell-safe ASCII
+	CharsetUnicode = "unicode" // Any script; only filesystem-illegal characters removed
+)
 
-	// Add track artist and title
-	parts = append(parts, trackArtist, title)
+// Sanitizer makes metadata safe to use in file names
+type Sanitizer struct {
+	Charset       string // CharsetASCII ("" = ASCII) or CharsetUnicode
+	Transliterate bool   // Write kana and Cyrillic in Latin letters instead of dropping them
+}
 
-	return strings.Join(parts, "-") + ".mp3"
+// SanitizerFor returns the sanitizer for a charset name ("" = ASCII)
+func SanitizerFor(charset string, transliterate bool) (Sanitizer, error) {
+	switch strings.ToLower(charset) {
+	case "", CharsetASCII:
+		return Sanitizer{Charset: CharsetASCII, Transliterate: transliterate}, nil
+	case CharsetUnicode:
+		return Sanitizer{Charset: CharsetUnicode, Transliterate: transliterate}, nil
+	}
+	return Sanitizer{}, fmt.Errorf("unknown charset %q (have %s, %s)", charset, CharsetASCII, CharsetUnicode)
+}
+
+// Sanitize prepares a string for use in a file name. The result may be
+// empty when nothing usable is left.
+// This is a pure function: string → string
+func (s Sanitizer) Sanitize(v string) string {
+	if s.Transliterate {
+		v = transliterate(v)
+	}
+	if s.Charset == CharsetUnicode {
+		return sanitizeUnicode(v)
+	}
+	return sanitize(v)
 }
 
 // sanitize prepares a string for use in a filename.
@@ -142,3 +143,52 @@ func normalizeToASCII(s string) string {
 	}
 	return b.String()
 }
+
+// sanitizeUnicode prepares a string for use in a filename on ext4, FAT,
+// exFAT and NTFS, keeping letters in any script.
+// Replaces characters illegal on any of them (/ \ : * ? " < > |) with
+// underscores, removes control characters and collapses whitespace.
+// Leading and trailing spaces and dots (dropped by Windows) are trimmed,
+// and Windows device names such as CON get an underscore.
+func sanitizeUnicode(s string) string {
+	s = norm.NFC.String(s)
+
+	var b strings.Builder
+	b.Grow(len(s))
+
+	lastWasSpace := false
+	for _, r := range s {
+		switch {
+		case strings.ContainsRune(`/\:*?"<>|`, r):
+			b.WriteByte('_')
+			lastWasSpace = false
+		case unicode.IsSpace(r):
+			if !lastWasSpace {
+				b.WriteByte(' ')
+				lastWasSpace = true
+			}
+		case unicode.IsControl(r), r == utf8.RuneError:
+			// skip - remove entirely
+		default:
+			b.WriteRune(r)
+			lastWasSpace = false
+		}
+	}
+
+	result := strings.Trim(b.String(), " .")
+	base, _, _ := strings.Cut(result, ".")
+	if windowsReserved[strings.ToUpper(strings.TrimRight(base, " "))] {
+		result += "_"
+	}
+	return result
+}
+
+// windowsReserved are device names that can't be file names on Windows
+// file systems, with or without an extension
+var windowsReserved = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
@@ -8,7 +8,7 @@ import (
 )
 
 func TestGenerateFilename_Basic(t *testing.T) {
-	got := GenerateFilename("Artist", "Album", 0, 1, "Song Title")
+	got := GenerateFilename("Artist", "Album", 0, 1, "Song Title", ".mp3")
 	want := "Artist-Album-01-Song_Title.mp3"
 
 	if got != want {
@@ -16,8 +16,17 @@ func TestGenerateFilename_Basic(t *testing.T) {
 	}
 }
 
+func TestGenerateFilename_Extension(t *testing.T) {
+	got := GenerateFilename("Artist", "Album", 0, 1, "Song", ".flac")
+	want := "Artist-Album-01-Song.flac"
+
+	if got != want {
+		t.Errorf("GenerateFilename() = %q, want %q", got, want)
+	}
+}
+
 func TestGenerateFilename_SpacesToUnderscores(t *testing.T) {
-	got := GenerateFilename("The Beatles", "Abbey Road", 0, 2, "Come Together")
+	got := GenerateFilename("The Beatles", "Abbey Road", 0, 2, "Come Together", ".mp3")
 	want := "The_Beatles-Abbey_Road-02-Come_Together.mp3"
 
 	if got != want {
@@ -27,7 +36,7 @@ func TestGenerateFilename_SpacesToUnderscores(t *testing.T) {
 
 func TestGenerateFilename_SlashReplaced(t *testing.T) {
 	// AC/DC should become AC_DC (slash is illegal in filenames)
-	got := GenerateFilename("AC/DC", "Back in Black", 0, 1, "Hells Bells")
+	got := GenerateFilename("AC/DC", "Back in Black", 0, 1, "Hells Bells", ".mp3")
 	want := "AC_DC-Back_in_Black-01-Hells_Bells.mp3"
 
 	if got != want {
@@ -36,7 +45,7 @@ func TestGenerateFilename_SlashReplaced(t *testing.T) {
 }
 
 func TestGenerateFilename_BackslashReplaced(t *testing.T) {
-	got := GenerateFilename("Test\\Artist", "Test\\Album", 0, 1, "Test\\Title")
+	got := GenerateFilename("Test\\Artist", "Test\\Album", 0, 1, "Test\\Title", ".mp3")
 	want := "Test_Artist-Test_Album-01-Test_Title.mp3"
 
 	if got != want {
@@ -46,7 +55,7 @@ func TestGenerateFilename_BackslashReplaced(t *testing.T) {
 
 func TestGenerateFilename_RemovesApostrophe(t *testing.T) {
 	// Apostrophes removed for shell safety (no quoting needed)
-	got := GenerateFilename("The Who", "Who's Next", 0, 3, "Won't Get Fooled Again")
+	got := GenerateFilename("The Who", "Who's Next", 0, 3, "Won't Get Fooled Again", ".mp3")
 	want := "The_Who-Whos_Next-03-Wont_Get_Fooled_Again.mp3"
 
 	if got != want {
@@ -56,7 +65,7 @@ func TestGenerateFilename_RemovesApostrophe(t *testing.T) {
 
 func TestGenerateFilename_RemovesDoubleQuotes(t *testing.T) {
 	// Double quotes removed for shell safety
-	got := GenerateFilename(`Richard "Groove" Holmes`, "Album", 0, 1, "Song")
+	got := GenerateFilename(`Richard "Groove" Holmes`, "Album", 0, 1, "Song", ".mp3")
 	want := "Richard_Groove_Holmes-Album-01-Song.mp3"
 
 	if got != want {
@@ -66,7 +75,7 @@ func TestGenerateFilename_RemovesDoubleQuotes(t *testing.T) {
 
 func TestGenerateFilename_RemovesSmartQuotes(t *testing.T) {
 	// Smart/curly quotes are non-ASCII so they get removed
-	got := GenerateFilename("Artist", "Album", 0, 1, "\u201cSmart\u201d \u2018Quotes\u2019")
+	got := GenerateFilename("Artist", "Album", 0, 1, "\u201cSmart\u201d \u2018Quotes\u2019", ".mp3")
 	want := "Artist-Album-01-Smart_Quotes.mp3"
 
 	if got != want {
@@ -76,7 +85,7 @@ func TestGenerateFilename_RemovesSmartQuotes(t *testing.T) {
 
 func TestGenerateFilename_ShellSafe(t *testing.T) {
 	// Verify shell-special characters are replaced, trailing underscores trimmed
-	got := GenerateFilename("Test$Artist", "Album!", 0, 1, "Song?")
+	got := GenerateFilename("Test$Artist", "Album!", 0, 1, "Song?", ".mp3")
 	want := "Test_Artist-Album-01-Song.mp3"
 
 	if got != want {
@@ -129,7 +138,7 @@ func TestGenerateFilename_ShellSafe_Integration(t *testing.T) {
 	tmpDir := t.TempDir()
 
 	for _, tc := range testCases {
-		filename := GenerateFilename(tc.artist, tc.album, 0, 1, tc.title)
+		filename := GenerateFilename(tc.artist, tc.album, 0, 1, tc.title, ".mp3")
 		fullPath := filepath.Join(tmpDir, filename)
 
 		// Create the file
@@ -156,7 +165,7 @@ func TestGenerateFilename_ShellSafe_Integration(t *testing.T) {
 
 func TestGenerateFilename_KeepsColon(t *testing.T) {
 	// Colons are kept (shell-safe, though illegal on Windows)
-	got := GenerateFilename("Artist", "Album: Subtitle", 0, 1, "Song: Extended Mix")
+	got := GenerateFilename("Artist", "Album: Subtitle", 0, 1, "Song: Extended Mix", ".mp3")
 	want := "Artist-Album:_Subtitle-01-Song:_Extended_Mix.mp3"
 
 	if got != want {
@@ -167,7 +176,7 @@ func TestGenerateFilename_KeepsColon(t *testing.T) {
 func TestGenerateFilename_MultiDisc(t *testing.T) {
 	// Multi-disc: disc > 0 adds CDN prefix
 	// Note: ? is replaced with underscore then trimmed
-	got := GenerateFilename("Pink Floyd", "The Wall", 1, 1, "In the Flesh?")
+	got := GenerateFilename("Pink Floyd", "The Wall", 1, 1, "In the Flesh?", ".mp3")
 	want := "Pink_Floyd-The_Wall-CD1-01-In_the_Flesh.mp3"
 
 	if got != want {
@@ -176,7 +185,7 @@ func TestGenerateFilename_MultiDisc(t *testing.T) {
 }
 
 func TestGenerateFilename_MultiDiscSecond(t *testing.T) {
-	got := GenerateFilename("Pink Floyd", "The Wall", 2, 13, "Another Brick in the Wall")
+	got := GenerateFilename("Pink Floyd", "The Wall", 2, 13, "Another Brick in the Wall", ".mp3")
 	want := "Pink_Floyd-The_Wall-CD2-13-Another_Brick_in_the_Wall.mp3"
 
 	if got != want {
@@ -186,7 +195,7 @@ func TestGenerateFilename_MultiDiscSecond(t *testing.T) {
 
 func TestGenerateFilename_TrackPadding(t *testing.T) {
 	// Single digit tracks should be zero-padded
-	got := GenerateFilename("Artist", "Album", 0, 9, "Track Nine")
+	got := GenerateFilename("Artist", "Album", 0, 9, "Track Nine", ".mp3")
 	want := "Artist-Album-09-Track_Nine.mp3"
 
 	if got != want {
@@ -195,7 +204,7 @@ func TestGenerateFilename_TrackPadding(t *testing.T) {
 }
 
 func TestGenerateFilename_DoubleDigitTrack(t *testing.T) {
-	got := GenerateFilename("Artist", "Album", 0, 12, "Track Twelve")
+	got := GenerateFilename("Artist", "Album", 0, 12, "Track Twelve", ".mp3")
 	want := "Artist-Album-12-Track_Twelve.mp3"
 
 	if got != want {
@@ -205,7 +214,7 @@ func TestGenerateFilename_DoubleDigitTrack(t *testing.T) {
 
 func TestGenerateCompilationFilename(t *testing.T) {
 	// Compilation: different format with track artist included
-	got := GenerateCompilationFilename("80s Hits", 0, 1, "A-ha", "Take On Me")
+	got := GenerateCompilationFilename("80s Hits", 0, 1, "A-ha", "Take On Me", ".mp3")
 	want := "80s_Hits-01-A-ha-Take_On_Me.mp3"
 
 	if got != want {
@@ -214,7 +223,7 @@ func TestGenerateCompilationFilename(t *testing.T) {
 }
 
 func TestGenerateCompilationFilename_MultiDisc(t *testing.T) {
-	got := GenerateCompilationFilename("Now 100", 2, 5, "Queen", "Bohemian Rhapsody")
+	got := GenerateCompilationFilename("Now 100", 2, 5, "Queen", "Bohemian Rhapsody", ".mp3")
 	want := "Now_100-CD2-05-Queen-Bohemian_Rhapsody.mp3"
 
 	if got != want {
@@ -223,7 +232,7 @@ func TestGenerateCompilationFilename_MultiDisc(t *testing.T) {
 }
 
 func TestGenerateCompilationFilename_SlashInArtist(t *testing.T) {
-	got := GenerateCompilationFilename("Metal Hits", 0, 3, "AC/DC", "Highway to Hell")
+	got := GenerateCompilationFilename("Metal Hits", 0, 3, "AC/DC", "Highway to Hell", ".mp3")
 	want := "Metal_Hits-03-AC_DC-Highway_to_Hell.mp3"
 
 	if got != want {
@@ -233,7 +242,7 @@ func TestGenerateCompilationFilename_SlashInArtist(t *testing.T) {
 
 func TestGenerateFilename_CollapsesUnderscores(t *testing.T) {
 	// "Heavy D & The Boyz" has " & " which becomes "___" without collapsing
-	got := GenerateFilename("Heavy D & The Boyz", "Album", 0, 1, "Song")
+	got := GenerateFilename("Heavy D & The Boyz", "Album", 0, 1, "Song", ".mp3")
 	want := "Heavy_D_The_Boyz-Album-01-Song.mp3"
 
 	if got != want {
@@ -244,7 +253,7 @@ func TestGenerateFilename_CollapsesUnderscores(t *testing.T) {
 func TestGenerateFilename_CollapsesUnderscores_Multiple(t *testing.T) {
 	// Multiple consecutive special chars should collapse
 	// Leading/trailing underscores are trimmed
-	got := GenerateFilename("A & B", "C (D) [E]", 0, 1, "F / G")
+	got := GenerateFilename("A & B", "C (D) [E]", 0, 1, "F / G", ".mp3")
 	want := "A_B-C_D_E-01-F_G.mp3"
 
 	if got != want {
@@ -254,7 +263,7 @@ func TestGenerateFilename_CollapsesUnderscores_Multiple(t *testing.T) {
 
 func TestGenerateFilename_NonASCII(t *testing.T) {
 	// Non-ASCII should be normalized to ASCII equivalents
-	got := GenerateFilename("Tone-Lōc", "Album", 0, 1, "Café")
+	got := GenerateFilename("Tone-Lōc", "Album", 0, 1, "Café", ".mp3")
 	want := "Tone-Loc-Album-01-Cafe.mp3"
 
 	if got != want {
@@ -278,7 +287,7 @@ func BenchmarkSanitize(b *testing.B) {
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
 		for _, input := range inputs {
-			_ = GenerateFilename(input, "Album", 0, 1, "Title")
+			_ = GenerateFilename(input, "Album", 0, 1, "Title", ".mp3")
 		}
 	}
 }
@@ -290,6 +299,77 @@ func BenchmarkSanitize_WorstCase(b *testing.B) {
 
 	b.ResetTimer()
 	for i := 0; i < b.N; i++ {
-		_ = GenerateFilename(worstCase, worstCase, 0, 1, worstCase)
+		_ = GenerateFilename(worstCase, worstCase, 0, 1, worstCase, ".mp3")
+	}
+}
+
+func TestSanitizer_Unicode(t *testing.T) {
+	s := Sanitizer{Charset: CharsetUnicode}
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"keeps scripts", "椎名林檎 – Кино", "椎名林檎 – Кино"},
+		{"keeps shell characters", "Who's Next (Deluxe) & $ongs!", "Who's Next (Deluxe) & $ongs!"},
+		{"illegal characters", `AC/DC: "Live" <1992> a\b|c?*`, "AC_DC_ _Live_ _1992_ a_b_c__"},
+		{"control characters", "Tab\tand\x00nul\x7f", "Tab andnul"},
+		{"collapses whitespace", "  Too   many  ", "Too many"},
+		{"trailing dots", "...And Justice for All...", "And Justice for All"},
+		{"reserved name", "Con", "Con_"},
+		{"reserved with extension", "aux.live", "aux.live_"},
+		{"composed", "Café", "Café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := s.Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizer_ASCII(t *testing.T) {
+	tests := []struct {
+		name          string
+		transliterate bool
+		in            string
+		want          string
+	}{
+		{"drops kana", false, "はじめまして", ""},
+		{"drops cyrillic", false, "Кино", ""},
+		{"transliterates kana", true, "はじめまして", "hajimemashite"},
+		{"transliterates cyrillic", true, "Группа крови", "Gruppa_krovi"},
+		{"kanji still dropped", true, "椎名林檎", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, _ := SanitizerFor(CharsetASCII, tt.transliterate)
+			if got := s.Sanitize(tt.in); got != tt.want {
+				t.Errorf("Sanitize(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizerFor(t *testing.T) {
+	if s, err := SanitizerFor("", false); err != nil || s.Charset != CharsetASCII {
+		t.Errorf("SanitizerFor(\"\") = %+v, %v, want ASCII", s, err)
+	}
+	if s, err := SanitizerFor("Unicode", true); err != nil || s != (Sanitizer{Charset: CharsetUnicode, Transliterate: true}) {
+		t.Errorf("SanitizerFor(Unicode) = %+v, %v", s, err)
+	}
+	if _, err := SanitizerFor("latin1", false); err == nil {
+		t.Error("SanitizerFor(latin1) succeeded")
+	}
+}
+
+func TestGenerateFilename_EmptyFallback(t *testing.T) {
+	// Titles with nothing ASCII left used to give Artist--03-.mp3
+	got := GenerateFilename("キノ", "Кино", 0, 3, "椎名林檎", ".mp3")
+	want := "Unknown_Artist-Unknown_Album-03-Track_03.mp3"
+
+	if got != want {
+		t.Errorf("GenerateFilename() = %q, want %q", got, want)
 	}
 }
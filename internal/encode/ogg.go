@@ -0,0 +1,76 @@
+package encode
+
+import (
+	"encoding/base64"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// maxArgLen is the longest single command-line argument Linux accepts
+// (MAX_ARG_STRLEN)
+const maxArgLen = 128 * 1024
+
+// oggEncoder encodes Ogg Vorbis with oggenc, which also writes the Vorbis
+// comments. oggenc has no picture option, so the cover goes in a
+// METADATA_BLOCK_PICTURE comment.
+type oggEncoder struct{}
+
+func (oggEncoder) Format() string { return "ogg" }
+func (oggEncoder) Ext() string    { return ".ogg" }
+func (oggEncoder) Tool() string   { return "oggenc" }
+
+// Encode encodes wav to a tagged Ogg Vorbis file at out. Cover art too
+// large to pass to oggenc is left out with a warning.
+// This is boundary code - calls oggenc.
+func (oggEncoder) Encode(wav, out string, tags TagSet, opts EncodeOptions) error {
+	if _, err := os.Stat(wav); err != nil {
+		return fmt.Errorf("input file: %w", err)
+	}
+
+	args, coverDropped := oggArgs(wav, out, tags, opts)
+	if coverDropped {
+		fmt.Fprintf(os.Stderr, "Warning: %s: cover art too large for oggenc (%d bytes), encoding without it\n", filepath.Base(wav), len(tags.CoverArt))
+	}
+	return runEncoder("oggenc", args, out, opts.Verbose)
+}
+
+// oggArgs builds the oggenc command line: the quality (or bitrate) for
+// opts, with the tags and cover art as Vorbis comments. Cover art too large
+// to pass as an argument is left out, and coverDropped says so.
+// This is a pure function: (paths, tags, options) → (args, coverDropped)
+func oggArgs(wav, out string, tags TagSet, opts EncodeOptions) (args []string, coverDropped bool) {
+	if opts.Bitrate > 0 {
+		args = append(args, "--bitrate", strconv.Itoa(opts.Bitrate))
+	} else {
+		args = append(args, "--quality", strconv.Itoa(vorbisQuality(opts)))
+	}
+	if !opts.Verbose {
+		args = append(args, "--quiet")
+	}
+	args = append(args, "--output", out)
+
+	for _, c := range tags.VorbisComments() {
+		args = append(args, "--comment", c)
+	}
+	if block := tags.PictureBlock(); block != nil {
+		c := "METADATA_BLOCK_PICTURE=" + base64.StdEncoding.EncodeToString(block)
+		if len(c) < maxArgLen {
+			args = append(args, "--comment", c)
+		} else {
+			coverDropped = true
+		}
+	}
+
+	return append(args, wav), coverDropped
+}
+
+// vorbisQuality returns the oggenc quality (-1 to 10) matching the LAME
+// quality level: V2 (~190 kbps) maps to q6 (~192 kbps).
+// This is a pure function: EncodeOptions → quality
+func vorbisQuality(opts EncodeOptions) int {
+	// By LAME V level 0-9
+	levels := []int{8, 7, 6, 5, 5, 4, 3, 2, 1, 0}
+	return levels[min(max(opts.Quality, 0), len(levels)-1)]
+}
@@ -0,0 +1,91 @@
+package encode
+
+import (
+	"encoding/base64"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func TestOggArgs(t *testing.T) {
+	got, _ := oggArgs("in.wav", "out.ogg", TagSet{Title: "Song"}, DefaultEncodeOptions())
+	want := []string{"--quality", "6", "--quiet", "--output", "out.ogg", "--comment", "TITLE=Song", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("oggArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestOggArgs_Bitrate(t *testing.T) {
+	got, _ := oggArgs("in.wav", "out.ogg", TagSet{}, EncodeOptions{Quality: 2, Bitrate: 160, Verbose: true})
+	want := []string{"--bitrate", "160", "--output", "out.ogg", "in.wav"}
+	if !slices.Equal(got, want) {
+		t.Errorf("oggArgs() = %q, want %q", got, want)
+	}
+}
+
+func TestOggArgs_Picture(t *testing.T) {
+	tags := TagSet{CoverArt: testPNG(), CoverArtMIME: "image/png"}
+	args, coverDropped := oggArgs("in.wav", "out.ogg", tags, DefaultEncodeOptions())
+	if coverDropped {
+		t.Fatal("oggArgs dropped a small cover")
+	}
+
+	var value string
+	for _, a := range args {
+		if v, ok := strings.CutPrefix(a, "METADATA_BLOCK_PICTURE="); ok {
+			value = v
+		}
+	}
+	block, err := base64.StdEncoding.DecodeString(value)
+	if err != nil {
+		t.Fatalf("METADATA_BLOCK_PICTURE is not base64: %v", err)
+	}
+	if !slices.Equal(block, tags.PictureBlock()) {
+		t.Error("METADATA_BLOCK_PICTURE should hold the PICTURE block")
+	}
+}
+
+func TestOggArgs_CoverTooLarge(t *testing.T) {
+	tags := TagSet{Title: "Song", CoverArt: make([]byte, maxArgLen)}
+	args, coverDropped := oggArgs("in.wav", "out.ogg", tags, DefaultEncodeOptions())
+	if !coverDropped {
+		t.Error("oggArgs should drop cover art too large for one argument")
+	}
+	want := []string{"--quality", "6", "--quiet", "--output", "out.ogg", "--comment", "TITLE=Song", "in.wav"}
+	if !slices.Equal(args, want) {
+		t.Errorf("oggArgs() = %q, want the tags without the cover: %q", args, want)
+	}
+}
+
+func TestVorbisQuality(t *testing.T) {
+	for q, want := range map[int]int{0: 8, 2: 6, 4: 5, 9: 0, 12: 0} {
+		if got := vorbisQuality(EncodeOptions{Quality: q}); got != want {
+			t.Errorf("vorbisQuality(V%d) = %d, want %d", q, got, want)
+		}
+	}
+}
+
+func TestOggEncoder_Integration(t *testing.T) {
+	e, _ := EncoderFor("ogg")
+	if !Available(e) {
+		t.Skip("oggenc not installed")
+	}
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "test.wav")
+	if err := os.WriteFile(wav, cdda.WriteWAV(make([]byte, 2352*75)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "test.ogg")
+	if err := e.Encode(wav, out, TagSet{Title: "Song", CoverArt: testPNG()}, DefaultEncodeOptions()); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Errorf("no Ogg output: %v", err)
+	}
+}
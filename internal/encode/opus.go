@@ -0,0 +1,67 @@
+package encode
+
+import (
+	"fmt"
+	"os"
+	"strconv"
+)
+
+// opusEncoder encodes Opus with opusenc, which also writes the Vorbis
+// comments and the cover picture
+type opusEncoder struct{}
+
+func (opusEncoder) Format() string { return "opus" }
+func (opusEncoder) Ext() string    { return ".opus" }
+func (opusEncoder) Tool() string   { return "opusenc" }
+
+// Encode encodes wav to a tagged Opus file at out.
+// This is boundary code - calls opusenc and performs file I/O.
+func (opusEncoder) Encode(wav, out string, tags TagSet, opts EncodeOptions) error {
+	if _, err := os.Stat(wav); err != nil {
+		return fmt.Errorf("input file: %w", err)
+	}
+
+	// opusenc reads the picture from a file
+	var picture string
+	if len(tags.CoverArt) > 0 {
+		picture = out + ".cover"
+		if err := os.WriteFile(picture, tags.CoverArt, 0644); err != nil {
+			return fmt.Errorf("write cover art: %w", err)
+		}
+		defer os.Remove(picture)
+	}
+
+	return runEncoder("opusenc", opusArgs(wav, out, tags, picture, opts), out, opts.Verbose)
+}
+
+// opusArgs builds the opusenc command line: VBR at the bitrate for opts,
+// with the tags as Vorbis comments (ReplayGain as R128 gains) and picture
+// (if not "") as the front cover.
+// This is a pure function: (paths, tags, options) → args
+func opusArgs(wav, out string, tags TagSet, picture string, opts EncodeOptions) []string {
+	args := []string{"--bitrate", strconv.Itoa(opusBitrate(opts))}
+	if !opts.Verbose {
+		args = append(args, "--quiet")
+	}
+	for _, c := range tags.opusComments() {
+		args = append(args, "--comment", c)
+	}
+	if picture != "" {
+		args = append(args, "--picture", fmt.Sprintf("3|%s|Cover||%s", tags.coverMIME(), picture))
+	}
+	return append(args, wav, out)
+}
+
+// opusBitrate returns the Opus bitrate in kbps: opts.Bitrate if set,
+// otherwise one matching the LAME quality level. Opus needs far fewer bits
+// than MP3 for the same quality: V2 (~190 kbps MP3) maps to 128 kbps,
+// which is transparent for most music.
+// This is a pure function: EncodeOptions → kbps
+func opusBitrate(opts EncodeOptions) int {
+	if opts.Bitrate > 0 {
+		return opts.Bitrate
+	}
+	// By LAME V level 0-9
+	rates := []int{192, 160, 128, 112, 96, 96, 80, 64, 64, 48}
+	return rates[min(max(opts.Quality, 0), len(rates)-1)]
+}
@@ -0,0 +1,64 @@
+package encode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+func TestOpusArgs(t *testing.T) {
+	tags := TagSet{Title: "Song", TrackNum: 1}
+	got := opusArgs("in.wav", "out.opus", tags, "out.opus.cover", DefaultEncodeOptions())
+	want := []string{
+		"--bitrate", "128", "--quiet",
+		"--comment", "TITLE=Song", "--comment", "TRACKNUMBER=1",
+		"--picture", "3|image/jpeg|Cover||out.opus.cover",
+		"in.wav", "out.opus",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("opusArgs() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestOpusBitrate(t *testing.T) {
+	for _, tt := range []struct {
+		opts EncodeOptions
+		want int
+	}{
+		{EncodeOptions{Quality: 0}, 192},
+		{EncodeOptions{Quality: 2}, 128},
+		{EncodeOptions{Quality: 9}, 48},
+		{EncodeOptions{Quality: 15}, 48},
+		{EncodeOptions{Quality: -1}, 192},
+		{EncodeOptions{Quality: 2, Bitrate: 96}, 96},
+	} {
+		if got := opusBitrate(tt.opts); got != tt.want {
+			t.Errorf("opusBitrate(%+v) = %d, want %d", tt.opts, got, tt.want)
+		}
+	}
+}
+
+func TestOpusEncoder_Integration(t *testing.T) {
+	e, _ := EncoderFor("opus")
+	if !Available(e) {
+		t.Skip("opusenc not installed")
+	}
+
+	dir := t.TempDir()
+	wav := filepath.Join(dir, "test.wav")
+	if err := os.WriteFile(wav, cdda.WriteWAV(make([]byte, 2352*75)), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	out := filepath.Join(dir, "test.opus")
+	tags := TagSet{Title: "Song", CoverArt: testPNG(), CoverArtMIME: "image/png"}
+	if err := e.Encode(wav, out, tags, DefaultEncodeOptions()); err != nil {
+		t.Fatalf("Encode error: %v", err)
+	}
+	if info, err := os.Stat(out); err != nil || info.Size() == 0 {
+		t.Errorf("no Opus output: %v", err)
+	}
+}
@@ -0,0 +1,68 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"image"
+	"image/color"
+	_ "image/jpeg" // Register decoders for DecodeConfig
+	_ "image/png"
+)
+
+// pictureFrontCover is the picture type of a front cover (same as ID3 APIC)
+const pictureFrontCover = 3
+
+// PictureBlock returns the cover art as the body of a FLAC PICTURE metadata
+// block, which is also the (base64-encoded) value of the
+// METADATA_BLOCK_PICTURE Vorbis comment in Ogg files. Dimensions and color
+// depth are read from the image when it is a JPEG or PNG. Returns nil
+// without cover art.
+// This is a pure function: TagSet → block
+func (t TagSet) PictureBlock() []byte {
+	if len(t.CoverArt) == 0 {
+		return nil
+	}
+
+	var width, height, depth, colors int
+	if cfg, _, err := image.DecodeConfig(bytes.NewReader(t.CoverArt)); err == nil {
+		width, height = cfg.Width, cfg.Height
+		depth, colors = colorDepth(cfg.ColorModel)
+	}
+
+	mime, desc := t.coverMIME(), "Cover"
+	var b bytes.Buffer
+	put := func(v int) { binary.Write(&b, binary.BigEndian, uint32(v)) }
+
+	put(pictureFrontCover)
+	put(len(mime))
+	b.WriteString(mime)
+	put(len(desc))
+	b.WriteString(desc)
+	put(width)
+	put(height)
+	put(depth)
+	put(colors) // Palette size, 0 unless indexed
+	put(len(t.CoverArt))
+	b.Write(t.CoverArt)
+
+	return b.Bytes()
+}
+
+// colorDepth returns the bits per pixel of an image color model and, for
+// indexed images, the number of palette colors
+func colorDepth(m color.Model) (depth, colors int) {
+	switch m {
+	case color.GrayModel:
+		return 8, 0
+	case color.Gray16Model:
+		return 16, 0
+	case color.RGBAModel, color.NRGBAModel:
+		return 32, 0
+	case color.RGBA64Model, color.NRGBA64Model:
+		return 64, 0
+	}
+	if p, ok := m.(color.Palette); ok {
+		return 8, len(p)
+	}
+	return 24, 0 // JPEG
+}
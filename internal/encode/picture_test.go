@@ -0,0 +1,58 @@
+package encode
+
+import (
+	"bytes"
+	"encoding/binary"
+	"testing"
+)
+
+func TestPictureBlock(t *testing.T) {
+	cover := testPNG() // 4x4 gray
+	block := TagSet{CoverArt: cover, CoverArtMIME: "image/png"}.PictureBlock()
+
+	r := bytes.NewReader(block)
+	u32 := func() int {
+		var v uint32
+		binary.Read(r, binary.BigEndian, &v)
+		return int(v)
+	}
+	str := func() string {
+		b := make([]byte, u32())
+		r.Read(b)
+		return string(b)
+	}
+
+	if typ := u32(); typ != 3 {
+		t.Errorf("picture type = %d, want 3 (front cover)", typ)
+	}
+	if mime := str(); mime != "image/png" {
+		t.Errorf("MIME = %q", mime)
+	}
+	if desc := str(); desc != "Cover" {
+		t.Errorf("description = %q", desc)
+	}
+	if w, h, depth, colors := u32(), u32(), u32(), u32(); w != 4 || h != 4 || depth != 8 || colors != 0 {
+		t.Errorf("dimensions = %dx%dx%d/%d, want 4x4x8/0", w, h, depth, colors)
+	}
+	data := make([]byte, u32())
+	r.Read(data)
+	if !bytes.Equal(data, cover) || r.Len() != 0 {
+		t.Error("picture data doesn't match the cover art")
+	}
+}
+
+func TestPictureBlock_UnknownImage(t *testing.T) {
+	block := TagSet{CoverArt: []byte("not an image")}.PictureBlock()
+
+	// type, MIME "image/jpeg", "Cover", then zero dimensions
+	dims := 4 + 4 + len("image/jpeg") + 4 + len("Cover")
+	if !bytes.Equal(block[dims:dims+16], make([]byte, 16)) {
+		t.Errorf("dimensions of an unreadable image should be 0, got % x", block[dims:dims+16])
+	}
+}
+
+func TestPictureBlock_NoCover(t *testing.T) {
+	if block := (TagSet{}).PictureBlock(); block != nil {
+		t.Errorf("PictureBlock() = %v, want nil", block)
+	}
+}
@@ -0,0 +1,56 @@
+package encode
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+)
+
+// ReplayGain holds ReplayGain 2.0 values: gains in dB to bring a track or
+// the whole album to -18 LUFS, and sample peaks (1.0 = full scale)
+type ReplayGain struct {
+	TrackGain float64
+	TrackPeak float64
+	AlbumGain float64
+	AlbumPeak float64
+}
+
+// rgTag is one ReplayGain value, named as in ID3 TXXX frames and Vorbis
+// comments
+type rgTag struct {
+	Name  string
+	Value string
+}
+
+// tags formats the values the way foobar2000 and loudgain do. A nil
+// ReplayGain has none.
+// This is a pure function: ReplayGain → tags
+func (r *ReplayGain) tags() []rgTag {
+	if r == nil {
+		return nil
+	}
+	return []rgTag{
+		{"REPLAYGAIN_TRACK_GAIN", fmt.Sprintf("%.2f dB", r.TrackGain)},
+		{"REPLAYGAIN_TRACK_PEAK", fmt.Sprintf("%.6f", r.TrackPeak)},
+		{"REPLAYGAIN_ALBUM_GAIN", fmt.Sprintf("%.2f dB", r.AlbumGain)},
+		{"REPLAYGAIN_ALBUM_PEAK", fmt.Sprintf("%.6f", r.AlbumPeak)},
+	}
+}
+
+// r128Tags returns the gains as Opus R128_*_GAIN comments (RFC 7845): Q7.8
+// fixed point, relative to -23 LUFS rather than ReplayGain's -18. Opus
+// players ignore REPLAYGAIN_* comments.
+// This is a pure function: ReplayGain → tags
+func (r *ReplayGain) r128Tags() []rgTag {
+	if r == nil {
+		return nil
+	}
+	q78 := func(gain float64) string {
+		v := math.Round((gain - 5) * 256)
+		return strconv.Itoa(int(min(max(v, math.MinInt16), math.MaxInt16)))
+	}
+	return []rgTag{
+		{"R128_TRACK_GAIN", q78(r.TrackGain)},
+		{"R128_ALBUM_GAIN", q78(r.AlbumGain)},
+	}
+}
@@ -0,0 +1,105 @@
+package encode
+
+import (
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/bogem/id3v2/v2"
+)
+
+func testReplayGain() *ReplayGain {
+	return &ReplayGain{TrackGain: -7.234, TrackPeak: 0.988037, AlbumGain: -6.5, AlbumPeak: 1}
+}
+
+func TestReplayGain_Tags(t *testing.T) {
+	got := testReplayGain().tags()
+	want := []rgTag{
+		{"REPLAYGAIN_TRACK_GAIN", "-7.23 dB"},
+		{"REPLAYGAIN_TRACK_PEAK", "0.988037"},
+		{"REPLAYGAIN_ALBUM_GAIN", "-6.50 dB"},
+		{"REPLAYGAIN_ALBUM_PEAK", "1.000000"},
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("tags() = %q, want %q", got, want)
+	}
+
+	var none *ReplayGain
+	if got := none.tags(); got != nil {
+		t.Errorf("nil tags() = %q, want none", got)
+	}
+}
+
+func TestReplayGain_R128Tags(t *testing.T) {
+	tests := []struct {
+		name      string
+		gain      float64
+		wantTrack string
+	}{
+		// R128 is 5 dB below ReplayGain's reference, in 1/256 dB
+		{"quiet", 5, "0"},
+		{"loud", -7.234, "-3132"},
+		{"clamped", -200, "-32768"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := (&ReplayGain{TrackGain: tt.gain}).r128Tags()
+			if got[0] != (rgTag{"R128_TRACK_GAIN", tt.wantTrack}) {
+				t.Errorf("r128Tags()[0] = %q, want R128_TRACK_GAIN=%s", got[0], tt.wantTrack)
+			}
+		})
+	}
+}
+
+func TestTagSet_Apply_ReplayGain(t *testing.T) {
+	// id3v2 tags any file; no MP3 encoder needed
+	path := filepath.Join(t.TempDir(), "test.mp3")
+	if err := os.WriteFile(path, append([]byte{0xFF, 0xFB, 0x90, 0x00}, make([]byte, 413)...), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := (TagSet{Title: "Song", ReplayGain: testReplayGain()}).Apply(path); err != nil {
+		t.Fatalf("Apply error: %v", err)
+	}
+
+	tag, err := id3v2.Open(path, id3v2.Options{Parse: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer tag.Close()
+
+	txxx := make(map[string]string)
+	for _, f := range tag.GetFrames("TXXX") {
+		udtf := f.(id3v2.UserDefinedTextFrame)
+		txxx[udtf.Description] = udtf.Value
+	}
+	if got := txxx["REPLAYGAIN_TRACK_GAIN"]; got != "-7.23 dB" {
+		t.Errorf("REPLAYGAIN_TRACK_GAIN = %q, want -7.23 dB", got)
+	}
+	if got := txxx["REPLAYGAIN_ALBUM_PEAK"]; got != "1.000000" {
+		t.Errorf("REPLAYGAIN_ALBUM_PEAK = %q, want 1.000000", got)
+	}
+}
+
+func TestVorbisComments_ReplayGain(t *testing.T) {
+	got := TagSet{Title: "Song", ReplayGain: testReplayGain()}.VorbisComments()
+	want := []string{
+		"TITLE=Song",
+		"REPLAYGAIN_TRACK_GAIN=-7.23 dB",
+		"REPLAYGAIN_TRACK_PEAK=0.988037",
+		"REPLAYGAIN_ALBUM_GAIN=-6.50 dB",
+		"REPLAYGAIN_ALBUM_PEAK=1.000000",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("VorbisComments() = %q, want %q", got, want)
+	}
+}
+
+func TestOpusComments_R128(t *testing.T) {
+	got := TagSet{Title: "Song", ReplayGain: testReplayGain()}.opusComments()
+	want := []string{"TITLE=Song", "R128_TRACK_GAIN=-3132", "R128_ALBUM_GAIN=-2944"}
+	if !slices.Equal(got, want) {
+		t.Errorf("opusComments() = %q, want %q", got, want)
+	}
+}
@@ -3,6 +3,7 @@ package encode
 import (
 	"fmt"
 	"strconv"
+	"strings"
 
 	"github.com/bogem/id3v2/v2"
 )
@@ -20,11 +21,14 @@ type TrackMeta struct {
 	Year         int
 	Genre        string
 	Compilation  bool
+	ISRC         string // From the disc's subchannel ("" if none)
 	CoverArt     []byte // Optional album cover (JPEG/PNG)
 	CoverArtMIME string // MIME type (image/jpeg or image/png)
+	MusicBrainz  MusicBrainzIDs
+	ReplayGain   *ReplayGain // nil = not analyzed
 }
 
-// TagSet contains the ID3 tags to be written
+// TagSet contains the tags to be written, as ID3 frames or Vorbis comments
 type TagSet struct {
 	Artist       string
 	AlbumArtist  string
@@ -37,8 +41,11 @@ type TagSet struct {
 	Year         int
 	Genre        string
 	Compilation  bool
+	ISRC         string
 	CoverArt     []byte
 	CoverArtMIME string
+	MusicBrainz  MusicBrainzIDs
+	ReplayGain   *ReplayGain
 }
 
 // BuildTags creates a TagSet from track metadata.
@@ -57,8 +64,11 @@ func BuildTags(meta TrackMeta) TagSet {
 		Year:         meta.Year,
 		Genre:        meta.Genre,
 		Compilation:  meta.Compilation,
+		ISRC:         meta.ISRC,
 		CoverArt:     meta.CoverArt,
 		CoverArtMIME: meta.CoverArtMIME,
+		MusicBrainz:  meta.MusicBrainz,
+		ReplayGain:   meta.ReplayGain,
 	}
 }
 
@@ -114,15 +124,41 @@ func (t TagSet) Apply(filepath string) error {
 		tag.AddTextFrame("TCMP", id3v2.EncodingUTF8, "1")
 	}
 
+	// ISRC (TSRC)
+	if t.ISRC != "" {
+		tag.AddTextFrame("TSRC", id3v2.EncodingUTF8, t.ISRC)
+	}
+
+	// MusicBrainz identifiers (TXXX, recording as UFID)
+	for _, mb := range t.MusicBrainz.tags() {
+		if mb.UFID {
+			tag.AddUFIDFrame(id3v2.UFIDFrame{
+				OwnerIdentifier: "http://musicbrainz.org",
+				Identifier:      []byte(mb.Values[0]),
+			})
+			continue
+		}
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: mb.Name,
+			Value:       strings.Join(mb.Values, "\x00"), // ID3v2.4 multiple values
+		})
+	}
+
+	// ReplayGain (TXXX)
+	for _, rg := range t.ReplayGain.tags() {
+		tag.AddUserDefinedTextFrame(id3v2.UserDefinedTextFrame{
+			Encoding:    id3v2.EncodingUTF8,
+			Description: rg.Name,
+			Value:       rg.Value,
+		})
+	}
+
 	// Cover art (APIC)
 	if len(t.CoverArt) > 0 {
-		mimeType := t.CoverArtMIME
-		if mimeType == "" {
-			mimeType = "image/jpeg" // Fallback
-		}
 		pic := id3v2.PictureFrame{
 			Encoding:    id3v2.EncodingUTF8,
-			MimeType:    mimeType,
+			MimeType:    t.coverMIME(),
 			PictureType: id3v2.PTFrontCover,
 			Description: "Cover",
 			Picture:     t.CoverArt,
@@ -43,6 +43,7 @@ func TestTagSet_Apply_Integration(t *testing.T) {
 		TrackTotal: 12,
 		Year:       2024,
 		Genre:      "Rock",
+		ISRC:       "USEE10001993",
 	})
 
 	if err := tags.Apply(mp3Path); err != nil {
@@ -71,6 +72,9 @@ func TestTagSet_Apply_Integration(t *testing.T) {
 	if tag.Genre() != "Rock" {
 		t.Errorf("Genre = %q, want %q", tag.Genre(), "Rock")
 	}
+	if tf := tag.GetTextFrame("TSRC"); tf.Text != "USEE10001993" {
+		t.Errorf("TSRC = %q, want %q", tf.Text, "USEE10001993")
+	}
 }
 
 func TestTagSet_Apply_Compilation(t *testing.T) {
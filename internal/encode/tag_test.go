@@ -112,6 +112,19 @@ func TestBuildTags_Genre(t *testing.T) {
 	}
 }
 
+func TestBuildTags_ISRC(t *testing.T) {
+	tags := BuildTags(TrackMeta{
+		Artist:   "Queen",
+		Title:    "Bohemian Rhapsody",
+		TrackNum: 11,
+		ISRC:     "GBUM71029604",
+	})
+
+	if tags.ISRC != "GBUM71029604" {
+		t.Errorf("ISRC = %q, want %q", tags.ISRC, "GBUM71029604")
+	}
+}
+
 func TestBuildTags_CoverArt(t *testing.T) {
 	// Cover art should pass through unchanged
 	coverData := []byte{0xFF, 0xD8, 0xFF, 0xE0} // JPEG magic bytes
@@ -0,0 +1,271 @@
+package encode
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+)
+
+// Template is a parsed file name template. Text is copied as is and "/"
+// separates directories; {field} is replaced by a track's metadata:
+//
+//	{title}        the sanitized value
+//	{track:02}     a printf width and flags: 01, 02, ...
+//	{disc:?CD%d/}  printf text, only if the field is set
+//
+// Fields are artist, albumartist, album, title, genre, track, tracktotal,
+// disc, disctotal and year. A number of 0 counts as unknown. An artist,
+// album or title with nothing left after sanitizing gets a placeholder
+// such as "Unknown Artist" or "Track 03". With the ASCII charset, spaces
+// in the template's own text follow the sanitizer too: " - " becomes "-"
+// and other spaces "_", so "{track:02} {title}" gives 01_Song_Title.
+type Template struct {
+	text  string
+	parts []templatePart
+}
+
+// templatePart is literal text or a field with its format
+type templatePart struct {
+	literal string
+	field   string
+	format  string // After the ":", without a leading "?"
+	cond    bool   // format is printf text used only if the field is set
+}
+
+// templateFields gets each field from the metadata, as a string or int
+var templateFields = map[string]func(TrackMeta) any{
+	"artist": func(m TrackMeta) any { return m.Artist },
+	"albumartist": func(m TrackMeta) any {
+		if m.AlbumArtist == "" {
+			return m.Artist
+		}
+		return m.AlbumArtist
+	},
+	"album":      func(m TrackMeta) any { return m.Album },
+	"title":      func(m TrackMeta) any { return m.Title },
+	"genre":      func(m TrackMeta) any { return m.Genre },
+	"track":      func(m TrackMeta) any { return m.TrackNum },
+	"tracktotal": func(m TrackMeta) any { return m.TrackTotal },
+	"disc":       func(m TrackMeta) any { return m.DiscNum },
+	"disctotal":  func(m TrackMeta) any { return m.DiscTotal },
+	"year":       func(m TrackMeta) any { return m.Year },
+}
+
+// templateFallbacks name a track when a field is empty after sanitizing,
+// so a file name never loses its artist, album or title
+var templateFallbacks = map[string]func(TrackMeta) string{
+	"artist":      func(TrackMeta) string { return "Unknown Artist" },
+	"albumartist": func(TrackMeta) string { return "Unknown Artist" },
+	"album":       func(TrackMeta) string { return "Unknown Album" },
+	"title":       func(m TrackMeta) string { return fmt.Sprintf("Track %02d", m.TrackNum) },
+}
+
+// ParseTemplate parses a file name template.
+// This is a pure function: text → Template
+func ParseTemplate(text string) (Template, error) {
+	t := Template{text: text}
+	for rest := text; rest != ""; {
+		open := strings.IndexAny(rest, "{}")
+		if open < 0 {
+			t.parts = append(t.parts, templatePart{literal: rest})
+			break
+		}
+		if rest[open] == '}' {
+			return Template{}, fmt.Errorf("template %q: unmatched }", text)
+		}
+		if open > 0 {
+			t.parts = append(t.parts, templatePart{literal: rest[:open]})
+		}
+		end := strings.IndexByte(rest[open:], '}')
+		if end < 0 {
+			return Template{}, fmt.Errorf("template %q: unclosed {", text)
+		}
+
+		p, err := parseField(rest[open+1 : open+end])
+		if err != nil {
+			return Template{}, fmt.Errorf("template %q: %w", text, err)
+		}
+		t.parts = append(t.parts, p)
+		rest = rest[open+end+1:]
+	}
+	return t, nil
+}
+
+// parseField parses the inside of a {field} or {field:format}
+func parseField(s string) (templatePart, error) {
+	name, format, _ := strings.Cut(s, ":")
+	get, ok := templateFields[name]
+	if !ok {
+		return templatePart{}, fmt.Errorf("unknown field {%s} (have %s)", name, strings.Join(TemplateFields(), ", "))
+	}
+	p := templatePart{field: name, format: format}
+	p.format, p.cond = strings.CutPrefix(format, "?")
+
+	// Catch a format that doesn't suit the field's type
+	if p.format != "" && strings.Contains(p.printf(get(TrackMeta{})), "%!") {
+		return templatePart{}, fmt.Errorf("bad format %q for {%s}", format, name)
+	}
+	return p, nil
+}
+
+// TemplateFields lists the fields a template can use
+func TemplateFields() []string {
+	var names []string
+	for name := range templateFields {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// String returns the template text
+func (t Template) String() string {
+	return t.text
+}
+
+// Filename returns the path of a track's file relative to the destination
+// directory, with the fields sanitized by s. Empty directories are
+// dropped, and "." and ".." can't be produced by the metadata.
+// This is a pure function: (Template, TrackMeta, ext, Sanitizer) → path
+func (t Template) Filename(meta TrackMeta, ext string, s Sanitizer) string {
+	var b strings.Builder
+	for _, p := range t.parts {
+		text := p.render(meta, s)
+		if s.Charset != CharsetUnicode {
+			text = asciiSpaces(text)
+		}
+		b.WriteString(text)
+	}
+
+	var dirs []string
+	for _, dir := range strings.Split(b.String(), "/") {
+		switch dir {
+		case "":
+		case ".", "..":
+			dirs = append(dirs, "_")
+		default:
+			dirs = append(dirs, dir)
+		}
+	}
+	return strings.Join(dirs, "/") + ext
+}
+
+// asciiSpaces replaces the spaces in template text for ASCII names:
+// a " - " separator becomes "-" as in the flat preset, and other runs of
+// spaces a single "_" as the sanitizer does.
+// This is a pure function: string → string
+func asciiSpaces(text string) string {
+	text = strings.ReplaceAll(text, " - ", "-")
+	var b strings.Builder
+	for i, r := range text {
+		if r != ' ' {
+			b.WriteRune(r)
+		} else if i == 0 || text[i-1] != ' ' {
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
+
+// render returns the part's text for a track
+func (p templatePart) render(meta TrackMeta, s Sanitizer) string {
+	if p.field == "" {
+		return p.literal
+	}
+	v := templateFields[p.field](meta)
+	if str, ok := v.(string); ok {
+		str = s.Sanitize(str)
+		if fallback, ok := templateFallbacks[p.field]; ok && str == "" {
+			str = s.Sanitize(fallback(meta))
+		}
+		v = str
+	}
+	if v == "" || v == 0 {
+		return ""
+	}
+	return p.printf(v)
+}
+
+// printf formats a field's value as the part says
+func (p templatePart) printf(v any) string {
+	switch {
+	case p.cond:
+		return fmt.Sprintf(p.format, v)
+	case p.format == "":
+		return fmt.Sprint(v)
+	}
+	verb := "s"
+	if _, ok := v.(int); ok {
+		verb = "d"
+	}
+	return fmt.Sprintf("%"+p.format+verb, v)
+}
+
+// Naming holds the file name templates for an album's tracks and how
+// their metadata is sanitized
+type Naming struct {
+	Album       Template
+	Compilation Template // Various-artists releases
+	Sanitizer   Sanitizer
+}
+
+// presets are the named file layouts. "flat" is the original
+// Artist-Album-NN-Title.ext in one directory.
+var presets = map[string]Naming{
+	"flat": {
+		Album:       mustParseTemplate("{albumartist}-{album}-{disc:?CD%d-}{track:02}-{title}"),
+		Compilation: mustParseTemplate("{album}-{disc:?CD%d-}{track:02}-{artist}-{title}"),
+	},
+	"library": {
+		Album:       mustParseTemplate("{albumartist}/{year:?%d - }{album}/{disc:?CD%d/}{track:02} {title}"),
+		Compilation: mustParseTemplate("{albumartist}/{year:?%d - }{album}/{disc:?CD%d/}{track:02} {artist} - {title}"),
+	},
+}
+
+// DefaultPreset is the naming used when none is chosen
+const DefaultPreset = "flat"
+
+func mustParseTemplate(text string) Template {
+	t, err := ParseTemplate(text)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// Presets lists the named file layouts
+func Presets() []string {
+	var names []string
+	for name := range presets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// NamingFor returns a preset by name, or the naming for a template (used
+// for compilations too).
+// This is a pure function: name or template → Naming
+func NamingFor(s string) (Naming, error) {
+	if n, ok := presets[s]; ok {
+		return n, nil
+	}
+	if !strings.Contains(s, "{") {
+		return Naming{}, fmt.Errorf("unknown naming preset %q (have %s, or a template)", s, strings.Join(Presets(), ", "))
+	}
+	t, err := ParseTemplate(s)
+	if err != nil {
+		return Naming{}, err
+	}
+	return Naming{Album: t, Compilation: t}, nil
+}
+
+// Filename returns the path of a track's file relative to the destination
+// directory.
+// This is a pure function: (Naming, TrackMeta, ext) → path
+func (n Naming) Filename(meta TrackMeta, ext string) string {
+	if meta.Compilation {
+		return n.Compilation.Filename(meta, ext, n.Sanitizer)
+	}
+	return n.Album.Filename(meta, ext, n.Sanitizer)
+}
@@ -0,0 +1,219 @@
+package encode
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func testMeta() TrackMeta {
+	return TrackMeta{
+		Artist:      "Queen",
+		AlbumArtist: "Queen",
+		Album:       "A Night at the Opera",
+		Title:       "Bohemian Rhapsody",
+		TrackNum:    11,
+		TrackTotal:  12,
+		DiscNum:     0,
+		Year:        1975,
+		Genre:       "Rock",
+	}
+}
+
+func TestTemplate_Filename(t *testing.T) {
+	tests := []struct {
+		name     string
+		template string
+		meta     func(*TrackMeta)
+		want     string
+	}{
+		{"fields", "{artist} - {title}", nil, "Queen-Bohemian_Rhapsody.flac"},
+		{"directories", "{albumartist}/{year} - {album}/{track:02} {title}", nil, "Queen/1975-A_Night_at_the_Opera/11_Bohemian_Rhapsody.flac"},
+		{"padding", "{track:03}", nil, "011.flac"},
+		{"conditional unset", "{disc:?CD%d/}{track:02}", nil, "11.flac"},
+		{"conditional set", "{disc:?CD%d/}{track:02}", func(m *TrackMeta) { m.DiscNum = 2 }, "CD2/11.flac"},
+		{"conditional string", "{genre:?%s/}{title}", nil, "Rock/Bohemian_Rhapsody.flac"},
+		{"album artist falls back", "{albumartist}", func(m *TrackMeta) { m.AlbumArtist = "" }, "Queen.flac"},
+		{"unknown year", "{year}/{album}", func(m *TrackMeta) { m.Year = 0 }, "A_Night_at_the_Opera.flac"},
+		{"slash in value", "{artist}/{title}", func(m *TrackMeta) { m.Artist = "AC/DC" }, "AC_DC/Bohemian_Rhapsody.flac"},
+		{"dot directory", "{album}/{title}", func(m *TrackMeta) { m.Album = ".." }, "_/Bohemian_Rhapsody.flac"},
+		{"empty title", "{track:02} {title}", func(m *TrackMeta) { m.Title = "" }, "11_Track_11.flac"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpl, err := ParseTemplate(tt.template)
+			if err != nil {
+				t.Fatalf("ParseTemplate error: %v", err)
+			}
+			meta := testMeta()
+			if tt.meta != nil {
+				tt.meta(&meta)
+			}
+			if got := tmpl.Filename(meta, ".flac", Sanitizer{}); got != tt.want {
+				t.Errorf("Filename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTemplate_Filename_Spaces(t *testing.T) {
+	tmpl, _ := ParseTemplate("{year:?%d - }{album}/{track:02}  {title:-20}")
+	meta := testMeta()
+
+	// ASCII names keep no spaces, even from the template or padding
+	if got, want := tmpl.Filename(meta, ".flac", Sanitizer{}), "1975-A_Night_at_the_Opera/11_Bohemian_Rhapsody_.flac"; got != want {
+		t.Errorf("ASCII Filename() = %q, want %q", got, want)
+	}
+	unicode := Sanitizer{Charset: CharsetUnicode}
+	if got, want := tmpl.Filename(meta, ".flac", unicode), "1975 - A Night at the Opera/11  Bohemian Rhapsody   .flac"; got != want {
+		t.Errorf("Unicode Filename() = %q, want %q", got, want)
+	}
+}
+
+func TestParseTemplate_Errors(t *testing.T) {
+	tests := []struct {
+		template string
+		want     string
+	}{
+		{"{artist", "unclosed {"},
+		{"artist}", "unmatched }"},
+		{"{composer}", "unknown field {composer}"},
+		{"{title:?CD%d}", "bad format"},
+		{"{disc:?CD}", "bad format"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.template, func(t *testing.T) {
+			_, err := ParseTemplate(tt.template)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("ParseTemplate(%q) error = %v, want %q", tt.template, err, tt.want)
+			}
+		})
+	}
+}
+
+func TestNaming_Presets(t *testing.T) {
+	meta := testMeta()
+	comp := testMeta()
+	comp.Compilation, comp.AlbumArtist, comp.DiscNum = true, "Various Artists", 2
+
+	tests := []struct {
+		preset string
+		meta   TrackMeta
+		want   string
+	}{
+		{"flat", meta, "Queen-A_Night_at_the_Opera-11-Bohemian_Rhapsody.mp3"},
+		{"flat", comp, "A_Night_at_the_Opera-CD2-11-Queen-Bohemian_Rhapsody.mp3"},
+		{"library", meta, "Queen/1975-A_Night_at_the_Opera/11_Bohemian_Rhapsody.mp3"},
+		{"library", comp, "Various_Artists/1975-A_Night_at_the_Opera/CD2/11_Queen-Bohemian_Rhapsody.mp3"},
+	}
+	for _, tt := range tests {
+		n, err := NamingFor(tt.preset)
+		if err != nil {
+			t.Fatalf("NamingFor(%q) error: %v", tt.preset, err)
+		}
+		if got := n.Filename(tt.meta, ".mp3"); got != tt.want {
+			t.Errorf("%s: Filename() = %q, want %q", tt.preset, got, tt.want)
+		}
+	}
+}
+
+func TestNamingFor(t *testing.T) {
+	n, err := NamingFor("{artist}/{title}")
+	if err != nil {
+		t.Fatalf("NamingFor error: %v", err)
+	}
+	comp := testMeta()
+	comp.Compilation = true
+	if got := n.Filename(comp, ".ogg"); got != "Queen/Bohemian_Rhapsody.ogg" {
+		t.Errorf("compilation Filename() = %q, want the template used for both", got)
+	}
+
+	if _, err := NamingFor("tree"); err == nil || !strings.Contains(err.Error(), "flat, library") {
+		t.Errorf("NamingFor(tree) error = %v, want the presets listed", err)
+	}
+}
+
+func TestConfig_Naming(t *testing.T) {
+	library, _ := NamingFor("library")
+	meta := testMeta()
+
+	tests := []struct {
+		name     string
+		config   Config
+		template string
+		want     string
+	}{
+		{"default", Config{}, "", "Queen-A_Night_at_the_Opera-11-Bohemian_Rhapsody.mp3"},
+		{"config", Config{Template: "library"}, "", library.Filename(meta, ".mp3")},
+		{"flag wins", Config{Template: "library"}, "{title}", "Bohemian_Rhapsody.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.config.Naming(Config{Template: tt.template})
+			if err != nil {
+				t.Fatalf("Naming error: %v", err)
+			}
+			if got := n.Filename(meta, ".mp3"); got != tt.want {
+				t.Errorf("Filename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Config{Template: "{bad"}).Naming(Config{}); err == nil || !strings.HasPrefix(err.Error(), "config: ") {
+		t.Errorf("bad config template error = %v", err)
+	}
+}
+
+func TestConfig_Naming_Charset(t *testing.T) {
+	meta := testMeta()
+	meta.Artist, meta.AlbumArtist, meta.Title = "Кино", "", "Группа крови"
+
+	tests := []struct {
+		name   string
+		config Config
+		flags  Config
+		want   string
+	}{
+		{"ascii drops cyrillic", Config{}, Config{}, "Unknown_Artist-A_Night_at_the_Opera-11-Track_11.mp3"},
+		{"config transliterates", Config{Transliterate: true}, Config{}, "Kino-A_Night_at_the_Opera-11-Gruppa_krovi.mp3"},
+		{"flag charset", Config{}, Config{Charset: "unicode"}, "Кино-A Night at the Opera-11-Группа крови.mp3"},
+		{"flag wins", Config{Charset: "ascii"}, Config{Charset: "unicode", Transliterate: true}, "Kino-A Night at the Opera-11-Gruppa krovi.mp3"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			n, err := tt.config.Naming(tt.flags)
+			if err != nil {
+				t.Fatalf("Naming error: %v", err)
+			}
+			if got := n.Filename(meta, ".mp3"); got != tt.want {
+				t.Errorf("Filename() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+
+	if _, err := (Config{}).Naming(Config{Charset: "ebcdic"}); err == nil {
+		t.Error("Naming accepted an unknown charset")
+	}
+}
+
+func TestLoadConfig(t *testing.T) {
+	dir := t.TempDir()
+
+	config, err := LoadConfig(filepath.Join(dir, "missing.json"))
+	if err != nil || config != (Config{}) {
+		t.Errorf("missing file = %+v, %v, want empty config", config, err)
+	}
+
+	path := filepath.Join(dir, "config.json")
+	os.WriteFile(path, []byte(`{"template": "library"}`), 0644)
+	config, err = LoadConfig(path)
+	if err != nil || config.Template != "library" {
+		t.Errorf("LoadConfig = %+v, %v, want the library template", config, err)
+	}
+
+	os.WriteFile(path, []byte(`{`), 0644)
+	if _, err := LoadConfig(path); err == nil {
+		t.Error("LoadConfig succeeded on bad JSON")
+	}
+}
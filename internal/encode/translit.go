@@ -0,0 +1,138 @@
+package encode
+
+import (
+	"strings"
+	"unicode"
+)
+
+// transliterate writes kana as Hepburn romaji and Cyrillic in Latin
+// letters, leaving other text alone. Kanji need a dictionary to read, so
+// they are left too.
+// This is a pure function: string → string
+func transliterate(s string) string {
+	var b strings.Builder
+	rs := []rune(s)
+	double := false // After a small tsu: double the next consonant
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+
+		if latin, ok := cyrillic[unicode.ToLower(r)]; ok {
+			if unicode.IsUpper(r) && latin != "" {
+				// Capitalize, or all caps when the next letter is too
+				if i+1 < len(rs) && unicode.IsUpper(rs[i+1]) {
+					latin = strings.ToUpper(latin)
+				} else {
+					latin = strings.ToUpper(latin[:1]) + latin[1:]
+				}
+			}
+			b.WriteString(latin)
+			continue
+		}
+
+		h := toHiragana(r)
+		switch {
+		case h == 'っ':
+			double = true
+			continue
+		case h == 'ー': // Long vowel: repeat the previous one
+			if out := b.String(); out != "" && strings.ContainsRune("aiueo", rune(out[len(out)-1])) {
+				b.WriteByte(out[len(out)-1])
+			}
+			continue
+		case h == '・':
+			b.WriteByte(' ')
+			continue
+		}
+
+		syllable, ok := kana[h]
+		if !ok {
+			double = false
+			b.WriteRune(r)
+			continue
+		}
+
+		// A small kana combines with the syllable: きゃ kya, ファ fa
+		if i+1 < len(rs) {
+			if small, ok := smallKana[toHiragana(rs[i+1])]; ok && len(syllable) > 1 {
+				syllable = combineKana(syllable, small)
+				i++
+			}
+		}
+
+		if double {
+			switch {
+			case strings.HasPrefix(syllable, "ch"):
+				syllable = "t" + syllable
+			case !strings.ContainsRune("aiueon", rune(syllable[0])):
+				syllable = syllable[:1] + syllable
+			}
+			double = false
+		}
+		b.WriteString(syllable)
+	}
+	return b.String()
+}
+
+// toHiragana maps katakana to the matching hiragana
+func toHiragana(r rune) rune {
+	if r >= 'ァ' && r <= 'ヶ' {
+		return r - 'ァ' + 'ぁ'
+	}
+	return r
+}
+
+// combineKana joins a syllable and a following small kana: yōon (ki + ya
+// = kya, shi + ya = sha) and the extended katakana vowels (fu + a = fa)
+func combineKana(syllable, small string) string {
+	stem := syllable[:len(syllable)-1]
+	if small[0] != 'y' {
+		return stem + small
+	}
+	if stem == "sh" || stem == "ch" || stem == "j" {
+		return stem + small[1:]
+	}
+	return stem + small
+}
+
+// kana is Hepburn romaji for each hiragana (katakana via toHiragana)
+var kana = map[rune]string{
+	'あ': "a", 'い': "i", 'う': "u", 'え': "e", 'お': "o",
+	'か': "ka", 'き': "ki", 'く': "ku", 'け': "ke", 'こ': "ko",
+	'が': "ga", 'ぎ': "gi", 'ぐ': "gu", 'げ': "ge", 'ご': "go",
+	'さ': "sa", 'し': "shi", 'す': "su", 'せ': "se", 'そ': "so",
+	'ざ': "za", 'じ': "ji", 'ず': "zu", 'ぜ': "ze", 'ぞ': "zo",
+	'た': "ta", 'ち': "chi", 'つ': "tsu", 'て': "te", 'と': "to",
+	'だ': "da", 'ぢ': "ji", 'づ': "zu", 'で': "de", 'ど': "do",
+	'な': "na", 'に': "ni", 'ぬ': "nu", 'ね': "ne", 'の': "no",
+	'は': "ha", 'ひ': "hi", 'ふ': "fu", 'へ': "he", 'ほ': "ho",
+	'ば': "ba", 'び': "bi", 'ぶ': "bu", 'べ': "be", 'ぼ': "bo",
+	'ぱ': "pa", 'ぴ': "pi", 'ぷ': "pu", 'ぺ': "pe", 'ぽ': "po",
+	'ま': "ma", 'み': "mi", 'む': "mu", 'め': "me", 'も': "mo",
+	'や': "ya", 'ゆ': "yu", 'よ': "yo",
+	'ら': "ra", 'り': "ri", 'る': "ru", 'れ': "re", 'ろ': "ro",
+	'わ': "wa", 'ゐ': "i", 'ゑ': "e", 'を': "o", 'ん': "n", 'ゔ': "vu",
+	// Small kana on their own
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo", 'ゎ': "wa",
+}
+
+// smallKana are the small kana that combine with the syllable before
+var smallKana = map[rune]string{
+	'ぁ': "a", 'ぃ': "i", 'ぅ': "u", 'ぇ': "e", 'ぉ': "o",
+	'ゃ': "ya", 'ゅ': "yu", 'ょ': "yo",
+}
+
+// cyrillic is a Latin spelling of each lowercase Cyrillic letter, after
+// the common passport and BGN/PCGN systems (Russian, Ukrainian,
+// Belarusian, Serbian, Macedonian and Bulgarian letters)
+var cyrillic = map[rune]string{
+	'а': "a", 'б': "b", 'в': "v", 'г': "g", 'д': "d", 'е': "e", 'ё': "yo",
+	'ж': "zh", 'з': "z", 'и': "i", 'й': "y", 'к': "k", 'л': "l", 'м': "m",
+	'н': "n", 'о': "o", 'п': "p", 'р': "r", 'с': "s", 'т': "t", 'у': "u",
+	'ф': "f", 'х': "kh", 'ц': "ts", 'ч': "ch", 'ш': "sh", 'щ': "shch",
+	'ъ': "", 'ы': "y", 'ь': "", 'э': "e", 'ю': "yu", 'я': "ya",
+	'є': "ye", 'і': "i", 'ї': "yi", 'ґ': "g", 'ў': "w",
+	'ђ': "dj", 'ј': "j", 'љ': "lj", 'њ': "nj", 'ћ': "c", 'џ': "dz",
+	'ѓ': "gj", 'ќ': "kj", 'ѕ': "dz",
+}
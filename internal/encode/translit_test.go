@@ -0,0 +1,34 @@
+package encode
+
+import "testing"
+
+func TestTransliterate(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{"hiragana", "さくら", "sakura"},
+		{"katakana", "カタカナ", "katakana"},
+		{"yoon", "きょうと しゃしん ちゃ じゅう", "kyouto shashin cha juu"},
+		{"sokuon", "ざっし まっちゃ", "zasshi matcha"},
+		{"long vowel", "ラーメン", "raamen"},
+		{"extended katakana", "ファイル ティー", "fairu tii"},
+		{"middle dot", "ボン・ジョヴィ", "bon jovi"},
+		{"mixed", "ONE OK ROCKのライブ", "ONE OK ROCKnoraibu"},
+		{"russian", "Кино — Группа крови", "Kino — Gruppa krovi"},
+		{"all caps", "ДДТ", "DDT"},
+		{"capitalized digraph", "Жанна Щукина", "Zhanna Shchukina"},
+		{"hard sign", "Объект", "Obekt"},
+		{"ukrainian", "Їжак і Ґанок", "Yizhak i Ganok"},
+		{"serbian", "Ђорђе Балашевић", "Djordje Balashevic"},
+		{"other scripts unchanged", "椎名林檎 Café", "椎名林檎 Café"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := transliterate(tt.in); got != tt.want {
+				t.Errorf("transliterate(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,70 @@
+package encode
+
+import (
+	"strconv"
+)
+
+// VorbisComments returns the tags as Vorbis comments ("FIELD=value"), the
+// tag format of FLAC, Ogg Vorbis and Opus. Empty fields are left out;
+// cover art is written separately as a picture.
+// This is a pure function: TagSet → comments
+func (t TagSet) VorbisComments() []string {
+	var c []string
+	add := func(field, value string) {
+		if value != "" {
+			c = append(c, field+"="+value)
+		}
+	}
+	num := func(n int) string {
+		if n <= 0 {
+			return ""
+		}
+		return strconv.Itoa(n)
+	}
+
+	add("TITLE", t.Title)
+	add("ARTIST", t.Artist)
+	add("ALBUM", t.Album)
+	add("ALBUMARTIST", t.AlbumArtist)
+	add("TRACKNUMBER", num(t.TrackNum))
+	add("TRACKTOTAL", num(t.TrackTotal))
+	add("DISCNUMBER", num(t.DiscNum))
+	add("DISCTOTAL", num(t.DiscTotal))
+	add("DATE", num(t.Year))
+	add("GENRE", t.Genre)
+	if t.Compilation {
+		add("COMPILATION", "1")
+	}
+	add("ISRC", t.ISRC)
+	for _, mb := range t.MusicBrainz.tags() {
+		for _, v := range mb.Values {
+			add(mb.Vorbis, v)
+		}
+	}
+	for _, rg := range t.ReplayGain.tags() {
+		add(rg.Name, rg.Value)
+	}
+
+	return c
+}
+
+// opusComments returns the Vorbis comments for an Opus file, with the
+// ReplayGain values as R128 gains.
+// This is a pure function: TagSet → comments
+func (t TagSet) opusComments() []string {
+	rg := t.ReplayGain
+	t.ReplayGain = nil
+	c := t.VorbisComments()
+	for _, r := range rg.r128Tags() {
+		c = append(c, r.Name+"="+r.Value)
+	}
+	return c
+}
+
+// coverMIME returns the cover art's MIME type, defaulting to JPEG
+func (t TagSet) coverMIME() string {
+	if t.CoverArtMIME == "" {
+		return "image/jpeg"
+	}
+	return t.CoverArtMIME
+}
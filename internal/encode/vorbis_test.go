@@ -0,0 +1,51 @@
+package encode
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVorbisComments(t *testing.T) {
+	tags := TagSet{
+		Artist:      "Queen",
+		AlbumArtist: "Various Artists",
+		Album:       "Now 100",
+		Title:       "Bohemian Rhapsody",
+		TrackNum:    5,
+		TrackTotal:  20,
+		DiscNum:     2,
+		DiscTotal:   2,
+		Year:        2018,
+		Genre:       "Pop",
+		Compilation: true,
+		ISRC:        "GBUM71029604",
+		CoverArt:    []byte{0xFF, 0xD8},
+	}
+
+	got := tags.VorbisComments()
+	want := []string{
+		"TITLE=Bohemian Rhapsody",
+		"ARTIST=Queen",
+		"ALBUM=Now 100",
+		"ALBUMARTIST=Various Artists",
+		"TRACKNUMBER=5",
+		"TRACKTOTAL=20",
+		"DISCNUMBER=2",
+		"DISCTOTAL=2",
+		"DATE=2018",
+		"GENRE=Pop",
+		"COMPILATION=1",
+		"ISRC=GBUM71029604",
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("VorbisComments() =\n%q\nwant\n%q", got, want)
+	}
+}
+
+func TestVorbisComments_OmitsEmpty(t *testing.T) {
+	got := TagSet{Title: "Song", TrackNum: 1}.VorbisComments()
+	want := []string{"TITLE=Song", "TRACKNUMBER=1"}
+	if !slices.Equal(got, want) {
+		t.Errorf("VorbisComments() = %q, want %q", got, want)
+	}
+}
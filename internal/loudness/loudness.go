@@ -0,0 +1,247 @@
+// Package loudness measures the loudness of CD audio as ITU-R BS.1770 /
+// EBU R128 integrated loudness and sample peak, for ReplayGain 2.0 and
+// Opus R128 gain tags.
+package loudness
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"os"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// Reference loudness levels in LUFS
+const (
+	ReplayGainReference = -18.0 // ReplayGain 2.0
+	R128Reference       = -23.0 // EBU R128, used by Opus
+)
+
+// Gating thresholds of BS.1770
+const (
+	absoluteGate = -70.0 // LUFS
+	relativeGate = -10.0 // LU below the ungated loudness
+)
+
+// subBlock is 100ms in sample frames: gating blocks are 400ms long and
+// start every 100ms (75% overlap), so each is 4 consecutive sub-blocks
+const subBlock = cdda.SampleRate / 10
+
+// wavHeaderScan is how much of a WAV file is read to find its data chunk
+const wavHeaderScan = 4096
+
+// Result is the measured loudness of a track or album
+type Result struct {
+	Peak   float64   // Sample peak, 1.0 = full scale
+	blocks []float64 // Mean square of each 400ms gating block
+}
+
+// Loudness returns the integrated loudness in LUFS, or -Inf for silence.
+// This is a pure function: Result → LUFS
+func (r Result) Loudness() float64 {
+	// Absolute gate, then a relative gate 10 LU below what passes it
+	gated := gate(r.blocks, power(absoluteGate))
+	if len(gated) == 0 {
+		return math.Inf(-1)
+	}
+	gated = gate(gated, mean(gated)*math.Pow(10, relativeGate/10))
+	return lufs(mean(gated))
+}
+
+// ReplayGain returns the ReplayGain 2.0 gain in dB: the change that brings
+// the audio to -18 LUFS. Silence gets no gain.
+// This is a pure function: Result → dB
+func (r Result) ReplayGain() float64 {
+	l := r.Loudness()
+	if math.IsInf(l, -1) {
+		return 0
+	}
+	return ReplayGainReference - l
+}
+
+// Album combines track results: the loudness of the tracks played in
+// sequence and the highest peak.
+// This is a pure function: []Result → Result
+func Album(tracks []Result) Result {
+	var album Result
+	for _, t := range tracks {
+		album.Peak = max(album.Peak, t.Peak)
+		album.blocks = append(album.blocks, t.blocks...)
+	}
+	return album
+}
+
+// Analyzer measures 16-bit stereo PCM at 44.1kHz written to it
+type Analyzer struct {
+	filters [cdda.Channels]kWeighting
+	sums    [4]float64 // Weighted energy of the last 4 sub-blocks, oldest first
+	n       int        // Sample frames in the current sub-block
+	subs    int        // Sub-blocks completed
+	result  Result
+	partial []byte // Bytes of an incomplete sample frame
+}
+
+// NewAnalyzer returns an analyzer for CD audio
+func NewAnalyzer() *Analyzer {
+	a := &Analyzer{}
+	for c := range a.filters {
+		a.filters[c] = newKWeighting(cdda.SampleRate)
+	}
+	return a
+}
+
+// Write analyzes little-endian 16-bit stereo samples. Sample frames may
+// be split across writes.
+func (a *Analyzer) Write(p []byte) (int, error) {
+	const frameSize = cdda.Channels * cdda.BitsPerSample / 8
+	n := len(p)
+
+	if len(a.partial) > 0 {
+		need := frameSize - len(a.partial)
+		if len(p) < need {
+			a.partial = append(a.partial, p...)
+			return n, nil
+		}
+		a.frame(append(a.partial, p[:need]...))
+		a.partial, p = a.partial[:0], p[need:]
+	}
+
+	for ; len(p) >= frameSize; p = p[frameSize:] {
+		a.frame(p)
+	}
+	a.partial = append(a.partial, p...)
+	return n, nil
+}
+
+// frame analyzes one sample frame
+func (a *Analyzer) frame(p []byte) {
+	var energy float64
+	for c := range a.filters {
+		s := float64(int16(binary.LittleEndian.Uint16(p[2*c:]))) / 32768
+		a.result.Peak = max(a.result.Peak, math.Abs(s))
+		y := a.filters[c].process(s)
+		energy += y * y // Left and right are weighted 1.0
+	}
+
+	a.sums[3] += energy
+	if a.n++; a.n < subBlock {
+		return
+	}
+
+	a.n = 0
+	if a.subs++; a.subs >= 4 {
+		a.result.blocks = append(a.result.blocks, (a.sums[0]+a.sums[1]+a.sums[2]+a.sums[3])/(4*subBlock))
+	}
+	a.sums = [4]float64{a.sums[1], a.sums[2], a.sums[3], 0}
+}
+
+// Result returns the loudness of everything written so far. A trailing
+// partial gating block is not counted.
+func (a *Analyzer) Result() Result {
+	return a.result
+}
+
+// AnalyzeWAV measures a CD audio WAV file.
+// This is boundary code - reads the file.
+func AnalyzeWAV(path string) (Result, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return Result{}, err
+	}
+	defer f.Close()
+
+	header := make([]byte, wavHeaderScan)
+	n, err := io.ReadFull(f, header)
+	if err != nil && !errors.Is(err, io.ErrUnexpectedEOF) {
+		return Result{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	offset, size, err := cdda.ParseWAVHeader(header[:n])
+	if err != nil {
+		return Result{}, fmt.Errorf("%s: %w", path, err)
+	}
+
+	a := NewAnalyzer()
+	if _, err := io.Copy(a, io.NewSectionReader(f, int64(offset), int64(size))); err != nil {
+		return Result{}, fmt.Errorf("read %s: %w", path, err)
+	}
+	return a.Result(), nil
+}
+
+// kWeighting is the BS.1770 K-weighting filter for one channel: a high
+// shelf modelling the head, then a high-pass (the "RLB" curve)
+type kWeighting struct {
+	shelf, highPass biquad
+}
+
+// newKWeighting designs the filter for a sample rate, as libebur128 does,
+// so rates other than the 48kHz of the standard's coefficient tables work
+func newKWeighting(rate float64) kWeighting {
+	// High shelf: +4dB above ~1.7kHz
+	f0, gain, q := 1681.974450955533, 3.999843853973347, 0.7071752369554196
+	k := math.Tan(math.Pi * f0 / rate)
+	vh := math.Pow(10, gain/20)
+	vb := math.Pow(vh, 0.4996667741545416)
+	a0 := 1 + k/q + k*k
+	shelf := biquad{
+		b0: (vh + vb*k/q + k*k) / a0,
+		b1: 2 * (k*k - vh) / a0,
+		b2: (vh - vb*k/q + k*k) / a0,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	// High-pass at ~38Hz
+	f0, q = 38.13547087602444, 0.5003270373238773
+	k = math.Tan(math.Pi * f0 / rate)
+	a0 = 1 + k/q + k*k
+	highPass := biquad{
+		b0: 1, b1: -2, b2: 1,
+		a1: 2 * (k*k - 1) / a0,
+		a2: (1 - k/q + k*k) / a0,
+	}
+
+	return kWeighting{shelf, highPass}
+}
+
+func (k *kWeighting) process(x float64) float64 {
+	return k.highPass.process(k.shelf.process(x))
+}
+
+// biquad is a second-order IIR filter (direct form II transposed)
+type biquad struct {
+	b0, b1, b2, a1, a2 float64
+	z1, z2             float64
+}
+
+func (f *biquad) process(x float64) float64 {
+	y := f.b0*x + f.z1
+	f.z1 = f.b1*x - f.a1*y + f.z2
+	f.z2 = f.b2*x - f.a2*y
+	return y
+}
+
+// gate returns the blocks louder than threshold (a mean square)
+func gate(blocks []float64, threshold float64) []float64 {
+	var kept []float64
+	for _, b := range blocks {
+		if b > threshold {
+			kept = append(kept, b)
+		}
+	}
+	return kept
+}
+
+func mean(xs []float64) float64 {
+	var sum float64
+	for _, x := range xs {
+		sum += x
+	}
+	return sum / float64(len(xs))
+}
+
+// lufs converts a mean square to loudness; power is the inverse
+func lufs(ms float64) float64 { return -0.691 + 10*math.Log10(ms) }
+func power(l float64) float64 { return math.Pow(10, (l+0.691)/10) }
@@ -0,0 +1,146 @@
+package loudness
+
+import (
+	"encoding/binary"
+	"math"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// sine returns seconds of a 1kHz stereo sine at level dBFS (peak) as
+// 16-bit PCM
+func sine(level, seconds float64) []byte {
+	amp := math.Pow(10, level/20) * 32767
+	n := int(seconds * cdda.SampleRate)
+	pcm := make([]byte, 4*n)
+	for i := range n {
+		s := uint16(int16(math.Round(amp * math.Sin(2*math.Pi*1000*float64(i)/cdda.SampleRate))))
+		binary.LittleEndian.PutUint16(pcm[4*i:], s)
+		binary.LittleEndian.PutUint16(pcm[4*i+2:], s)
+	}
+	return pcm
+}
+
+func analyze(pcm []byte) Result {
+	a := NewAnalyzer()
+	a.Write(pcm)
+	return a.Result()
+}
+
+func TestLoudness_Sine(t *testing.T) {
+	// EBU Tech 3341 case 1: a 1kHz sine at -23 dBFS in both channels
+	// measures -23 LUFS
+	tests := []struct {
+		name  string
+		level float64
+	}{
+		{"reference", -23},
+		{"loud", -10},
+		{"quiet", -40},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := analyze(sine(tt.level, 5))
+			if got := r.Loudness(); math.Abs(got-tt.level) > 0.1 {
+				t.Errorf("Loudness() = %.2f LUFS, want %.1f", got, tt.level)
+			}
+			if got := r.ReplayGain(); math.Abs(got-(ReplayGainReference-tt.level)) > 0.1 {
+				t.Errorf("ReplayGain() = %.2f dB, want %.1f", got, ReplayGainReference-tt.level)
+			}
+			if want := math.Pow(10, tt.level/20); math.Abs(r.Peak-want) > 0.001 {
+				t.Errorf("Peak = %f, want %f", r.Peak, want)
+			}
+		})
+	}
+}
+
+func TestLoudness_Silence(t *testing.T) {
+	r := analyze(make([]byte, 4*cdda.SampleRate))
+	if got := r.Loudness(); !math.IsInf(got, -1) {
+		t.Errorf("Loudness() = %f, want -Inf", got)
+	}
+	if got := r.ReplayGain(); got != 0 {
+		t.Errorf("ReplayGain() = %f, want 0", got)
+	}
+	if r.Peak != 0 {
+		t.Errorf("Peak = %f, want 0", r.Peak)
+	}
+}
+
+func TestLoudness_Gating(t *testing.T) {
+	// EBU Tech 3341 case 3: -36, -23, -36 dBFS for 10s, 60s, 10s measure
+	// -23 LUFS, as the relative gate drops the quiet parts
+	pcm := append(sine(-36, 10), sine(-23, 60)...)
+	pcm = append(pcm, sine(-36, 10)...)
+	if got := analyze(pcm).Loudness(); math.Abs(got+23) > 0.1 {
+		t.Errorf("Loudness() = %.2f LUFS, want -23", got)
+	}
+}
+
+func TestLoudness_RelativeGateEdge(t *testing.T) {
+	// Equal lengths at -20 and -32.3 dBFS: the quiet half is about 9.5 LU
+	// below the ungated loudness, so it is inside the gate and counts
+	pcm := append(sine(-20, 10), sine(-32.3, 10)...)
+	want := 10 * math.Log10((math.Pow(10, -2)+math.Pow(10, -3.23))/2)
+	if got := analyze(pcm).Loudness(); math.Abs(got-want) > 0.1 {
+		t.Errorf("Loudness() = %.2f LUFS, want %.2f", got, want)
+	}
+}
+
+func TestAnalyzer_SplitWrites(t *testing.T) {
+	pcm := sine(-20, 2)
+	a := NewAnalyzer()
+	for chunk := pcm; len(chunk) > 0; {
+		n := min(len(chunk), 4093) // Splits sample frames
+		a.Write(chunk[:n])
+		chunk = chunk[n:]
+	}
+	got, want := a.Result(), analyze(pcm)
+	if got.Loudness() != want.Loudness() || got.Peak != want.Peak {
+		t.Errorf("split writes = %f LUFS peak %f, want %f LUFS peak %f", got.Loudness(), got.Peak, want.Loudness(), want.Peak)
+	}
+}
+
+func TestAlbum(t *testing.T) {
+	quiet := analyze(sine(-30, 10))
+	loud := analyze(sine(-20, 10))
+	album := Album([]Result{quiet, loud})
+
+	// Both tracks are within 10 LU, so the album is the energy mean
+	want := 10 * math.Log10((math.Pow(10, -3)+math.Pow(10, -2))/2)
+	if got := album.Loudness(); math.Abs(got-want) > 0.1 {
+		t.Errorf("Album loudness = %.2f LUFS, want %.2f", got, want)
+	}
+	if album.Peak != loud.Peak {
+		t.Errorf("Album peak = %f, want %f", album.Peak, loud.Peak)
+	}
+}
+
+func TestAnalyzeWAV(t *testing.T) {
+	pcm := sine(-23, 3)
+	path := filepath.Join(t.TempDir(), "track01.wav")
+	if err := os.WriteFile(path, cdda.WriteWAV(pcm), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	r, err := AnalyzeWAV(path)
+	if err != nil {
+		t.Fatalf("AnalyzeWAV error: %v", err)
+	}
+	if got := r.Loudness(); math.Abs(got+23) > 0.1 {
+		t.Errorf("Loudness() = %.2f LUFS, want -23", got)
+	}
+}
+
+func TestAnalyzeWAV_NotWAV(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "track01.wav")
+	if err := os.WriteFile(path, []byte("not a wav file"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := AnalyzeWAV(path); err == nil {
+		t.Error("AnalyzeWAV succeeded on a non-WAV file")
+	}
+}
@@ -0,0 +1,57 @@
+package metadata
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+)
+
+// cdTextFile mirrors the cdtext.json that cd-rip writes from the disc's CD-TEXT
+type cdTextFile struct {
+	Blocks []struct {
+		Language int           `json:"language"`
+		Genre    string        `json:"genre"`
+		Album    cdTextEntry   `json:"album"`
+		Tracks   []cdTextEntry `json:"tracks"`
+	} `json:"blocks"`
+}
+
+// cdTextEntry is the disc's or one track's CD-TEXT in cdtext.json
+type cdTextEntry struct {
+	Num       int    `json:"num"`
+	Title     string `json:"title"`
+	Performer string `json:"performer"`
+}
+
+// ParseCDText reads a cdtext.json file and converts its first (default
+// language) block to an Album. Tracks without a performer take the disc's.
+func ParseCDText(path string) (*Album, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("read CD-TEXT: %w", err)
+	}
+	var f cdTextFile
+	if err := json.Unmarshal(data, &f); err != nil {
+		return nil, fmt.Errorf("parse CD-TEXT: %w", err)
+	}
+	if len(f.Blocks) == 0 {
+		return nil, errors.New("parse CD-TEXT: no blocks")
+	}
+
+	b := f.Blocks[0]
+	album := &Album{
+		Artist:      b.Album.Performer,
+		AlbumTitle:  b.Album.Title,
+		Genre:       b.Genre,
+		TotalTracks: len(b.Tracks),
+	}
+	for _, t := range b.Tracks {
+		artist := t.Performer
+		if artist == "" {
+			artist = b.Album.Performer
+		}
+		album.Tracks = append(album.Tracks, Track{Num: t.Num, Title: t.Title, Artist: artist})
+	}
+	return album, nil
+}
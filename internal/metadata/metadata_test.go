@@ -323,3 +323,31 @@ func BenchmarkValidate(b *testing.B) {
 		_ = album.Validate(4)
 	}
 }
+
+func TestParseCDText(t *testing.T) {
+	album, err := ParseCDText("testdata/cdtext.json")
+	if err != nil {
+		t.Fatalf("ParseCDText error: %v", err)
+	}
+	if album.Artist != "The Trio" || album.AlbumTitle != "Live at the Blue Note" {
+		t.Errorf("album = %q - %q, want the first block", album.Artist, album.AlbumTitle)
+	}
+	if album.Genre != "Jazz" {
+		t.Errorf("Genre = %q, want Jazz", album.Genre)
+	}
+	if len(album.Tracks) != 2 {
+		t.Fatalf("len(Tracks) = %d, want 2", len(album.Tracks))
+	}
+	if album.Tracks[0].Artist != "The Trio" {
+		t.Errorf("Tracks[0].Artist = %q, want the disc performer", album.Tracks[0].Artist)
+	}
+	if album.Tracks[1].Artist != "Guest Vocalist" {
+		t.Errorf("Tracks[1].Artist = %q, want %q", album.Tracks[1].Artist, "Guest Vocalist")
+	}
+}
+
+func TestParseCDText_NotFound(t *testing.T) {
+	if _, err := ParseCDText("testdata/nonexistent.json"); err == nil {
+		t.Error("expected error for missing file")
+	}
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"sort"
+	"strings"
 	"time"
 
 	"go.uploadedlobster.com/mbtypes"
@@ -12,22 +13,29 @@ import (
 
 // Release contains metadata for an album/release
 type Release struct {
-	MBID        string  // MusicBrainz ID
-	Title       string  // Album title
-	Artist      string  // Artist name (may be "Various Artists" for compilations)
-	Year        int     // Release year
-	Country     string  // Release country code
-	TrackCount  int     // Number of tracks
-	DiscCount   int     // Number of discs
-	Tracks      []Track // Track list
-	Compilation bool    // True if Various Artists
+	MBID             string   // MusicBrainz ID
+	ReleaseGroupMBID string   // The release group (album across editions)
+	Title            string   // Album title
+	Artist           string   // Artist name (may be "Various Artists" for compilations)
+	ArtistMBIDs      []string // One per credited artist
+	DiscID           string   // Disc ID the release was found by ("" if searched)
+	Year             int      // Release year
+	Country          string   // Release country code
+	Barcode          string   // UPC/EAN ("" if unknown)
+	TrackCount       int      // Number of tracks
+	DiscCount        int      // Number of discs
+	Tracks           []Track  // Track list
+	Compilation      bool     // True if Various Artists
 }
 
 // Track contains metadata for a single track
 type Track struct {
-	Num    int
-	Title  string
-	Artist string // May differ from album artist on compilations
+	Num           int
+	Title         string
+	Artist        string   // May differ from album artist on compilations
+	ArtistMBIDs   []string // One per credited artist
+	MBID          string   // The track on this release
+	RecordingMBID string   // The recording, shared by every release it is on
 }
 
 // Client wraps the MusicBrainz API
@@ -70,16 +78,8 @@ func (c *Client) LookupByDiscID(discID string) ([]Release, error) {
 
 	var releases []Release
 	for _, r := range disc.Releases {
-		release := Release{
-			MBID:        string(r.ID),
-			Title:       r.Title,
-			Artist:      getArtistName(r.ArtistCredit),
-			Year:        r.Date.Year,
-			Country:     string(r.CountryCode),
-			TrackCount:  getTotalTracks(r.Media),
-			DiscCount:   len(r.Media),
-			Compilation: isCompilation(r.ArtistCredit),
-		}
+		release := newRelease(r)
+		release.DiscID = discID
 		releases = append(releases, release)
 	}
 
@@ -94,7 +94,7 @@ func (c *Client) GetReleaseTracks(mbid string) (*Release, error) {
 
 	ctx := context.Background()
 	filter := musicbrainzws2.IncludesFilter{
-		Includes: []string{"recordings", "artists", "artist-credits"},
+		Includes: []string{"recordings", "artists", "artist-credits", "release-groups"},
 	}
 
 	r, err := c.client.LookupRelease(ctx, mbtypes.MBID(mbid), filter)
@@ -102,24 +102,19 @@ func (c *Client) GetReleaseTracks(mbid string) (*Release, error) {
 		return nil, fmt.Errorf("release lookup: %w", err)
 	}
 
-	release := Release{
-		MBID:        string(r.ID),
-		Title:       r.Title,
-		Artist:      getArtistName(r.ArtistCredit),
-		Year:        r.Date.Year,
-		Country:     string(r.CountryCode),
-		TrackCount:  getTotalTracks(r.Media),
-		DiscCount:   len(r.Media),
-		Compilation: isCompilation(r.ArtistCredit),
-	}
+	release := newRelease(r)
 
 	// Extract tracks from all media
 	for _, medium := range r.Media {
 		for _, track := range medium.Tracks {
+			credit := getTrackCredit(track, r.ArtistCredit)
 			t := Track{
-				Num:    track.Position,
-				Title:  track.Title,
-				Artist: getTrackArtist(track, r.ArtistCredit),
+				Num:           track.Position,
+				Title:         track.Title,
+				Artist:        getArtistName(credit),
+				ArtistMBIDs:   getArtistIDs(credit),
+				MBID:          string(track.ID),
+				RecordingMBID: string(track.Recording.ID),
 			}
 			release.Tracks = append(release.Tracks, t)
 		}
@@ -128,6 +123,26 @@ func (c *Client) GetReleaseTracks(mbid string) (*Release, error) {
 	return &release, nil
 }
 
+// newRelease converts an API release, without its tracks
+func newRelease(r musicbrainzws2.Release) Release {
+	release := Release{
+		MBID:        string(r.ID),
+		Title:       r.Title,
+		Artist:      getArtistName(r.ArtistCredit),
+		ArtistMBIDs: getArtistIDs(r.ArtistCredit),
+		Year:        r.Date.Year,
+		Country:     string(r.CountryCode),
+		Barcode:     r.Barcode,
+		TrackCount:  getTotalTracks(r.Media),
+		DiscCount:   len(r.Media),
+		Compilation: isCompilation(r.ArtistCredit),
+	}
+	if r.ReleaseGroup != nil {
+		release.ReleaseGroupMBID = string(r.ReleaseGroup.ID)
+	}
+	return release
+}
+
 func getArtistName(credit musicbrainzws2.ArtistCredit) string {
 	if len(credit) == 0 {
 		return "Unknown Artist"
@@ -135,17 +150,28 @@ func getArtistName(credit musicbrainzws2.ArtistCredit) string {
 	return credit.String()
 }
 
-func getTrackArtist(track musicbrainzws2.Track, albumCredit musicbrainzws2.ArtistCredit) string {
+// getArtistIDs returns the MBIDs of the credited artists
+func getArtistIDs(credit musicbrainzws2.ArtistCredit) []string {
+	var ids []string
+	for _, c := range credit {
+		if c.Artist.ID != "" {
+			ids = append(ids, string(c.Artist.ID))
+		}
+	}
+	return ids
+}
+
+func getTrackCredit(track musicbrainzws2.Track, albumCredit musicbrainzws2.ArtistCredit) musicbrainzws2.ArtistCredit {
 	// Use track's artist credit if present
 	if len(track.ArtistCredit) > 0 {
-		return track.ArtistCredit.String()
+		return track.ArtistCredit
 	}
 	// Use recording's artist credit if different from album
 	if len(track.Recording.ArtistCredit) > 0 {
-		return track.Recording.ArtistCredit.String()
+		return track.Recording.ArtistCredit
 	}
 	// Fall back to album artist
-	return getArtistName(albumCredit)
+	return albumCredit
 }
 
 func isCompilation(credit musicbrainzws2.ArtistCredit) bool {
@@ -164,14 +190,21 @@ func getTotalTracks(media []musicbrainzws2.Medium) int {
 	return total
 }
 
-// SortReleasesByTrackMatch sorts releases so those matching targetTrackCount come first,
-// then by year (newest first). Returns a new sorted slice (does not modify input).
-func SortReleasesByTrackMatch(releases []Release, targetTrackCount int) []Release {
+// SortReleasesByTrackMatch sorts releases so those whose barcode matches the
+// disc's media catalog number come first, then those matching
+// targetTrackCount, then by year (newest first). mcn may be empty.
+// Returns a new sorted slice (does not modify input).
+func SortReleasesByTrackMatch(releases []Release, targetTrackCount int, mcn string) []Release {
 	// Copy to avoid modifying input
 	sorted := make([]Release, len(releases))
 	copy(sorted, releases)
 
 	sort.SliceStable(sorted, func(i, j int) bool {
+		iBarcode := BarcodeMatches(sorted[i].Barcode, mcn)
+		jBarcode := BarcodeMatches(sorted[j].Barcode, mcn)
+		if iBarcode != jBarcode {
+			return iBarcode // Same pressing first
+		}
 		iMatch := sorted[i].TrackCount == targetTrackCount
 		jMatch := sorted[j].TrackCount == targetTrackCount
 		if iMatch != jMatch {
@@ -183,6 +216,16 @@ func SortReleasesByTrackMatch(releases []Release, targetTrackCount int) []Releas
 	return sorted
 }
 
+// BarcodeMatches reports whether a release barcode and a disc's media
+// catalog number identify the same product. The MCN is a 13-digit EAN, so a
+// 12-digit UPC-A barcode appears with a leading zero; leading zeros are
+// ignored. An empty barcode or MCN never matches.
+func BarcodeMatches(barcode, mcn string) bool {
+	b := strings.TrimLeft(barcode, "0")
+	m := strings.TrimLeft(mcn, "0")
+	return b != "" && b == m
+}
+
 // Search searches for releases by text query (artist, album, etc).
 func (c *Client) Search(query string) ([]Release, error) {
 	// Rate limit
@@ -202,17 +245,7 @@ func (c *Client) Search(query string) ([]Release, error) {
 
 	var releases []Release
 	for _, r := range result.Releases {
-		release := Release{
-			MBID:        string(r.ID),
-			Title:       r.Title,
-			Artist:      getArtistName(r.ArtistCredit),
-			Year:        r.Date.Year,
-			Country:     string(r.CountryCode),
-			TrackCount:  getTotalTracks(r.Media),
-			DiscCount:   len(r.Media),
-			Compilation: isCompilation(r.ArtistCredit),
-		}
-		releases = append(releases, release)
+		releases = append(releases, newRelease(r))
 	}
 
 	return releases, nil
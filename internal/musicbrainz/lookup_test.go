@@ -126,6 +126,51 @@ func TestGetTotalTracks_Single(t *testing.T) {
 	}
 }
 
+func TestGetArtistIDs(t *testing.T) {
+	credit := musicbrainzws2.ArtistCredit{
+		{Name: "Queen", JoinPhrase: " & ", Artist: musicbrainzws2.Artist{ID: "0383dadf-2a4e-4d10-a46a-e9e041da8eb3"}},
+		{Name: "David Bowie", Artist: musicbrainzws2.Artist{ID: "5441c29d-3602-4898-b1a1-b77fa23b8e50"}},
+		{Name: "Uncredited"},
+	}
+
+	got := getArtistIDs(credit)
+	if len(got) != 2 || got[0] != "0383dadf-2a4e-4d10-a46a-e9e041da8eb3" || got[1] != "5441c29d-3602-4898-b1a1-b77fa23b8e50" {
+		t.Errorf("getArtistIDs() = %q, want both artists' MBIDs", got)
+	}
+}
+
+func TestGetTrackCredit(t *testing.T) {
+	album := musicbrainzws2.ArtistCredit{{Name: "Various Artists"}}
+	recording := musicbrainzws2.ArtistCredit{{Name: "A-ha"}}
+
+	track := musicbrainzws2.Track{Recording: musicbrainzws2.Recording{ArtistCredit: recording}}
+	if got := getTrackCredit(track, album); len(got) != 1 || got[0].Name != "A-ha" {
+		t.Errorf("getTrackCredit() = %v, want the recording's credit", got)
+	}
+	if got := getTrackCredit(musicbrainzws2.Track{}, album); len(got) != 1 || got[0].Name != "Various Artists" {
+		t.Errorf("getTrackCredit() = %v, want the album's credit", got)
+	}
+}
+
+func TestNewRelease_IDs(t *testing.T) {
+	r := newRelease(musicbrainzws2.Release{
+		ID:           "b84ee12a-09ef-421b-82de-0441a926375b",
+		ReleaseGroup: &musicbrainzws2.ReleaseGroup{ID: "9162580e-5df4-32de-80cc-f45a8d8a9b1d"},
+		ArtistCredit: musicbrainzws2.ArtistCredit{{Name: "The Beatles", Artist: musicbrainzws2.Artist{ID: "b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d"}}},
+	})
+
+	if r.MBID != "b84ee12a-09ef-421b-82de-0441a926375b" || r.ReleaseGroupMBID != "9162580e-5df4-32de-80cc-f45a8d8a9b1d" {
+		t.Errorf("MBID/ReleaseGroupMBID = %q/%q", r.MBID, r.ReleaseGroupMBID)
+	}
+	if len(r.ArtistMBIDs) != 1 || r.ArtistMBIDs[0] != "b10bbbfc-cf9e-42e0-be17-e2c3e1d2600d" {
+		t.Errorf("ArtistMBIDs = %q", r.ArtistMBIDs)
+	}
+
+	if r := newRelease(musicbrainzws2.Release{}); r.ReleaseGroupMBID != "" {
+		t.Errorf("ReleaseGroupMBID = %q without a release group, want empty", r.ReleaseGroupMBID)
+	}
+}
+
 func TestSortReleasesByTrackMatch(t *testing.T) {
 	releases := []Release{
 		{Title: "Box Set", TrackCount: 38, Year: 2017},
@@ -136,7 +181,7 @@ func TestSortReleasesByTrackMatch(t *testing.T) {
 	}
 
 	// Sort for 12-track target
-	sorted := SortReleasesByTrackMatch(releases, 12)
+	sorted := SortReleasesByTrackMatch(releases, 12, "")
 
 	// First 3 should be 12-track releases
 	for i := 0; i < 3; i++ {
@@ -163,7 +208,7 @@ func TestSortReleasesByTrackMatch_NoMatches(t *testing.T) {
 	}
 
 	// Sort for 12-track target (no matches)
-	sorted := SortReleasesByTrackMatch(releases, 12)
+	sorted := SortReleasesByTrackMatch(releases, 12, "")
 
 	// Should sort by year (newest first) when no matches
 	if sorted[0].Year != 2020 {
@@ -171,6 +216,43 @@ func TestSortReleasesByTrackMatch_NoMatches(t *testing.T) {
 	}
 }
 
+func TestSortReleasesByTrackMatch_Barcode(t *testing.T) {
+	releases := []Release{
+		{Title: "Remaster", TrackCount: 12, Year: 2011, Barcode: "602527648547"},
+		{Title: "Original", TrackCount: 12, Year: 1992, Barcode: "720642442524"},
+		{Title: "Box Set", TrackCount: 38, Year: 2017},
+	}
+
+	// UPC-A barcode matches the 13-digit MCN with a leading zero
+	sorted := SortReleasesByTrackMatch(releases, 12, "0720642442524")
+
+	if sorted[0].Title != "Original" {
+		t.Errorf("sorted[0] = %q, want the release whose barcode matches the MCN", sorted[0].Title)
+	}
+	if sorted[1].Title != "Remaster" {
+		t.Errorf("sorted[1] = %q, want Remaster (track count match)", sorted[1].Title)
+	}
+}
+
+func TestBarcodeMatches(t *testing.T) {
+	tests := []struct {
+		barcode, mcn string
+		want         bool
+	}{
+		{"0720642442524", "0720642442524", true},
+		{"720642442524", "0720642442524", true},
+		{"720642442525", "0720642442524", false},
+		{"", "0720642442524", false},
+		{"720642442524", "", false},
+		{"", "", false},
+	}
+	for _, tt := range tests {
+		if got := BarcodeMatches(tt.barcode, tt.mcn); got != tt.want {
+			t.Errorf("BarcodeMatches(%q, %q) = %v, want %v", tt.barcode, tt.mcn, got, tt.want)
+		}
+	}
+}
+
 func TestRelease_Struct(t *testing.T) {
 	// Verify Release struct can hold expected data
 	r := Release{
@@ -0,0 +1,95 @@
+package rip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// ReadCDText reads and parses the disc's CD-TEXT.
+// Returns nil if the drive or disc has none.
+func ReadCDText(dev scsi.Drive) []cdda.CDTextBlock {
+	raw, err := dev.ReadCDText()
+	if errors.Is(err, scsi.ErrIllegalRequest) {
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("Warning: can't read CD-TEXT (%v)\n", err)
+		return nil
+	}
+
+	blocks, err := cdda.ParseCDText(raw)
+	if errors.Is(err, cdda.ErrNoCDText) {
+		return nil
+	}
+	if err != nil {
+		fmt.Printf("Warning: can't parse CD-TEXT (%v)\n", err)
+		return nil
+	}
+	return blocks
+}
+
+// CDTextJSON encodes CD-TEXT blocks as cdtext.json (see docs/metadata-format.md)
+func CDTextJSON(blocks []cdda.CDTextBlock) []byte {
+	type jsonEntry struct {
+		Num        int    `json:"num,omitempty"`
+		Title      string `json:"title,omitempty"`
+		Performer  string `json:"performer,omitempty"`
+		Songwriter string `json:"songwriter,omitempty"`
+		Composer   string `json:"composer,omitempty"`
+		Arranger   string `json:"arranger,omitempty"`
+		Message    string `json:"message,omitempty"`
+		Code       string `json:"code,omitempty"` // UPC/EAN or ISRC
+	}
+
+	type jsonBlock struct {
+		Block    int         `json:"block"`
+		Language int         `json:"language"` // EBU Tech 3258 code (9 = English)
+		Genre    string      `json:"genre,omitempty"`
+		Album    jsonEntry   `json:"album"`
+		Tracks   []jsonEntry `json:"tracks"`
+	}
+
+	entry := func(num int, e cdda.CDTextEntry) jsonEntry {
+		return jsonEntry{
+			Num:        num,
+			Title:      e.Title,
+			Performer:  e.Performer,
+			Songwriter: e.Songwriter,
+			Composer:   e.Composer,
+			Arranger:   e.Arranger,
+			Message:    e.Message,
+			Code:       e.Code,
+		}
+	}
+
+	out := struct {
+		Blocks []jsonBlock `json:"blocks"`
+	}{}
+	for _, b := range blocks {
+		nums := make([]int, 0, len(b.Tracks))
+		for n := range b.Tracks {
+			nums = append(nums, n)
+		}
+		sort.Ints(nums)
+
+		jb := jsonBlock{
+			Block:    b.Number,
+			Language: int(b.Language),
+			Genre:    b.Genre,
+			Album:    entry(0, b.Album),
+			Tracks:   make([]jsonEntry, 0, len(nums)),
+		}
+		for _, n := range nums {
+			jb.Tracks = append(jb.Tracks, entry(n, b.Tracks[n]))
+		}
+		out.Blocks = append(out.Blocks, jb)
+	}
+
+	data, _ := json.MarshalIndent(out, "", "  ")
+	return data
+}
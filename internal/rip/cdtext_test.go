@@ -0,0 +1,73 @@
+package rip
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// buildCDText encodes null-separated titles for the disc and its tracks as a
+// READ TOC format 5 response
+func buildCDText(titles string) []byte {
+	payload := []byte(titles)
+	for len(payload)%12 != 0 {
+		payload = append(payload, 0)
+	}
+
+	data := make([]byte, 4)
+	for i := 0; i < len(payload); i += 12 {
+		done := payload[:i]
+		pos := len(done) - (bytes.LastIndexByte(done, 0) + 1)
+
+		pack := make([]byte, cdda.CDTextPackSize)
+		pack[0] = 0x80                               // Title
+		pack[1] = byte(bytes.Count(done, []byte{0})) // Track of the first character
+		pack[2] = byte(i / 12)
+		pack[3] = byte(min(pos, 15)) // Its position in the track's string
+		copy(pack[4:16], payload[i:i+12])
+		binary.BigEndian.PutUint16(pack[16:18], cdda.CDTextCRC(pack[:16]))
+		data = append(data, pack...)
+	}
+	binary.BigEndian.PutUint16(data[0:2], uint16(len(data)-2))
+	return data
+}
+
+func TestReadCDText_SimDrive(t *testing.T) {
+	dev, _, _ := simDisc(t)
+	dev.SetCDText(buildCDText("Album\x00One\x00Two\x00Three\x00"))
+
+	blocks := ReadCDText(dev)
+	if len(blocks) != 1 {
+		t.Fatalf("got %d blocks, want 1", len(blocks))
+	}
+
+	var got struct {
+		Blocks []struct {
+			Album  struct{ Title string }
+			Tracks []struct {
+				Num   int
+				Title string
+			}
+		}
+	}
+	if err := json.Unmarshal(CDTextJSON(blocks), &got); err != nil {
+		t.Fatalf("cdtext.json does not parse: %v", err)
+	}
+	if got.Blocks[0].Album.Title != "Album" {
+		t.Errorf("album title = %q, want Album", got.Blocks[0].Album.Title)
+	}
+	if len(got.Blocks[0].Tracks) != 3 || got.Blocks[0].Tracks[2].Num != 3 || got.Blocks[0].Tracks[2].Title != "Three" {
+		t.Errorf("tracks = %+v, want One/Two/Three in order", got.Blocks[0].Tracks)
+	}
+}
+
+func TestReadCDText_None(t *testing.T) {
+	dev, _, _ := simDisc(t)
+
+	if blocks := ReadCDText(dev); blocks != nil {
+		t.Errorf("ReadCDText = %+v, want nil for a disc without CD-TEXT", blocks)
+	}
+}
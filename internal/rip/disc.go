@@ -0,0 +1,68 @@
+package rip
+
+import (
+	"fmt"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// ReadSessions numbers each track's session from the full TOC, or from the
+// session info if the drive doesn't support the full TOC. Drives that
+// support neither leave the TOC as it was.
+func ReadSessions(dev scsi.Drive, toc cdda.TOC) cdda.TOC {
+	if raw, err := dev.ReadFullTOC(); err == nil {
+		if full, err := cdda.ParseFullTOC(raw); err == nil {
+			return cdda.CopySessions(toc, full)
+		}
+	}
+
+	raw, err := dev.ReadSessionInfo()
+	if err != nil {
+		fmt.Printf("Warning: can't read sessions (%v)\n", err)
+		return toc
+	}
+	info, err := cdda.ParseSessionInfo(raw)
+	if err != nil {
+		fmt.Printf("Warning: can't read sessions (%v)\n", err)
+		return toc
+	}
+	return cdda.SetSessions(toc, info)
+}
+
+// ReadCodes reads the media catalog number and track ISRCs.
+// Drives that don't support READ SUB-CHANNEL leave the TOC as it was.
+func ReadCodes(dev scsi.Drive, toc cdda.TOC) cdda.TOC {
+	readMCN := func() ([]byte, error) {
+		return dev.ReadSubChannel(scsi.SubChannelMCN, 0)
+	}
+	readISRC := func(track int) ([]byte, error) {
+		return dev.ReadSubChannel(scsi.SubChannelISRC, track)
+	}
+
+	coded, err := cdda.ReadCodes(toc, readMCN, readISRC)
+	if err != nil {
+		fmt.Printf("Warning: can't read catalog number or ISRCs (%v)\n", err)
+		return toc
+	}
+	return coded
+}
+
+// ScanIndexes finds pregaps and indexes from the Q subchannel.
+// Drives that can't read the subchannel leave the TOC as it was.
+func ScanIndexes(dev scsi.Drive, toc cdda.TOC) cdda.TOC {
+	readQ := func(lba int) (cdda.SubQ, error) {
+		data, err := dev.ReadSubQ(lba, 1)
+		if err != nil {
+			return cdda.SubQ{}, err
+		}
+		return cdda.ParseSubQ(data), nil
+	}
+
+	scanned, err := cdda.ScanIndexes(toc, readQ)
+	if err != nil {
+		fmt.Printf("Warning: can't read pregaps (%v)\n", err)
+		return toc
+	}
+	return scanned
+}
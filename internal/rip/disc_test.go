@@ -0,0 +1,76 @@
+package rip
+
+import (
+	"encoding/binary"
+	"testing"
+)
+
+func TestScanIndexes_SimDrive(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+	dev.SetIndex(180, 2, 0)
+	dev.SetIndex(250, 2, 2)
+
+	toc = ScanIndexes(dev, toc)
+
+	if toc.Tracks[1].Pregap != 20 {
+		t.Errorf("track 2 pregap = %d, want 20", toc.Tracks[1].Pregap)
+	}
+	if len(toc.Tracks[1].Indexes) != 1 || toc.Tracks[1].Indexes[0] != 250 {
+		t.Errorf("track 2 indexes = %v, want [250]", toc.Tracks[1].Indexes)
+	}
+}
+
+func TestReadCodes_SimDrive(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+	dev.SetMCN("0724384260729")
+	dev.SetISRC(2, "USEE10001993")
+
+	toc = ReadCodes(dev, toc)
+
+	if toc.MCN != "0724384260729" {
+		t.Errorf("MCN = %q, want 0724384260729", toc.MCN)
+	}
+	if toc.Tracks[0].ISRC != "" || toc.Tracks[1].ISRC != "USEE10001993" {
+		t.Errorf("ISRCs = %q, %q, want only track 2", toc.Tracks[0].ISRC, toc.Tracks[1].ISRC)
+	}
+}
+
+func TestReadSessions_SimDrive(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+
+	// Without a full TOC the sim reports one session starting at track 1
+	single := ReadSessions(dev, toc)
+	for _, tr := range single.Tracks {
+		if tr.Session != 1 {
+			t.Errorf("track %d session = %d, want 1", tr.Num, tr.Session)
+		}
+	}
+
+	// Track 3 in a second session
+	msf := func(lba int) (byte, byte, byte) {
+		f := lba + 150
+		return byte(f / 75 / 60), byte(f / 75 % 60), byte(f % 75)
+	}
+	full := []byte{0, 0, 1, 2}
+	for _, e := range []struct {
+		session, point, lba int
+	}{{1, 1, 150}, {1, 2, 200}, {2, 0xA0, -1}, {2, 3, 310}} {
+		d := make([]byte, 11)
+		d[0], d[1], d[3] = byte(e.session), 0x10, byte(e.point)
+		if e.point == 0xA0 {
+			d[8] = 3
+		} else {
+			d[8], d[9], d[10] = msf(e.lba)
+		}
+		full = append(full, d...)
+	}
+	binary.BigEndian.PutUint16(full[0:2], uint16(len(full)-2))
+	dev.SetFullTOC(full)
+
+	multi := ReadSessions(dev, toc)
+	for i, want := range []int{1, 1, 2} {
+		if multi.Tracks[i].Session != want {
+			t.Errorf("track %d session = %d, want %d", i+1, multi.Tracks[i].Session, want)
+		}
+	}
+}
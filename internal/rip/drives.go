@@ -0,0 +1,91 @@
+package rip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// DriveSettings holds per-drive ripping settings
+type DriveSettings struct {
+	ReadOffset int `json:"read_offset"` // Samples
+}
+
+// DriveConfig maps a drive (see DriveKeys) to its settings
+type DriveConfig map[string]DriveSettings
+
+// DriveKeys returns the config keys that identify a drive, most specific
+// first: INQUIRY vendor/product/revision, then vendor/product for settings
+// that apply to every firmware revision of a model.
+func DriveKeys(info scsi.InquiryData) []string {
+	model := info.Vendor + " " + info.Product
+	return []string{model + " " + info.Revision, model}
+}
+
+// DefaultDriveConfigPath returns ~/.config/crostini-cd-rip/drives.json
+// (or the platform equivalent), or "" if there is no config directory.
+func DefaultDriveConfigPath() string {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(dir, "crostini-cd-rip", "drives.json")
+}
+
+// LoadDriveConfig reads the drive config file.
+// A missing file is an empty config, not an error.
+func LoadDriveConfig(path string) (DriveConfig, error) {
+	config := DriveConfig{}
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return config, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read drive config: %w", err)
+	}
+
+	if err := json.Unmarshal(data, &config); err != nil {
+		return nil, fmt.Errorf("parse drive config %s: %w", path, err)
+	}
+	return config, nil
+}
+
+// SaveDriveConfig writes the drive config file, creating its directory
+func SaveDriveConfig(path string, config DriveConfig) error {
+	if path == "" {
+		return errors.New("no drive config path")
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return fmt.Errorf("create config directory: %w", err)
+	}
+
+	data, _ := json.MarshalIndent(config, "", "  ")
+	if err := os.WriteFile(path, append(data, '\n'), 0644); err != nil {
+		return fmt.Errorf("write drive config: %w", err)
+	}
+	return nil
+}
+
+// Lookup returns the settings for a drive, if configured
+func (c DriveConfig) Lookup(info scsi.InquiryData) (DriveSettings, bool) {
+	for _, key := range DriveKeys(info) {
+		if s, ok := c[key]; ok {
+			return s, true
+		}
+	}
+	return DriveSettings{}, false
+}
+
+// Set stores settings for this exact drive (vendor/product/revision)
+func (c DriveConfig) Set(info scsi.InquiryData, s DriveSettings) {
+	c[DriveKeys(info)[0]] = s
+}
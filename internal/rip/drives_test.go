@@ -0,0 +1,59 @@
+package rip
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+func TestLoadDriveConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drives.json")
+	os.WriteFile(path, []byte(`{"ASUS SDRW-08D2S-U": {"read_offset": 6}}`), 0644)
+
+	config, err := LoadDriveConfig(path)
+	if err != nil {
+		t.Fatalf("LoadDriveConfig error: %v", err)
+	}
+
+	s, ok := config.Lookup(scsi.InquiryData{Vendor: "ASUS", Product: "SDRW-08D2S-U"})
+	if !ok || s.ReadOffset != 6 {
+		t.Errorf("lookup() = %+v, %v, want offset 6", s, ok)
+	}
+	if _, ok := config.Lookup(scsi.InquiryData{Vendor: "LG", Product: "GP65"}); ok {
+		t.Error("lookup() found an unconfigured drive")
+	}
+}
+
+func TestLoadDriveConfig_Missing(t *testing.T) {
+	config, err := LoadDriveConfig(filepath.Join(t.TempDir(), "drives.json"))
+	if err != nil || len(config) != 0 {
+		t.Errorf("LoadDriveConfig() = %v, %v, want empty config", config, err)
+	}
+}
+
+func TestLoadDriveConfig_Invalid(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "drives.json")
+	os.WriteFile(path, []byte(`{not json`), 0644)
+
+	if _, err := LoadDriveConfig(path); err == nil {
+		t.Error("LoadDriveConfig should fail on invalid JSON")
+	}
+}
+
+func TestDriveConfig_RevisionFirst(t *testing.T) {
+	config := DriveConfig{
+		"LG GP65 1.00": {ReadOffset: 6},
+		"LG GP65":      {ReadOffset: 12},
+	}
+
+	s, _ := config.Lookup(scsi.InquiryData{Vendor: "LG", Product: "GP65", Revision: "1.00"})
+	if s.ReadOffset != 6 {
+		t.Errorf("revision 1.00 offset = %d, want 6", s.ReadOffset)
+	}
+	s, _ = config.Lookup(scsi.InquiryData{Vendor: "LG", Product: "GP65", Revision: "2.00"})
+	if s.ReadOffset != 12 {
+		t.Errorf("revision 2.00 offset = %d, want 12 (model default)", s.ReadOffset)
+	}
+}
@@ -0,0 +1,101 @@
+package rip
+
+import (
+	"errors"
+	"fmt"
+	"io"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// ImageName is the single-image rip's file name within the output directory
+const ImageName = cdda.ImageName
+
+// Image extracts every audio track in one continuous pass to disc.wav
+// (see cdda.ImageRange) and computes each track's AccurateRip CRCs from it.
+// Returns the image path and the per-track CRCs.
+func Image(dev scsi.Drive, toc cdda.TOC, opts Options) (string, []accuraterip.TrackCRC, error) {
+	start, end, ok := cdda.ImageRange(toc, opts.HTOA)
+	if !ok {
+		return "", nil, errors.New("no audio tracks")
+	}
+
+	if opts.ReadOffset != 0 {
+		dev = scsi.NewOffsetDrive(dev, opts.ReadOffset, toc.LeadoutLBA)
+	}
+
+	// Route the audio to a checksum per track; hidden track one audio has none
+	split := &splitWriter{}
+	if first := toc.Tracks[0]; start < first.LBA {
+		split.add(first.LBA-start, io.Discard)
+	}
+	var sums []*accuraterip.TrackChecksum
+	var nums []int
+	audio := audioTracks(toc)
+	for n, i := range audio {
+		s, e := cdda.TrackRange(toc, i, false)
+		sum := accuraterip.NewTrackChecksum(e-s, n == 0, n == len(audio)-1)
+		split.add(e-s, sum)
+		sums = append(sums, sum)
+		nums = append(nums, toc.Tracks[i].Num)
+	}
+
+	path := fmt.Sprintf("%s/%s", opts.OutputDir, ImageName)
+	wavFile, err := ripRange(dev, toc, "Disc image", path, start, end, split, opts)
+	if err != nil || wavFile == "" {
+		return "", nil, err
+	}
+
+	crcs := make([]accuraterip.TrackCRC, len(sums))
+	for i, sum := range sums {
+		v1, v2 := sum.Sums()
+		crcs[i] = accuraterip.TrackCRC{Num: nums[i], V1: v1, V2: v2}
+	}
+	return wavFile, crcs, nil
+}
+
+// audioTracks returns the indexes of the audio tracks in toc.Tracks
+func audioTracks(toc cdda.TOC) []int {
+	var idx []int
+	for i, t := range toc.Tracks {
+		if t.IsAudio() {
+			idx = append(idx, i)
+		}
+	}
+	return idx
+}
+
+// splitWriter divides a continuous stream of audio among writers, each
+// receiving a fixed number of frames in turn. Bytes past the last writer are
+// dropped.
+type splitWriter struct {
+	parts []splitPart
+	cur   int
+}
+
+type splitPart struct {
+	w    io.Writer
+	left int // Bytes still owed to w
+}
+
+// add appends a writer that receives the next frames frames
+func (s *splitWriter) add(frames int, w io.Writer) {
+	s.parts = append(s.parts, splitPart{w: w, left: frames * scsi.FrameSize})
+}
+
+func (s *splitWriter) Write(p []byte) (int, error) {
+	n := len(p)
+	for len(p) > 0 && s.cur < len(s.parts) {
+		part := &s.parts[s.cur]
+		chunk := min(len(p), part.left)
+		part.w.Write(p[:chunk])
+		part.left -= chunk
+		p = p[chunk:]
+		if part.left == 0 {
+			s.cur++
+		}
+	}
+	return n, nil
+}
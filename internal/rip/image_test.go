@@ -0,0 +1,61 @@
+package rip
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+func TestImage(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dir := t.TempDir()
+
+	path, crcs, err := Image(dev, toc, Options{OutputDir: dir, ChunkSize: 64})
+	if err != nil {
+		t.Fatalf("Image error: %v", err)
+	}
+	if path != dir+"/disc.wav" {
+		t.Errorf("path = %s, want disc.wav", path)
+	}
+
+	wav, _ := os.ReadFile(path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[150*scsi.FrameSize:400*scsi.FrameSize])) {
+		t.Error("disc.wav should hold LBA 150 through the lead-out")
+	}
+
+	// Per-track CRCs match a track-by-track rip
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 75})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(crcs) != len(ripped) {
+		t.Fatalf("got %d CRCs, want %d", len(crcs), len(ripped))
+	}
+	for i := range crcs {
+		if crcs[i] != ripped[i].CRC {
+			t.Errorf("track %d CRC = %+v, want %+v", crcs[i].Num, crcs[i], ripped[i].CRC)
+		}
+	}
+}
+
+func TestImage_HTOA(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	toc = ScanIndexes(dev, toc)
+	dir := t.TempDir()
+
+	path, crcs, err := Image(dev, toc, Options{OutputDir: dir, ChunkSize: 75, HTOA: true})
+	if err != nil {
+		t.Fatalf("Image error: %v", err)
+	}
+
+	wav, _ := os.ReadFile(path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[:400*scsi.FrameSize])) {
+		t.Error("disc.wav should start with the hidden track")
+	}
+	if len(crcs) != 3 || crcs[0].Num != 1 {
+		t.Errorf("CRCs = %+v, want tracks 1-3 only", crcs)
+	}
+}
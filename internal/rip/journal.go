@@ -0,0 +1,195 @@
+package rip
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+)
+
+// JournalName is the rip journal's file name within the output directory
+const JournalName = "rip-journal.json"
+
+// Journal records the progress of a rip so that an interrupted rip can
+// be continued with --resume. It is saved after every chunk.
+type Journal struct {
+	DiscID      string           `json:"disc_id"`
+	ReadOffset  int              `json:"read_offset"` // Samples
+	PrependGaps bool             `json:"prepend_gaps"`
+	Completed   []JournalEntry   `json:"completed"`
+	Partial     *JournalPartial  `json:"partial,omitempty"`
+	Aborted     []JournalPartial `json:"aborted,omitempty"` // Given up on after read errors
+
+	path string
+}
+
+// JournalEntry is a finished WAV file
+type JournalEntry struct {
+	File  string `json:"file"`  // Name within the output directory
+	CRC32 uint32 `json:"crc32"` // Of the audio data
+}
+
+// JournalPartial is a WAV file being ripped or aborted. Its audio is in
+// File+PartSuffix.
+type JournalPartial struct {
+	File     string `json:"file"`
+	StartLBA int    `json:"start_lba"`
+	EndLBA   int    `json:"end_lba"`
+	DoneLBA  int    `json:"done_lba"` // Frames before this are on disk
+	CRC32    uint32 `json:"crc32"`    // Of the audio on disk
+}
+
+// OpenJournal starts a new journal for the disc in dir, or with resume
+// continues the one there. Resuming fails if the journal is for another
+// disc or was written with a different read offset or pregap placement.
+func OpenJournal(dir, discID string, opts Options, resume bool) (*Journal, error) {
+	path := filepath.Join(dir, JournalName)
+	if !resume {
+		j := &Journal{DiscID: discID, ReadOffset: opts.ReadOffset, PrependGaps: opts.PrependGaps, path: path}
+		return j, j.save()
+	}
+
+	data, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, fmt.Errorf("no rip to resume in %s", dir)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("read journal: %w", err)
+	}
+	j := &Journal{path: path}
+	if err := json.Unmarshal(data, j); err != nil {
+		return nil, fmt.Errorf("parse journal %s: %w", path, err)
+	}
+
+	if j.DiscID != discID {
+		return nil, fmt.Errorf("journal is for disc %s, but disc %s is in the drive", j.DiscID, discID)
+	}
+	if j.ReadOffset != opts.ReadOffset || j.PrependGaps != opts.PrependGaps {
+		return nil, fmt.Errorf("journal was ripped with read offset %+d and prepend gaps %t, not %+d and %t",
+			j.ReadOffset, j.PrependGaps, opts.ReadOffset, opts.PrependGaps)
+	}
+	return j, nil
+}
+
+// save writes the journal under a temporary name and renames it into
+// place, so an interruption leaves the previous journal intact
+func (j *Journal) save() error {
+	data, _ := json.MarshalIndent(j, "", "  ")
+	tmp := j.path + PartSuffix
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("write journal: %w", err)
+	}
+	return nil
+}
+
+// completed returns the journal entry for a finished file
+func (j *Journal) completed(file string) (JournalEntry, bool) {
+	for _, e := range j.Completed {
+		if e.File == file {
+			return e, true
+		}
+	}
+	return JournalEntry{}, false
+}
+
+// partial returns the progress of file if it is the one being ripped, or
+// was aborted, over [startLBA, endLBA)
+func (j *Journal) partial(file string, startLBA, endLBA int) (JournalPartial, bool) {
+	candidates := j.Aborted
+	if j.Partial != nil {
+		candidates = append([]JournalPartial{*j.Partial}, candidates...)
+	}
+	for _, p := range candidates {
+		if p.File == file && p.StartLBA == startLBA && p.EndLBA == endLBA {
+			return p, true
+		}
+	}
+	return JournalPartial{}, false
+}
+
+// progress records the audio on disk for the file being ripped
+func (j *Journal) progress(p JournalPartial) error {
+	j.Aborted = withoutFile(j.Aborted, p.File)
+	j.Partial = &p
+	return j.save()
+}
+
+// abort records the audio on disk for a file given up on, so that a later
+// rip of other files keeps it and --resume continues it from p.DoneLBA
+func (j *Journal) abort(p JournalPartial) error {
+	j.Aborted = append(withoutFile(j.Aborted, p.File), p)
+	j.Partial = nil
+	return j.save()
+}
+
+// withoutFile returns ps without the entry for file
+func withoutFile(ps []JournalPartial, file string) []JournalPartial {
+	kept := ps[:0]
+	for _, p := range ps {
+		if p.File != file {
+			kept = append(kept, p)
+		}
+	}
+	return kept
+}
+
+// complete records a finished file, replacing any earlier entry for it
+func (j *Journal) complete(e JournalEntry) error {
+	kept := j.Completed[:0]
+	for _, old := range j.Completed {
+		if old.File != e.File {
+			kept = append(kept, old)
+		}
+	}
+	j.Completed = append(kept, e)
+	j.Aborted = withoutFile(j.Aborted, e.File)
+	j.Partial = nil
+	return j.save()
+}
+
+// replayAudio checks that n bytes of audio at offset in path have the given
+// CRC-32 and, if so, feeds them to w. Nothing is written to w on a mismatch.
+func replayAudio(path string, offset, n int64, crc uint32, w io.Writer) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	defer f.Close()
+
+	h := crc32.NewIEEE()
+	if copied, err := io.Copy(h, io.NewSectionReader(f, offset, n)); err != nil || copied != n || h.Sum32() != crc {
+		return false
+	}
+	_, err = io.Copy(w, io.NewSectionReader(f, offset, n))
+	return err == nil
+}
+
+// replayWAV checks a finished WAV file against its journal entry and, if it
+// matches, feeds its audio to w
+func replayWAV(path string, e JournalEntry, w io.Writer) bool {
+	f, err := os.Open(path)
+	if err != nil {
+		return false
+	}
+	header := make([]byte, cdda.WAVHeaderSize)
+	_, err = io.ReadFull(f, header)
+	f.Close()
+	if err != nil {
+		return false
+	}
+
+	offset, size, err := cdda.ParseWAVHeader(header)
+	if err != nil {
+		return false
+	}
+	return replayAudio(path, int64(offset), int64(size), e.CRC32, w)
+}
@@ -0,0 +1,179 @@
+package rip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+func TestTracks_Resume(t *testing.T) {
+	retryDelay = 0
+	dir := t.TempDir()
+
+	// A clean rip for the expected checksums
+	dev, toc, pcm := simDisc(t)
+	want, err := Tracks(dev, toc, nil, Options{OutputDir: t.TempDir(), ChunkSize: 25})
+	if err != nil {
+		t.Fatalf("clean rip error: %v", err)
+	}
+
+	// The disc is ejected halfway through track 2
+	dev, toc, _ = simDisc(t)
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseNotReady, ASC: scsi.ASCMediumNotPresent}, 0)
+	opts := Options{OutputDir: dir, ChunkSize: 25}
+	discID := cdda.CalculateDiscID(toc)
+	if opts.Journal, err = OpenJournal(dir, discID, opts, false); err != nil {
+		t.Fatalf("OpenJournal error: %v", err)
+	}
+	if _, err := Tracks(dev, toc, nil, opts); !errors.Is(err, scsi.ErrMediumNotPresent) {
+		t.Fatalf("Tracks error = %v, want ErrMediumNotPresent", err)
+	}
+	if p := opts.Journal.Partial; p == nil || p.File != "track02.wav" || p.DoneLBA != 250 {
+		t.Fatalf("journal partial = %+v, want track02.wav done to LBA 250", p)
+	}
+
+	// On resume, frames already on disk are never read again: unreadable
+	// there, they would come back as silence
+	dev, toc, _ = simDisc(t)
+	for _, lba := range []int{160, 210} {
+		dev.InjectFault(lba, scsi.SenseData{Key: scsi.SenseMediumError, ASC: scsi.ASCUnrecoveredReadError}, 0)
+	}
+	if opts.Journal, err = OpenJournal(dir, discID, opts, true); err != nil {
+		t.Fatalf("OpenJournal resume error: %v", err)
+	}
+	ripped, err := Tracks(dev, toc, nil, opts)
+	if err != nil {
+		t.Fatalf("resumed Tracks error: %v", err)
+	}
+	if len(ripped) != 3 {
+		t.Fatalf("ripped %d tracks, want 3", len(ripped))
+	}
+
+	bounds := [][2]int{{150, 200}, {200, 310}, {310, 400}}
+	for i, b := range bounds {
+		wav, _ := os.ReadFile(ripped[i].Path)
+		if !bytes.Equal(wav, cdda.WriteWAV(pcm[b[0]*scsi.FrameSize:b[1]*scsi.FrameSize])) {
+			t.Errorf("track %d WAV does not match disc image LBA %d-%d", i+1, b[0], b[1])
+		}
+		if ripped[i].CRC != want[i].CRC {
+			t.Errorf("track %d CRC = %+v, want %+v", i+1, ripped[i].CRC, want[i].CRC)
+		}
+	}
+	if opts.Journal.Partial != nil || len(opts.Journal.Completed) != 3 {
+		t.Errorf("journal = %+v, want 3 completed tracks", opts.Journal)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "track02.wav"+PartSuffix)); !os.IsNotExist(err) {
+		t.Error("track02.wav.part should be gone after the resumed rip")
+	}
+}
+
+func TestTracks_ResumeAborted(t *testing.T) {
+	retryDelay = 0
+	dir := t.TempDir()
+
+	// Track 2 fails halfway; track 3 is still ripped
+	dev, toc, pcm := simDisc(t)
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseIllegalRequest, ASC: scsi.ASCLBAOutOfRange}, 0)
+	opts := Options{OutputDir: dir, ChunkSize: 25}
+	discID := cdda.CalculateDiscID(toc)
+	var err error
+	if opts.Journal, err = OpenJournal(dir, discID, opts, false); err != nil {
+		t.Fatalf("OpenJournal error: %v", err)
+	}
+	ripped, err := Tracks(dev, toc, nil, opts)
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(ripped) != 2 {
+		t.Fatalf("ripped %d tracks, want 2", len(ripped))
+	}
+	if len(opts.Journal.Completed) != 2 {
+		t.Errorf("journal completed = %+v, want tracks 1 and 3", opts.Journal.Completed)
+	}
+	if a := opts.Journal.Aborted; len(a) != 1 || a[0].File != "track02.wav" || a[0].DoneLBA != 250 {
+		t.Fatalf("journal aborted = %+v, want track02.wav done to LBA 250", a)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "track02.wav"+PartSuffix)); err != nil {
+		t.Fatalf("track02.wav.part should be kept for --resume: %v", err)
+	}
+
+	// On resume, track 2 continues where it failed
+	dev, toc, _ = simDisc(t)
+	dev.InjectFault(210, scsi.SenseData{Key: scsi.SenseIllegalRequest, ASC: scsi.ASCLBAOutOfRange}, 0)
+	if opts.Journal, err = OpenJournal(dir, discID, opts, true); err != nil {
+		t.Fatalf("OpenJournal resume error: %v", err)
+	}
+	if ripped, err = Tracks(dev, toc, nil, opts); err != nil {
+		t.Fatalf("resumed Tracks error: %v", err)
+	}
+	if len(ripped) != 3 {
+		t.Fatalf("ripped %d tracks, want 3", len(ripped))
+	}
+	wav, _ := os.ReadFile(ripped[1].Path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[200*scsi.FrameSize:310*scsi.FrameSize])) {
+		t.Error("track 2 WAV does not match disc image LBA 200-310")
+	}
+	if len(opts.Journal.Aborted) != 0 || len(opts.Journal.Completed) != 3 {
+		t.Errorf("journal = %+v, want 3 completed tracks", opts.Journal)
+	}
+}
+
+func TestTracks_ResumeChangedFile(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dir := t.TempDir()
+	opts := Options{OutputDir: dir, ChunkSize: 75}
+	discID := cdda.CalculateDiscID(toc)
+
+	var err error
+	if opts.Journal, err = OpenJournal(dir, discID, opts, false); err != nil {
+		t.Fatalf("OpenJournal error: %v", err)
+	}
+	if _, err := Tracks(dev, toc, tracks(1), opts); err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	// Damage the finished file; its checksum no longer matches the journal
+	path := filepath.Join(dir, "track01.wav")
+	wav, _ := os.ReadFile(path)
+	wav[cdda.WAVHeaderSize+100] ^= 0xFF
+	os.WriteFile(path, wav, 0644)
+
+	if opts.Journal, err = OpenJournal(dir, discID, opts, true); err != nil {
+		t.Fatalf("OpenJournal resume error: %v", err)
+	}
+	if _, err := Tracks(dev, toc, tracks(1), opts); err != nil {
+		t.Fatalf("resumed Tracks error: %v", err)
+	}
+
+	wav, _ = os.ReadFile(path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[150*scsi.FrameSize:200*scsi.FrameSize])) {
+		t.Error("track 1 should be ripped again")
+	}
+}
+
+func TestOpenJournal_Resume(t *testing.T) {
+	dir := t.TempDir()
+	opts := Options{ReadOffset: 6}
+
+	if _, err := OpenJournal(dir, "disc-a", opts, true); err == nil {
+		t.Error("expected error resuming without a journal")
+	}
+	if _, err := OpenJournal(dir, "disc-a", opts, false); err != nil {
+		t.Fatalf("OpenJournal error: %v", err)
+	}
+
+	if _, err := OpenJournal(dir, "disc-b", opts, true); err == nil {
+		t.Error("expected error resuming with a different disc")
+	}
+	if _, err := OpenJournal(dir, "disc-a", Options{ReadOffset: 0}, true); err == nil {
+		t.Error("expected error resuming with a different read offset")
+	}
+	if _, err := OpenJournal(dir, "disc-a", opts, true); err != nil {
+		t.Errorf("resume error: %v", err)
+	}
+}
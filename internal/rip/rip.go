@@ -0,0 +1,363 @@
+// Package rip extracts audio from a CD drive to WAV files: track by track
+// or as one disc image, with retries, secure re-reads, read offset
+// correction and a journal for resuming interrupted rips.
+package rip
+
+import (
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/accuraterip"
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// Options configures track extraction
+type Options struct {
+	OutputDir   string
+	ChunkSize   int // Frames per READ CD
+	Verbose     bool
+	Secure      bool        // Read every chunk at least twice and compare
+	MaxRereads  int         // Extra reads allowed for a chunk whose passes disagree
+	ReadOffset  int         // Drive read offset correction in samples
+	PrependGaps bool        // Rip pregaps at the start of their track instead of the end of the previous one
+	HTOA        bool        // Extract hidden track one audio as track 0
+	Journal     *Journal    // Records progress for --resume (nil for none)
+	OnTrack     func(Track) // Called as each track is finished (nil for none)
+}
+
+// Track is a track written to disk and its AccurateRip checksums.
+// CRC.Num is the track number.
+type Track struct {
+	Path string
+	CRC  accuraterip.TrackCRC
+}
+
+// Tracks extracts the audio tracks in tracksToRip (all if nil) to
+// trackNN.wav files in the output directory, in disc order.
+// Returns the tracks ripped so far and an error if the disc was ejected or
+// changed; other failures skip just the failing track.
+func Tracks(dev scsi.Drive, toc cdda.TOC, tracksToRip map[int]bool, opts Options) ([]Track, error) {
+	var ripped []Track
+	finish := func(t Track) {
+		ripped = append(ripped, t)
+		if opts.OnTrack != nil {
+			opts.OnTrack(t)
+		}
+	}
+
+	if opts.ReadOffset != 0 {
+		dev = scsi.NewOffsetDrive(dev, opts.ReadOffset, toc.LeadoutLBA)
+	}
+
+	// AccurateRip excludes the outer frames of the disc's first and last audio tracks
+	firstAudio, lastAudio := 0, 0
+	for _, track := range toc.Tracks {
+		if track.IsAudio() {
+			if firstAudio == 0 {
+				firstAudio = track.Num
+			}
+			lastAudio = track.Num
+		}
+	}
+
+	if start, end, ok := cdda.HiddenTrackRange(toc); ok && opts.HTOA && (tracksToRip == nil || tracksToRip[0]) {
+		fmt.Printf("Hidden track one audio:\n")
+		wavFile, err := ripTrack(dev, toc, 0, start, end, io.Discard, opts)
+		if err != nil {
+			return ripped, err
+		}
+		if wavFile != "" {
+			finish(Track{Path: wavFile, CRC: accuraterip.TrackCRC{Num: 0}})
+		}
+	}
+
+	for i, track := range toc.Tracks {
+		// Skip if not in selection
+		if tracksToRip != nil && !tracksToRip[track.Num] {
+			continue
+		}
+
+		// Skip data tracks
+		if track.Type == cdda.TrackTypeData {
+			fmt.Printf("Track %d: Skipping (data track)\n", track.Num)
+			continue
+		}
+
+		startLBA, endLBA := cdda.TrackRange(toc, i, opts.PrependGaps)
+
+		sum := accuraterip.NewTrackChecksum(endLBA-startLBA, track.Num == firstAudio, track.Num == lastAudio)
+		wavFile, err := ripTrack(dev, toc, track.Num, startLBA, endLBA, sum, opts)
+		if err != nil {
+			return ripped, err
+		}
+		if wavFile != "" {
+			v1, v2 := sum.Sums()
+			finish(Track{
+				Path: wavFile,
+				CRC:  accuraterip.TrackCRC{Num: track.Num, V1: v1, V2: v2},
+			})
+		}
+	}
+
+	return ripped, nil
+}
+
+// ripTrack extracts one track to a WAV file, feeding the audio to sum.
+// Returns an error only when the rest of the disc can't be ripped either
+// (disc ejected or changed); other failures abort just this track.
+func ripTrack(dev scsi.Drive, toc cdda.TOC, trackNum, startLBA, endLBA int, sum io.Writer, opts Options) (string, error) {
+	filename := fmt.Sprintf("%s/track%02d.wav", opts.OutputDir, trackNum)
+	return ripRange(dev, toc, fmt.Sprintf("Track %d", trackNum), filename, startLBA, endLBA, sum, opts)
+}
+
+// PartSuffix marks a WAV file still being ripped
+const PartSuffix = ".part"
+
+// ripRange extracts frames [startLBA, endLBA) to a WAV file, feeding the
+// audio to sum. label names the range in progress output.
+// The audio is streamed to disk under a temporary name and renamed when
+// complete, so a WAV file with the final name is never partial.
+// With a journal, progress is recorded after every chunk: a file the
+// journal lists as complete is verified and skipped, and a partial file is
+// continued from its last good chunk.
+// Errors are as for ripTrack.
+func ripRange(dev scsi.Drive, toc cdda.TOC, label, filename string, startLBA, endLBA int, sum io.Writer, opts Options) (string, error) {
+	totalFrames := endLBA - startLBA
+	durationSec := float64(totalFrames) / float64(scsi.FramesPerSecond)
+	journal := opts.Journal
+	base := filepath.Base(filename)
+
+	if journal != nil {
+		if e, ok := journal.completed(base); ok && replayWAV(filename, e, sum) {
+			fmt.Printf("%s: already ripped (%s)\n", label, filename)
+			return filename, nil
+		}
+	}
+
+	fmt.Printf("%s: %d frames (%.1fs / %.1fm)\n",
+		label, totalFrames, durationSec, durationSec/60)
+
+	// Stream to filename.part, renamed into place once the WAV is complete
+	partName := filename + PartSuffix
+	part, err := os.OpenFile(partName, os.O_CREATE|os.O_RDWR, 0644)
+	if err != nil {
+		fmt.Printf("  Error creating %s: %v\n", partName, err)
+		return "", nil
+	}
+	committed, keep := false, false
+	defer func() {
+		if !committed {
+			part.Close()
+			if !keep {
+				os.Remove(partName)
+			}
+		}
+	}()
+
+	// Continue from the journal's last good chunk, or start over
+	currentLBA := startLBA
+	crc := crc32.NewIEEE()
+	var resumed JournalPartial
+	var resumeOK bool
+	if journal != nil {
+		resumed, resumeOK = journal.partial(base, startLBA, endLBA)
+	}
+	if resumeOK {
+		size := int64(resumed.DoneLBA-startLBA) * scsi.FrameSize
+		resumeOK = replayAudio(partName, cdda.WAVHeaderSize, size, resumed.CRC32, io.MultiWriter(sum, crc)) &&
+			part.Truncate(cdda.WAVHeaderSize+size) == nil
+	}
+	var wav *cdda.WAVWriter
+	if resumeOK {
+		currentLBA = resumed.DoneLBA
+		wav, err = cdda.ResumeWAVWriter(part, int64(currentLBA-startLBA)*scsi.FrameSize)
+		fmt.Printf("  Resuming at LBA %d\n", currentLBA)
+	} else if err = part.Truncate(0); err == nil {
+		wav, err = cdda.NewWAVWriter(part)
+	}
+	if err != nil {
+		fmt.Printf("  Error writing %s: %v\n", partName, err)
+		return "", nil
+	}
+
+	startTime := time.Now()
+	resumeLBA := currentLBA
+	badFrames := 0
+	suspicious := 0
+	rereadChunks := 0
+
+	for currentLBA < endLBA {
+		framesToRead := opts.ChunkSize
+		if currentLBA+framesToRead > endLBA {
+			framesToRead = endLBA - currentLBA
+		}
+
+		var data []byte
+		var bad int
+		var err error
+		if opts.Secure {
+			var vote cdda.VoteResult
+			vote, bad, err = readChunkSecure(dev, currentLBA, framesToRead, toc.LeadoutLBA, opts.MaxRereads)
+			data = vote.Data
+			suspicious += vote.Suspicious
+			if vote.Disagreed > 0 {
+				rereadChunks++
+			}
+		} else {
+			data, bad, err = ReadChunk(dev, currentLBA, framesToRead)
+		}
+		if err != nil {
+			if errors.Is(err, scsi.ErrMediumNotPresent) || errors.Is(err, scsi.ErrUnitAttention) {
+				fmt.Printf("\n  %v at LBA %d\n", err, currentLBA)
+				keep = journal != nil // --resume continues from here
+				return "", err
+			}
+			fmt.Printf("\n  Error: too many errors at LBA %d (%v), %s not saved\n", currentLBA, err, label)
+			wav.Close()
+			if journal != nil {
+				// --resume continues from here; the audio so far is kept
+				keep = true
+				err := journal.abort(JournalPartial{
+					File:     base,
+					StartLBA: startLBA,
+					EndLBA:   endLBA,
+					DoneLBA:  currentLBA,
+					CRC32:    crc.Sum32(),
+				})
+				if err != nil {
+					fmt.Printf("  Warning: %v\n", err)
+				}
+			}
+			return "", nil
+		}
+
+		if _, err := wav.Write(data); err != nil {
+			fmt.Printf("\n  Error writing %s: %v\n", partName, err)
+			return "", nil
+		}
+		sum.Write(data)
+		crc.Write(data)
+		currentLBA += framesToRead
+		badFrames += bad
+
+		if journal != nil {
+			err := journal.progress(JournalPartial{
+				File:     base,
+				StartLBA: startLBA,
+				EndLBA:   endLBA,
+				DoneLBA:  currentLBA,
+				CRC32:    crc.Sum32(),
+			})
+			if err != nil {
+				fmt.Printf("\n  Warning: %v\n", err)
+			}
+		}
+
+		// Progress
+		done := currentLBA - startLBA
+		progress := done * 100 / totalFrames
+		elapsed := time.Since(startTime).Seconds()
+		speed := float64(currentLBA-resumeLBA) / elapsed
+		eta := float64(totalFrames-done) / speed
+
+		fmt.Printf("\r  %3d%% | %d/%d frames | %.0f frames/s | ETA: %.0fs   ",
+			progress, done, totalFrames, speed, eta)
+	}
+
+	fmt.Println()
+
+	if badFrames > 0 {
+		fmt.Printf("  Warning: %d unreadable frames replaced with silence\n", badFrames)
+	}
+	if opts.Secure {
+		fmt.Printf("  Secure: %d suspicious frames (%d chunks re-read)\n", suspicious, rereadChunks)
+	}
+
+	// Finish the WAV file
+	if err := wav.Close(); err != nil {
+		fmt.Printf("  Error writing %s: %v\n", partName, err)
+		return "", nil
+	}
+	if err := part.Close(); err != nil {
+		fmt.Printf("  Error writing %s: %v\n", partName, err)
+		return "", nil
+	}
+	if err := os.Rename(partName, filename); err != nil {
+		fmt.Printf("  Error renaming %s: %v\n", partName, err)
+		return "", nil
+	}
+	committed = true
+
+	if journal != nil {
+		if err := journal.complete(JournalEntry{File: base, CRC32: crc.Sum32()}); err != nil {
+			fmt.Printf("  Warning: %v\n", err)
+		}
+	}
+
+	fileSize := cdda.WAVHeaderSize + wav.Size()
+	fmt.Printf("  Saved: %s (%.1f MB)\n", filename, float64(fileSize)/1024/1024)
+
+	return filename, nil
+}
+
+// Read retry policy
+const maxRetries = 10
+
+var retryDelay = 100 * time.Millisecond
+
+// ReadChunk reads frames with retries.
+// Returns (data, badFrames, error).
+//
+// A missing or changed disc and illegal requests fail immediately since
+// retrying can't help. Unrecoverable read errors (scratches) and short reads
+// fall back to salvageFrames once retries run out, so one bad sector doesn't
+// cost the track.
+func ReadChunk(dev scsi.Drive, lba, frames int) ([]byte, int, error) {
+	for retries := 0; ; retries++ {
+		data, err := dev.ReadCDFrames(lba, frames)
+		if err == nil {
+			return data, 0, nil
+		}
+
+		if errors.Is(err, scsi.ErrMediumNotPresent) ||
+			errors.Is(err, scsi.ErrUnitAttention) ||
+			errors.Is(err, scsi.ErrIllegalRequest) {
+			return nil, 0, err
+		}
+
+		if retries >= maxRetries {
+			if errors.Is(err, scsi.ErrUnrecoverableRead) || errors.Is(err, scsi.ErrShortRead) {
+				data, bad := salvageFrames(dev, lba, frames)
+				return data, bad, nil
+			}
+			return nil, 0, err
+		}
+
+		fmt.Printf("\n  Error at LBA %d (%v), retrying...\n", lba, err)
+		time.Sleep(retryDelay)
+	}
+}
+
+// salvageFrames reads frames one at a time, substituting silence for frames
+// that still can't be read. Returns the data and the number of silenced frames.
+func salvageFrames(dev scsi.Drive, lba, frames int) ([]byte, int) {
+	data := make([]byte, 0, frames*scsi.FrameSize)
+	bad := 0
+
+	for i := 0; i < frames; i++ {
+		frame, err := dev.ReadCDFrames(lba+i, 1)
+		if err != nil || len(frame) != scsi.FrameSize {
+			frame = make([]byte, scsi.FrameSize)
+			bad++
+		}
+		data = append(data, frame...)
+	}
+
+	return data, bad
+}
@@ -0,0 +1,270 @@
+package rip
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// End-to-end rip tests against a simulated drive (no USB hardware needed).
+
+// simDisc returns a simulated 3-track disc and its parsed TOC
+func simDisc(t *testing.T) (*scsi.SimDrive, cdda.TOC, []byte) {
+	t.Helper()
+	pcm := scsi.SimImage(400)
+	dev := scsi.NewSimDrive(scsi.SimTOC([]int{150, 200, 310}, 400), pcm)
+
+	raw, err := dev.ReadTOCRaw()
+	if err != nil {
+		t.Fatalf("ReadTOCRaw error: %v", err)
+	}
+	toc, err := cdda.ParseTOC(raw)
+	if err != nil {
+		t.Fatalf("ParseTOC error: %v", err)
+	}
+	return dev, toc, pcm
+}
+
+// tracks returns a track selection for Tracks
+func tracks(nums ...int) map[int]bool {
+	sel := make(map[int]bool)
+	for _, n := range nums {
+		sel[n] = true
+	}
+	return sel
+}
+
+func TestTracks_SimDrive(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 32})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(ripped) != 3 {
+		t.Fatalf("ripped %d tracks, want 3", len(ripped))
+	}
+
+	bounds := [][2]int{{150, 200}, {200, 310}, {310, 400}}
+	for i, b := range bounds {
+		wav, err := os.ReadFile(ripped[i].Path)
+		if err != nil {
+			t.Fatalf("read %s: %v", ripped[i].Path, err)
+		}
+		want := cdda.WriteWAV(pcm[b[0]*scsi.FrameSize : b[1]*scsi.FrameSize])
+		if !bytes.Equal(wav, want) {
+			t.Errorf("track %d WAV does not match disc image LBA %d-%d", i+1, b[0], b[1])
+		}
+	}
+}
+
+func TestTracks_Selection(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(2), Options{OutputDir: dir, ChunkSize: 75})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(ripped) != 1 {
+		t.Fatalf("ripped %d tracks, want 1", len(ripped))
+	}
+	if ripped[0].Path != dir+"/track02.wav" {
+		t.Errorf("ripped %s, want track02.wav", ripped[0].Path)
+	}
+}
+
+func TestTracks_TransientReadError(t *testing.T) {
+	retryDelay = 0
+	dev, toc, pcm := simDisc(t)
+	dev.InjectFault(160, scsi.SenseData{Key: scsi.SenseMediumError, ASC: scsi.ASCUnrecoveredReadError}, 3)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(1), Options{OutputDir: dir, ChunkSize: 75})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	wav, _ := os.ReadFile(ripped[0].Path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[150*scsi.FrameSize:200*scsi.FrameSize])) {
+		t.Error("track 1 should be intact after retries")
+	}
+}
+
+func TestTracks_ScratchedSector(t *testing.T) {
+	retryDelay = 0
+	dev, toc, pcm := simDisc(t)
+	dev.InjectFault(160, scsi.SenseData{Key: scsi.SenseMediumError, ASC: scsi.ASCUnrecoveredReadError}, 0)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(1), Options{OutputDir: dir, ChunkSize: 75})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	// Only the unreadable frame is silenced; the rest of the track survives
+	want := make([]byte, 50*scsi.FrameSize)
+	copy(want, pcm[150*scsi.FrameSize:200*scsi.FrameSize])
+	for i := 10 * scsi.FrameSize; i < 11*scsi.FrameSize; i++ {
+		want[i] = 0
+	}
+	wav, _ := os.ReadFile(ripped[0].Path)
+	if !bytes.Equal(wav, cdda.WriteWAV(want)) {
+		t.Error("track 1 should have exactly one silenced frame")
+	}
+}
+
+func TestTracks_DiscEjected(t *testing.T) {
+	retryDelay = 0
+	dev, toc, _ := simDisc(t)
+	dev.Eject()
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 75})
+	if !errors.Is(err, scsi.ErrMediumNotPresent) {
+		t.Fatalf("Tracks error = %v, want ErrMediumNotPresent", err)
+	}
+	if len(ripped) != 0 {
+		t.Errorf("ripped %d tracks after eject, want 0", len(ripped))
+	}
+}
+
+func TestTracks_ReadOffset(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 32, ReadOffset: 30})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	shift := 30 * scsi.SampleSize
+
+	// Track 2 is shifted forward and borrows the head of track 3 at the end
+	wav, _ := os.ReadFile(ripped[1].Path)
+	want := pcm[200*scsi.FrameSize+shift : 310*scsi.FrameSize+shift]
+	if !bytes.Equal(wav, cdda.WriteWAV(want)) {
+		t.Error("track 2 should be shifted 30 samples across the track boundary")
+	}
+
+	// The last track runs into the lead-out, which the sim can't read
+	wav, _ = os.ReadFile(ripped[2].Path)
+	want = append(bytes.Clone(pcm[310*scsi.FrameSize+shift:]), make([]byte, shift)...)
+	if !bytes.Equal(wav, cdda.WriteWAV(want)) {
+		t.Error("track 3 should end with zero padding past the lead-out")
+	}
+}
+
+func TestTracks_PrependGaps(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dev.SetIndex(180, 2, 0)
+	toc = ScanIndexes(dev, toc)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(1, 2), Options{OutputDir: dir, ChunkSize: 75, PrependGaps: true})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	bounds := [][2]int{{150, 180}, {180, 310}}
+	for i, b := range bounds {
+		wav, _ := os.ReadFile(ripped[i].Path)
+		if !bytes.Equal(wav, cdda.WriteWAV(pcm[b[0]*scsi.FrameSize:b[1]*scsi.FrameSize])) {
+			t.Errorf("track %d should span LBA %d-%d", i+1, b[0], b[1])
+		}
+	}
+}
+
+func TestTracks_HTOA(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	toc = ScanIndexes(dev, toc) // Track 1 starts at LBA 150, so 150 frames are hidden
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(0), Options{OutputDir: dir, ChunkSize: 75, HTOA: true})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(ripped) != 1 || ripped[0].Path != dir+"/track00.wav" {
+		t.Fatalf("ripped %v, want track00.wav only", ripped)
+	}
+
+	wav, _ := os.ReadFile(ripped[0].Path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[:150*scsi.FrameSize])) {
+		t.Error("track00.wav should hold LBA 0-150")
+	}
+}
+
+func TestTracks_NoPartialFiles(t *testing.T) {
+	retryDelay = 0
+	dev, toc, _ := simDisc(t)
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseNotReady, ASC: scsi.ASCMediumNotPresent}, 0)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 25})
+	if !errors.Is(err, scsi.ErrMediumNotPresent) {
+		t.Fatalf("Tracks error = %v, want ErrMediumNotPresent", err)
+	}
+	if len(ripped) != 1 {
+		t.Fatalf("ripped %d tracks, want 1", len(ripped))
+	}
+
+	// Track 2 was interrupted halfway: neither it nor its temporary file remains
+	for _, name := range []string{"track02.wav", "track02.wav" + PartSuffix} {
+		if _, err := os.Stat(dir + "/" + name); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist after an interrupted rip", name)
+		}
+	}
+}
+
+func TestTracks_TooManyErrors(t *testing.T) {
+	retryDelay = 0
+	dev, toc, _ := simDisc(t)
+	dev.InjectFault(250, scsi.SenseData{Key: scsi.SenseIllegalRequest, ASC: scsi.ASCLBAOutOfRange}, 0)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, nil, Options{OutputDir: dir, ChunkSize: 25})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	// Track 2 is skipped; the tracks around it are still ripped
+	var nums []int
+	for _, tr := range ripped {
+		nums = append(nums, tr.CRC.Num)
+	}
+	if len(nums) != 2 || nums[0] != 1 || nums[1] != 3 {
+		t.Errorf("ripped tracks %v, want [1 3]", nums)
+	}
+	for _, name := range []string{"track02.wav", "track02.wav" + PartSuffix} {
+		if _, err := os.Stat(dir + "/" + name); !os.IsNotExist(err) {
+			t.Errorf("%s should not exist after an aborted track", name)
+		}
+	}
+}
+
+func TestTracks_OnTrack(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+
+	var finished []int
+	opts := Options{
+		OutputDir: t.TempDir(),
+		ChunkSize: 75,
+		OnTrack: func(tr Track) {
+			if _, err := os.Stat(tr.Path); err != nil {
+				t.Errorf("track %d not on disk when handed over: %v", tr.CRC.Num, err)
+			}
+			finished = append(finished, tr.CRC.Num)
+		},
+	}
+	if _, err := Tracks(dev, toc, tracks(1, 3), opts); err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+	if len(finished) != 2 || finished[0] != 1 || finished[1] != 3 {
+		t.Errorf("OnTrack called for %v, want [1 3]", finished)
+	}
+}
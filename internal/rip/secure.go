@@ -0,0 +1,68 @@
+package rip
+
+import (
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+// readChunkSecure reads a chunk at least twice, defeating the drive cache
+// between passes, and re-reads it up to maxRereads more times while some
+// frame has no majority version.
+// Returns (vote, badFrames, error); vote.Data holds the majority audio.
+func readChunkSecure(dev scsi.Drive, lba, frames, leadoutLBA, maxRereads int) (cdda.VoteResult, int, error) {
+	var reads [][]byte
+	badFrames := 0
+
+	read := func() error {
+		if len(reads) > 0 {
+			defeatCache(dev, lba, frames, leadoutLBA)
+		}
+		data, bad, err := ReadChunk(dev, lba, frames)
+		if err != nil {
+			return err
+		}
+		if bad > badFrames {
+			badFrames = bad
+		}
+		reads = append(reads, data)
+		return nil
+	}
+
+	for pass := 0; pass < 2; pass++ {
+		if err := read(); err != nil {
+			return cdda.VoteResult{}, 0, err
+		}
+	}
+
+	vote := cdda.VoteFrames(reads)
+	for rereads := 0; vote.Suspicious > 0 && rereads < maxRereads; rereads++ {
+		if err := read(); err != nil {
+			return cdda.VoteResult{}, 0, err
+		}
+		vote = cdda.VoteFrames(reads)
+	}
+
+	return vote, badFrames, nil
+}
+
+// cacheFlushSize is how much audio defeatCache reads. USB drives commonly
+// cache 1-4 MB, so this assumes no drive caches more than 6 MB.
+const cacheFlushSize = 6 << 20
+
+// defeatCache reads cacheFlushSize of audio far away from the chunk at lba,
+// in reads of frames frames, so the drive's cache no longer holds the chunk
+// and the next pass reads the disc again. READ CD has no force-unit-access
+// bit, so evicting the chunk is the only way.
+// Errors are ignored; the reads only exist for their side effect.
+func defeatCache(dev scsi.Drive, lba, frames, leadoutLBA int) {
+	n := (cacheFlushSize + scsi.FrameSize - 1) / scsi.FrameSize
+
+	// The end of the disc for a chunk in the first half, else the start
+	start, end := 0, min(n, lba)
+	if lba < leadoutLBA/2 {
+		start, end = max(leadoutLBA-n, lba+frames), leadoutLBA
+	}
+	for far := start; far < end; far += frames {
+		dev.ReadCDFrames(far, min(frames, end-far))
+	}
+}
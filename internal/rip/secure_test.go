@@ -0,0 +1,103 @@
+package rip
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/binaryphile/crostini-cd-rip/internal/cdda"
+	"github.com/binaryphile/crostini-cd-rip/internal/scsi"
+)
+
+func TestTracks_SecureCorrectsMisread(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dev.InjectMisread(170, 1) // First pass misreads, later passes are clean
+	dir := t.TempDir()
+
+	opts := Options{OutputDir: dir, ChunkSize: 25, Secure: true, MaxRereads: 3}
+	ripped, err := Tracks(dev, toc, tracks(1), opts)
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	wav, _ := os.ReadFile(ripped[0].Path)
+	if !bytes.Equal(wav, cdda.WriteWAV(pcm[150*scsi.FrameSize:200*scsi.FrameSize])) {
+		t.Error("secure mode should vote out the misread frame")
+	}
+}
+
+func TestTracks_NonSecureKeepsMisread(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+	dev.InjectMisread(170, 1)
+	dir := t.TempDir()
+
+	ripped, err := Tracks(dev, toc, tracks(1), Options{OutputDir: dir, ChunkSize: 25})
+	if err != nil {
+		t.Fatalf("Tracks error: %v", err)
+	}
+
+	wav, _ := os.ReadFile(ripped[0].Path)
+	if bytes.Equal(wav, cdda.WriteWAV(pcm[150*scsi.FrameSize:200*scsi.FrameSize])) {
+		t.Error("single-pass rip should not detect a silent misread")
+	}
+}
+
+func TestReadChunkSecure_Unstable(t *testing.T) {
+	dev, toc, _ := simDisc(t)
+	dev.InjectMisread(170, 0) // Different garbage on every read
+
+	vote, _, err := readChunkSecure(dev, 150, 25, toc.LeadoutLBA, 3)
+	if err != nil {
+		t.Fatalf("readChunkSecure error: %v", err)
+	}
+	if vote.Suspicious != 1 {
+		t.Errorf("Suspicious = %d, want 1", vote.Suspicious)
+	}
+}
+
+func TestReadChunkSecure_Clean(t *testing.T) {
+	dev, toc, pcm := simDisc(t)
+
+	vote, _, err := readChunkSecure(dev, 150, 25, toc.LeadoutLBA, 3)
+	if err != nil {
+		t.Fatalf("readChunkSecure error: %v", err)
+	}
+	if vote.Disagreed != 0 || vote.Suspicious != 0 {
+		t.Errorf("Disagreed/Suspicious = %d/%d, want 0/0", vote.Disagreed, vote.Suspicious)
+	}
+	if !bytes.Equal(vote.Data, pcm[150*scsi.FrameSize:175*scsi.FrameSize]) {
+		t.Error("Data should match the disc")
+	}
+}
+
+// readLog records the frames a drive is asked for
+type readLog struct {
+	scsi.Drive
+	lbas []int
+	n    int
+}
+
+func (d *readLog) ReadCDFrames(lba, n int) ([]byte, error) {
+	d.lbas = append(d.lbas, lba)
+	d.n += n
+	return d.Drive.ReadCDFrames(lba, n)
+}
+
+func TestDefeatCache_ReadsPastCache(t *testing.T) {
+	const leadout = 10000
+	dev := &readLog{Drive: scsi.NewSimDrive(scsi.SimTOC([]int{150}, leadout), scsi.SimImage(leadout))}
+
+	for _, lba := range []int{150, 9000} {
+		dev.lbas, dev.n = nil, 0
+		defeatCache(dev, lba, 75, leadout)
+
+		if dev.n*scsi.FrameSize < cacheFlushSize {
+			t.Errorf("chunk at %d: read %d bytes, want at least %d", lba, dev.n*scsi.FrameSize, cacheFlushSize)
+		}
+		for _, far := range dev.lbas {
+			if far+75 > lba && far < lba+75 {
+				t.Errorf("chunk at %d: read LBA %d, which overlaps the chunk", lba, far)
+			}
+		}
+	}
+}
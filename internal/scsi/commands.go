@@ -2,10 +2,12 @@ package scsi
 
 // SCSI command opcodes
 const (
-	OpTestUnitReady = 0x00
-	OpInquiry       = 0x12
-	OpReadTOC       = 0x43
-	OpReadCD        = 0xBE
+	OpTestUnitReady  = 0x00
+	OpRequestSense   = 0x03
+	OpInquiry        = 0x12
+	OpReadSubChannel = 0x42
+	OpReadTOC        = 0x43
+	OpReadCD         = 0xBE
 )
 
 // BuildTestUnitReady creates the CDB for TEST UNIT READY command.
@@ -14,6 +16,12 @@ func BuildTestUnitReady() []byte {
 	return []byte{OpTestUnitReady, 0, 0, 0, 0, 0}
 }
 
+// BuildRequestSense creates the CDB for REQUEST SENSE command.
+// Returns 6-byte CDB requesting 18 bytes of fixed-format sense data.
+func BuildRequestSense() []byte {
+	return []byte{OpRequestSense, 0, 0, 0, SenseDataSize, 0}
+}
+
 // BuildInquiry creates the CDB for INQUIRY command.
 // Returns 6-byte CDB requesting 36 bytes of response.
 func BuildInquiry() []byte {
@@ -43,6 +51,60 @@ func BuildReadTOC() []byte {
 	}
 }
 
+// SessionInfoSize is the response length of READ TOC format 1
+const SessionInfoSize = 12
+
+// FullTOCMaxSize is the allocation length for READ TOC format 2: a 4-byte
+// header plus up to 255 11-byte descriptors
+const FullTOCMaxSize = 4 + 255*11
+
+// BuildReadTOCSessionInfo creates the CDB for READ TOC format 1
+// (multi-session information: the first track of the last session).
+// Returns 10-byte CDB.
+func BuildReadTOCSessionInfo() []byte {
+	return []byte{
+		OpReadTOC,
+		0x00, // LBA format
+		0x01, // Format: session info
+		0, 0, 0, 0,
+		0, SessionInfoSize, // Allocation length
+		0,
+	}
+}
+
+// BuildReadTOCFull creates the CDB for READ TOC format 2 (full TOC: the raw
+// Q subchannel of every session's lead-in). Addresses in the response are
+// always MSF. Returns 10-byte CDB.
+func BuildReadTOCFull() []byte {
+	return []byte{
+		OpReadTOC,
+		0x02, // MSF (required for format 2)
+		0x02, // Format: full TOC
+		0, 0, 0,
+		1,                                          // Starting session
+		FullTOCMaxSize >> 8, FullTOCMaxSize & 0xFF, // Allocation length
+		0,
+	}
+}
+
+// CDTextMaxSize is the allocation length for READ TOC format 5: a 4-byte
+// header plus up to 8 blocks of 255 18-byte packs
+const CDTextMaxSize = 4 + 8*255*18
+
+// BuildReadTOCCDText creates the CDB for READ TOC format 5 (CD-TEXT from the
+// lead-in). Returns 10-byte CDB; the response is a 4-byte header followed by
+// 18-byte packs. Drives without CD-TEXT support fail it with ILLEGAL REQUEST.
+func BuildReadTOCCDText() []byte {
+	return []byte{
+		OpReadTOC,
+		0x00,
+		0x05, // Format: CD-TEXT
+		0, 0, 0, 0,
+		CDTextMaxSize >> 8, CDTextMaxSize & 0xFF, // Allocation length
+		0,
+	}
+}
+
 // BuildReadCD creates the CDB for READ CD command (audio extraction).
 // startLBA: Starting Logical Block Address
 // numFrames: Number of 2352-byte frames to read
@@ -63,6 +125,45 @@ func BuildReadCD(startLBA, numFrames int) []byte {
 	}
 }
 
+// SubQSize is the length of formatted Q subchannel data per frame
+const SubQSize = 16
+
+// BuildReadCDSubQ creates the CDB for READ CD returning only the formatted
+// Q subchannel of each frame (no audio).
+// Returns 12-byte CDB; the response is SubQSize bytes per frame.
+func BuildReadCDSubQ(startLBA, numFrames int) []byte {
+	cdb := BuildReadCD(startLBA, numFrames)
+	cdb[9] = 0x00  // No user data
+	cdb[10] = 0x02 // Sub-channel selection: formatted Q
+	return cdb
+}
+
+// READ SUB-CHANNEL data formats
+const (
+	SubChannelMCN  = 0x02 // Media catalog number (UPC/EAN)
+	SubChannelISRC = 0x03 // Track ISRC
+)
+
+// SubChannelSize is the response length of READ SUB-CHANNEL for the MCN and
+// ISRC formats: a 4-byte header and 20 bytes of data
+const SubChannelSize = 24
+
+// BuildReadSubChannel creates the CDB for READ SUB-CHANNEL.
+// format: SubChannelMCN or SubChannelISRC; track is ignored for the MCN.
+// Returns 10-byte CDB.
+func BuildReadSubChannel(format byte, track int) []byte {
+	return []byte{
+		OpReadSubChannel,
+		0x00,
+		0x40, // SubQ: return sub-channel data
+		format,
+		0, 0,
+		byte(track),
+		0, SubChannelSize, // Allocation length
+		0,
+	}
+}
+
 // InquiryData represents parsed INQUIRY response
 type InquiryData struct {
 	DeviceType byte   // Peripheral device type (5 = CD-ROM)
@@ -49,6 +49,63 @@ func TestBuildReadTOC(t *testing.T) {
 	}
 }
 
+func TestBuildReadTOCCDText(t *testing.T) {
+	cdb := BuildReadTOCCDText()
+
+	if len(cdb) != 10 || cdb[0] != OpReadTOC {
+		t.Fatalf("CDB = % x, want 10-byte READ TOC", cdb)
+	}
+	if cdb[2] != 0x05 {
+		t.Errorf("Format = 0x%02x, want 0x05 (CD-TEXT)", cdb[2])
+	}
+	allocLen := int(cdb[7])<<8 | int(cdb[8])
+	if allocLen != CDTextMaxSize {
+		t.Errorf("Allocation length = %d, want %d", allocLen, CDTextMaxSize)
+	}
+}
+
+func TestBuildReadSubChannel(t *testing.T) {
+	cdb := BuildReadSubChannel(SubChannelISRC, 7)
+
+	if len(cdb) != 10 || cdb[0] != OpReadSubChannel {
+		t.Fatalf("CDB = % x, want 10-byte READ SUB-CHANNEL", cdb)
+	}
+	if cdb[2] != 0x40 {
+		t.Errorf("SubQ byte = 0x%02x, want 0x40", cdb[2])
+	}
+	if cdb[3] != SubChannelISRC || cdb[6] != 7 {
+		t.Errorf("format/track = 0x%02x/%d, want ISRC/7", cdb[3], cdb[6])
+	}
+	if cdb[8] != SubChannelSize {
+		t.Errorf("Allocation length = %d, want %d", cdb[8], SubChannelSize)
+	}
+}
+
+func TestBuildReadTOCSessionInfo(t *testing.T) {
+	cdb := BuildReadTOCSessionInfo()
+
+	if cdb[0] != OpReadTOC || cdb[2] != 0x01 {
+		t.Errorf("CDB = % x, want READ TOC format 1", cdb)
+	}
+	if cdb[8] != SessionInfoSize {
+		t.Errorf("Allocation length = %d, want %d", cdb[8], SessionInfoSize)
+	}
+}
+
+func TestBuildReadTOCFull(t *testing.T) {
+	cdb := BuildReadTOCFull()
+
+	if cdb[0] != OpReadTOC || cdb[2] != 0x02 {
+		t.Errorf("CDB = % x, want READ TOC format 2", cdb)
+	}
+	if cdb[1] != 0x02 {
+		t.Errorf("MSF byte = 0x%02x, want 0x02 (full TOC is MSF)", cdb[1])
+	}
+	if allocLen := int(cdb[7])<<8 | int(cdb[8]); allocLen != FullTOCMaxSize {
+		t.Errorf("Allocation length = %d, want %d", allocLen, FullTOCMaxSize)
+	}
+}
+
 func TestBuildReadCD(t *testing.T) {
 	// Test reading 1 frame at LBA 150 (typical track 1 start)
 	cdb := BuildReadCD(150, 1)
@@ -13,6 +13,8 @@ import (
 const (
 	FrameSize       = 2352 // Raw CD-DA frame size in bytes
 	FramesPerSecond = 75   // CD audio frames per second
+	SampleSize      = 4    // Bytes per stereo sample (16-bit left + right)
+	FrameSamples    = FrameSize / SampleSize
 )
 
 // Known USB CD drive IDs
@@ -180,13 +182,48 @@ func (d *Device) Close() {
 
 // SendCommand sends a SCSI command and receives response.
 // Returns (data, status, error)
+//
+// If the drive reports StatusFailed, REQUEST SENSE is issued automatically
+// and the decoded sense data is returned as a *SenseError.
 func (d *Device) SendCommand(cdb []byte, dataLen int, timeout time.Duration) ([]byte, byte, error) {
+	data, status, err := d.transfer(cdb, dataLen, timeout)
+	if err != nil || status != StatusFailed || cdb[0] == OpRequestSense {
+		return data, status, err
+	}
+
+	sense, err := d.requestSense(timeout)
+	if err != nil {
+		// No sense available - report the bare status as before
+		return data, status, nil
+	}
+	return data, status, sense.Err()
+}
+
+// requestSense fetches sense data for the previous failed command
+func (d *Device) requestSense(timeout time.Duration) (SenseData, error) {
+	data, status, err := d.transfer(BuildRequestSense(), SenseDataSize, timeout)
+	if err != nil {
+		return SenseData{}, fmt.Errorf("REQUEST SENSE: %w", err)
+	}
+	if status != StatusPassed {
+		return SenseData{}, fmt.Errorf("REQUEST SENSE failed with status %d", status)
+	}
+	return ParseSense(data), nil
+}
+
+// transfer performs one CBW/data/CSW exchange without sense handling.
+//
+// Transport failures (stalls, CSW read errors, invalid CSWs and phase errors)
+// leave the drive in an undefined state, so they trigger Bulk-Only reset
+// recovery before the error is returned. The caller can then retry.
+func (d *Device) transfer(cdb []byte, dataLen int, timeout time.Duration) ([]byte, byte, error) {
 	// Build CBW
 	direction := DirectionIn
 	if dataLen == 0 {
 		direction = DirectionOut
 	}
-	cbw := BuildCBW(d.tag, uint32(dataLen), byte(direction), cdb)
+	tag := d.tag
+	cbw := BuildCBW(tag, uint32(dataLen), byte(direction), cdb)
 	d.tag++
 
 	// Send CBW with timeout
@@ -195,10 +232,10 @@ func (d *Device) SendCommand(cdb []byte, dataLen int, timeout time.Duration) ([]
 
 	n, err := d.epOut.WriteContext(writeCtx, cbw)
 	if err != nil {
-		return nil, 0xFF, fmt.Errorf("CBW write: %w", err)
+		return nil, 0xFF, d.recoverTransport(fmt.Errorf("CBW write: %w", err))
 	}
 	if n != len(cbw) {
-		return nil, 0xFF, fmt.Errorf("CBW short write: %d/%d bytes", n, len(cbw))
+		return nil, 0xFF, d.recoverTransport(fmt.Errorf("CBW short write: %d/%d bytes", n, len(cbw)))
 	}
 
 	// Read data if expected
@@ -210,76 +247,125 @@ func (d *Device) SendCommand(cdb []byte, dataLen int, timeout time.Duration) ([]
 		data = make([]byte, dataLen)
 		n, err := d.epIn.ReadContext(readCtx, data)
 		if err != nil {
-			// Try to recover by reading CSW anyway
+			// Device may have stalled the data phase; clear it and read CSW anyway
 			data = nil
+			d.clearHalt(d.epIn.Desc.Address)
 		} else {
 			data = data[:n]
 		}
 	}
 
-	// Read CSW
-	cswCtx, cswCancel := context.WithTimeout(context.Background(), timeout)
-	defer cswCancel()
-
-	cswBuf := make([]byte, CSWSize)
-	_, err = d.epIn.ReadContext(cswCtx, cswBuf)
+	// Read CSW (retry once after clearing a stall, per BOT spec 6.7.2)
+	csw, err := d.readCSW(timeout)
 	if err != nil {
-		return data, 0xFF, fmt.Errorf("CSW read: %w", err)
+		d.clearHalt(d.epIn.Desc.Address)
+		csw, err = d.readCSW(timeout)
 	}
-
-	csw, err := ParseCSW(cswBuf)
 	if err != nil {
-		return data, 0xFF, err
+		return data, 0xFF, d.recoverTransport(err)
+	}
+
+	if err := ValidateCSW(csw, tag, uint32(dataLen)); err != nil {
+		return data, 0xFF, d.recoverTransport(err)
+	}
+
+	if csw.Status == StatusPhaseError {
+		return data, csw.Status, d.recoverTransport(ErrPhaseError)
 	}
 
 	return data, csw.Status, nil
 }
 
-// Inquiry sends INQUIRY command and returns device info
-func (d *Device) Inquiry() (*InquiryData, error) {
-	cdb := BuildInquiry()
-	data, status, err := d.SendCommand(cdb, 36, 5*time.Second)
+// readCSW reads and parses one Command Status Wrapper
+func (d *Device) readCSW(timeout time.Duration) (CSW, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	buf := make([]byte, CSWSize)
+	if _, err := d.epIn.ReadContext(ctx, buf); err != nil {
+		return CSW{}, fmt.Errorf("CSW read: %w", err)
+	}
+	return ParseCSW(buf)
+}
+
+// recoverTransport runs reset recovery after a transport error and returns the
+// original error, annotated if recovery itself failed.
+func (d *Device) recoverTransport(cause error) error {
+	if err := d.resetRecovery(); err != nil {
+		return fmt.Errorf("%w (reset recovery failed: %v)", cause, err)
+	}
+	return cause
+}
+
+// resetRecovery runs the Bulk-Only reset recovery sequence (BOT spec 5.3.4):
+// a Bulk-Only Mass Storage Reset class request, then CLEAR_FEATURE(HALT)
+// on the bulk-in and bulk-out endpoints.
+func (d *Device) resetRecovery() error {
+	_, err := d.dev.Control(
+		gousb.ControlOut|gousb.ControlClass|gousb.ControlInterface,
+		RequestBulkOnlyReset, 0, uint16(d.intf.Setting.Number), nil)
 	if err != nil {
-		return nil, err
+		return fmt.Errorf("bulk-only reset: %w", err)
 	}
-	if status != StatusPassed {
-		return nil, fmt.Errorf("INQUIRY failed with status %d", status)
+	if err := d.clearHalt(d.epIn.Desc.Address); err != nil {
+		return err
 	}
+	return d.clearHalt(d.epOut.Desc.Address)
+}
 
-	info := ParseInquiry(data)
-	return &info, nil
+// clearHalt sends CLEAR_FEATURE(ENDPOINT_HALT) to an endpoint.
+// gousb doesn't expose libusb_clear_halt, so this is a standard control request.
+func (d *Device) clearHalt(addr gousb.EndpointAddress) error {
+	_, err := d.dev.Control(
+		gousb.ControlOut|gousb.ControlEndpoint,
+		RequestClearFeature, FeatureEndpointHalt, uint16(addr), nil)
+	if err != nil {
+		return fmt.Errorf("clear halt on endpoint 0x%02x: %w", uint8(addr), err)
+	}
+	return nil
+}
+
+// Inquiry sends INQUIRY command and returns device info
+func (d *Device) Inquiry() (*InquiryData, error) {
+	return inquiry(d)
 }
 
 // TestUnitReady checks if drive is ready (disc loaded)
 func (d *Device) TestUnitReady() bool {
-	cdb := BuildTestUnitReady()
-	_, status, err := d.SendCommand(cdb, 0, 5*time.Second)
-	return err == nil && status == StatusPassed
+	return testUnitReady(d)
 }
 
 // ReadTOCRaw reads raw TOC data from CD
 func (d *Device) ReadTOCRaw() ([]byte, error) {
-	cdb := BuildReadTOC()
-	data, status, err := d.SendCommand(cdb, 1020, 10*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("READ TOC: %w", err)
-	}
-	if status != StatusPassed {
-		return nil, fmt.Errorf("READ TOC failed with status %d", status)
-	}
-	return data, nil
+	return readTOCRaw(d)
+}
+
+// ReadSessionInfo reads the multi-session information (READ TOC format 1)
+func (d *Device) ReadSessionInfo() ([]byte, error) {
+	return readSessionInfo(d)
+}
+
+// ReadFullTOC reads the full TOC of every session (READ TOC format 2)
+func (d *Device) ReadFullTOC() ([]byte, error) {
+	return readFullTOC(d)
 }
 
 // ReadCDFrames reads raw audio frames
 func (d *Device) ReadCDFrames(startLBA, numFrames int) ([]byte, error) {
-	cdb := BuildReadCD(startLBA, numFrames)
-	dataLen := numFrames * FrameSize
-	data, status, err := d.SendCommand(cdb, dataLen, 60*time.Second)
-	if err != nil {
-		return nil, fmt.Errorf("READ CD: %w", err)
-	}
-	if status != StatusPassed {
-		return nil, fmt.Errorf("READ CD failed with status %d at LBA %d", status, startLBA)
-	}
-	return data, nil
+	return readCDFrames(d, startLBA, numFrames)
+}
+
+// ReadSubQ reads the formatted Q subchannel of numFrames frames
+func (d *Device) ReadSubQ(startLBA, numFrames int) ([]byte, error) {
+	return readSubQ(d, startLBA, numFrames)
+}
+
+// ReadCDText reads the raw CD-TEXT packs from the lead-in
+func (d *Device) ReadCDText() ([]byte, error) {
+	return readCDText(d)
+}
+
+// ReadSubChannel reads the media catalog number or a track's ISRC
+func (d *Device) ReadSubChannel(format byte, track int) ([]byte, error) {
+	return readSubChannel(d, format, track)
 }
@@ -0,0 +1,153 @@
+package scsi
+
+import (
+	"fmt"
+	"time"
+)
+
+// Transport sends a SCSI command and returns the data-in phase and CSW status.
+// Device implements it over USB Bulk-Only; SimDrive implements it in memory.
+type Transport interface {
+	SendCommand(cdb []byte, dataLen int, timeout time.Duration) ([]byte, byte, error)
+}
+
+// Drive is a CD drive that cd-rip can read from.
+type Drive interface {
+	Transport
+	Inquiry() (*InquiryData, error)
+	TestUnitReady() bool
+	ReadTOCRaw() ([]byte, error)
+	ReadSessionInfo() ([]byte, error)
+	ReadFullTOC() ([]byte, error)
+	ReadCDFrames(startLBA, numFrames int) ([]byte, error)
+	ReadSubQ(startLBA, numFrames int) ([]byte, error)
+	ReadCDText() ([]byte, error)
+	ReadSubChannel(format byte, track int) ([]byte, error)
+	Close()
+}
+
+// Compile-time interface checks
+var (
+	_ Drive = (*Device)(nil)
+	_ Drive = (*SimDrive)(nil)
+	_ Drive = (*OffsetDrive)(nil)
+)
+
+// inquiry sends INQUIRY over t and parses the response
+func inquiry(t Transport) (*InquiryData, error) {
+	cdb := BuildInquiry()
+	data, status, err := t.SendCommand(cdb, 36, 5*time.Second)
+	if err != nil {
+		return nil, err
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("INQUIRY failed with status %d", status)
+	}
+
+	info := ParseInquiry(data)
+	return &info, nil
+}
+
+// testUnitReady sends TEST UNIT READY over t
+func testUnitReady(t Transport) bool {
+	cdb := BuildTestUnitReady()
+	_, status, err := t.SendCommand(cdb, 0, 5*time.Second)
+	return err == nil && status == StatusPassed
+}
+
+// readTOCRaw sends READ TOC over t and returns the raw response
+func readTOCRaw(t Transport) ([]byte, error) {
+	cdb := BuildReadTOC()
+	data, status, err := t.SendCommand(cdb, 1020, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ TOC: %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ TOC failed with status %d", status)
+	}
+	return data, nil
+}
+
+// readSessionInfo sends READ TOC format 1 over t and returns the raw response
+func readSessionInfo(t Transport) ([]byte, error) {
+	cdb := BuildReadTOCSessionInfo()
+	data, status, err := t.SendCommand(cdb, SessionInfoSize, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ TOC (session info): %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ TOC (session info) failed with status %d", status)
+	}
+	return data, nil
+}
+
+// readFullTOC sends READ TOC format 2 over t and returns the raw response.
+// Drives that don't support it fail with a *SenseError matching
+// ErrIllegalRequest.
+func readFullTOC(t Transport) ([]byte, error) {
+	cdb := BuildReadTOCFull()
+	data, status, err := t.SendCommand(cdb, FullTOCMaxSize, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ TOC (full): %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ TOC (full) failed with status %d", status)
+	}
+	return data, nil
+}
+
+// readCDFrames sends READ CD over t and returns the raw audio frames
+func readCDFrames(t Transport, startLBA, numFrames int) ([]byte, error) {
+	cdb := BuildReadCD(startLBA, numFrames)
+	dataLen := numFrames * FrameSize
+	data, status, err := t.SendCommand(cdb, dataLen, 60*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ CD: %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ CD failed with status %d at LBA %d", status, startLBA)
+	}
+	return data, nil
+}
+
+// readSubQ sends READ CD over t and returns the formatted Q subchannel of
+// each frame, SubQSize bytes apiece
+func readSubQ(t Transport, startLBA, numFrames int) ([]byte, error) {
+	cdb := BuildReadCDSubQ(startLBA, numFrames)
+	data, status, err := t.SendCommand(cdb, numFrames*SubQSize, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ CD (subchannel): %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ CD (subchannel) failed with status %d at LBA %d", status, startLBA)
+	}
+	return data, nil
+}
+
+// readCDText sends READ TOC format 5 over t and returns the raw CD-TEXT
+// response. Discs without CD-TEXT fail with a *SenseError matching
+// ErrIllegalRequest.
+func readCDText(t Transport) ([]byte, error) {
+	cdb := BuildReadTOCCDText()
+	data, status, err := t.SendCommand(cdb, CDTextMaxSize, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ TOC (CD-TEXT): %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ TOC (CD-TEXT) failed with status %d", status)
+	}
+	return data, nil
+}
+
+// readSubChannel sends READ SUB-CHANNEL over t and returns the raw response
+func readSubChannel(t Transport, format byte, track int) ([]byte, error) {
+	cdb := BuildReadSubChannel(format, track)
+	data, status, err := t.SendCommand(cdb, SubChannelSize, 10*time.Second)
+	if err != nil {
+		return nil, fmt.Errorf("READ SUB-CHANNEL: %w", err)
+	}
+	if status != StatusPassed {
+		return nil, fmt.Errorf("READ SUB-CHANNEL failed with status %d", status)
+	}
+	return data, nil
+}
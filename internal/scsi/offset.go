@@ -0,0 +1,88 @@
+package scsi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrShortRead means a drive returned fewer bytes than were asked for
+var ErrShortRead = errors.New("short read")
+
+// OffsetDrive wraps a Drive to correct its read offset: the number of
+// samples by which the audio a drive returns for an LBA is displaced from
+// where it belongs. Reads through an OffsetDrive are shifted by Offset
+// samples, so a frame's data spans two physical frames and track boundaries.
+type OffsetDrive struct {
+	Drive
+	Offset     int // Read offset correction in samples (positive reads later)
+	LeadoutLBA int // End of the program area
+}
+
+// NewOffsetDrive wraps d so that reads are corrected by offset samples.
+// leadoutLBA bounds the program area; reads that spill outside it are
+// attempted anyway and zero-filled if the drive refuses them.
+func NewOffsetDrive(d Drive, offset, leadoutLBA int) *OffsetDrive {
+	return &OffsetDrive{Drive: d, Offset: offset, LeadoutLBA: leadoutLBA}
+}
+
+// ReadCDFrames reads n frames of offset-corrected audio starting at lba
+func (d *OffsetDrive) ReadCDFrames(lba, n int) ([]byte, error) {
+	if d.Offset == 0 {
+		return d.Drive.ReadCDFrames(lba, n)
+	}
+
+	readLBA, readFrames, skip := OffsetSpan(lba, n, d.Offset)
+	data, err := d.readSpan(readLBA, readFrames)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) < skip+n*FrameSize {
+		return nil, fmt.Errorf("LBA %d: %w (%d of %d bytes)", readLBA, ErrShortRead, len(data), readFrames*FrameSize)
+	}
+	return data[skip : skip+n*FrameSize], nil
+}
+
+// readSpan reads frames that may extend into the lead-in (negative LBAs) or
+// lead-out. Many drives can't read there; the missing part becomes silence.
+func (d *OffsetDrive) readSpan(lba, n int) ([]byte, error) {
+	lo, hi := max(lba, 0), min(lba+n, d.LeadoutLBA)
+	if lo == lba && hi == lba+n {
+		return d.Drive.ReadCDFrames(lba, n)
+	}
+
+	if data, err := d.Drive.ReadCDFrames(lba, n); err == nil {
+		return data, nil
+	}
+
+	out := make([]byte, n*FrameSize)
+	if hi > lo {
+		data, err := d.Drive.ReadCDFrames(lo, hi-lo)
+		if err != nil {
+			return nil, err
+		}
+		copy(out[(lo-lba)*FrameSize:], data)
+	}
+	return out, nil
+}
+
+// OffsetSpan maps a range of frames to the physical frames that hold it
+// once shifted by offset samples.
+// This is a pure function: (lba, frames, offset) → (readLBA, readFrames, skip).
+//
+// skip is the number of bytes to drop from the start of the physical read;
+// an offset that isn't a whole number of frames needs one extra frame.
+func OffsetSpan(lba, frames, offset int) (int, int, int) {
+	start := lba*FrameSize + offset*SampleSize
+
+	readLBA := start / FrameSize
+	if start%FrameSize < 0 {
+		readLBA-- // Round toward negative infinity in the lead-in
+	}
+	skip := start - readLBA*FrameSize
+
+	readFrames := frames
+	if skip > 0 {
+		readFrames++
+	}
+	return readLBA, readFrames, skip
+}
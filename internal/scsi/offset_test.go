@@ -0,0 +1,101 @@
+package scsi
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+)
+
+func TestOffsetSpan(t *testing.T) {
+	tests := []struct {
+		name                  string
+		lba, frames, offset   int
+		wantLBA, wantN, wantS int
+	}{
+		{"no offset", 10, 5, 0, 10, 5, 0},
+		{"positive", 10, 5, 6, 10, 6, 24},
+		{"negative", 10, 5, -6, 9, 6, FrameSize - 24},
+		{"whole frame", 10, 5, FrameSamples, 11, 5, 0},
+		{"into lead-in", 0, 1, -1, -1, 2, FrameSize - 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lba, n, skip := OffsetSpan(tt.lba, tt.frames, tt.offset)
+			if lba != tt.wantLBA || n != tt.wantN || skip != tt.wantS {
+				t.Errorf("OffsetSpan() = %d, %d, %d, want %d, %d, %d",
+					lba, n, skip, tt.wantLBA, tt.wantN, tt.wantS)
+			}
+		})
+	}
+}
+
+func TestOffsetDrive_Shift(t *testing.T) {
+	pcm := SimImage(10)
+	dev := NewOffsetDrive(NewSimDrive(nil, pcm), 30, 10)
+
+	data, err := dev.ReadCDFrames(2, 3)
+	if err != nil {
+		t.Fatalf("ReadCDFrames error: %v", err)
+	}
+
+	start := 2*FrameSize + 30*SampleSize
+	if !bytes.Equal(data, pcm[start:start+3*FrameSize]) {
+		t.Error("data should start 30 samples after LBA 2")
+	}
+}
+
+func TestOffsetDrive_LeadOutZeroFilled(t *testing.T) {
+	pcm := SimImage(10)
+	dev := NewOffsetDrive(NewSimDrive(nil, pcm), 30, 10)
+
+	data, err := dev.ReadCDFrames(8, 2)
+	if err != nil {
+		t.Fatalf("ReadCDFrames error: %v", err)
+	}
+
+	// Sim image ends at the lead-out, so the last 30 samples are silence
+	split := len(data) - 30*SampleSize
+	if !bytes.Equal(data[:split], pcm[8*FrameSize+30*SampleSize:]) {
+		t.Error("program area part should match the image")
+	}
+	if !bytes.Equal(data[split:], make([]byte, 30*SampleSize)) {
+		t.Error("lead-out part should be zero-filled")
+	}
+}
+
+func TestOffsetDrive_LeadInZeroFilled(t *testing.T) {
+	pcm := SimImage(10)
+	dev := NewOffsetDrive(NewSimDrive(nil, pcm), -30, 10)
+
+	data, err := dev.ReadCDFrames(0, 1)
+	if err != nil {
+		t.Fatalf("ReadCDFrames error: %v", err)
+	}
+
+	if !bytes.Equal(data[:30*SampleSize], make([]byte, 30*SampleSize)) {
+		t.Error("lead-in part should be zero-filled")
+	}
+	if !bytes.Equal(data[30*SampleSize:], pcm[:FrameSize-30*SampleSize]) {
+		t.Error("program area part should match the image")
+	}
+}
+
+// shortDrive returns one frame less than asked for
+type shortDrive struct{ Drive }
+
+func (d shortDrive) ReadCDFrames(lba, n int) ([]byte, error) {
+	data, err := d.Drive.ReadCDFrames(lba, n)
+	if err != nil {
+		return nil, err
+	}
+	return data[:len(data)-FrameSize], nil
+}
+
+func TestOffsetDrive_ShortRead(t *testing.T) {
+	dev := NewOffsetDrive(shortDrive{NewSimDrive(nil, SimImage(10))}, 30, 10)
+
+	if _, err := dev.ReadCDFrames(2, 3); !errors.Is(err, ErrShortRead) {
+		t.Errorf("ReadCDFrames error = %v, want ErrShortRead", err)
+	}
+}
@@ -3,6 +3,7 @@ package scsi
 import (
 	"encoding/binary"
 	"errors"
+	"fmt"
 )
 
 // USB Mass Storage Bulk-Only protocol constants
@@ -26,6 +27,16 @@ const (
 	StatusPhaseError = 0x02
 )
 
+// Control requests used for Bulk-Only reset recovery
+const (
+	RequestBulkOnlyReset = 0xFF // Class request: Bulk-Only Mass Storage Reset
+	RequestClearFeature  = 0x01 // Standard request: CLEAR_FEATURE
+	FeatureEndpointHalt  = 0x00 // Feature selector: ENDPOINT_HALT
+)
+
+// ErrPhaseError is returned when the device reports a CSW phase error
+var ErrPhaseError = errors.New("CSW phase error")
+
 // CBW represents a Command Block Wrapper
 type CBW struct {
 	Tag           uint32
@@ -81,3 +92,19 @@ func ParseCSW(data []byte) (CSW, error) {
 		Status:  data[12],
 	}, nil
 }
+
+// ValidateCSW checks that a CSW is a meaningful reply to the CBW with the
+// given tag and data transfer length (BOT spec 6.3).
+// This is a pure function: (CSW, tag, dataLen) → error
+func ValidateCSW(csw CSW, tag uint32, dataLen uint32) error {
+	if csw.Tag != tag {
+		return fmt.Errorf("CSW tag mismatch: got %d, want %d", csw.Tag, tag)
+	}
+	if csw.Status > StatusPhaseError {
+		return fmt.Errorf("invalid CSW status %d", csw.Status)
+	}
+	if csw.Residue > dataLen {
+		return fmt.Errorf("CSW residue %d exceeds data length %d", csw.Residue, dataLen)
+	}
+	return nil
+}
@@ -148,3 +148,45 @@ func TestParseCSW_TooShort(t *testing.T) {
 		t.Error("ParseCSW should fail with short data")
 	}
 }
+
+func TestValidateCSW_Valid(t *testing.T) {
+	csw := CSW{Tag: 7, Residue: 0, Status: StatusPassed}
+
+	if err := ValidateCSW(csw, 7, 2352); err != nil {
+		t.Errorf("ValidateCSW error: %v", err)
+	}
+}
+
+func TestValidateCSW_ShortTransfer(t *testing.T) {
+	// Residue up to the requested length is legal (device sent less data)
+	csw := CSW{Tag: 7, Residue: 1000, Status: StatusPassed}
+
+	if err := ValidateCSW(csw, 7, 2352); err != nil {
+		t.Errorf("ValidateCSW error: %v", err)
+	}
+}
+
+func TestValidateCSW_TagMismatch(t *testing.T) {
+	// Stale CSW from an earlier command
+	csw := CSW{Tag: 6, Status: StatusPassed}
+
+	if err := ValidateCSW(csw, 7, 36); err == nil {
+		t.Error("ValidateCSW should fail on tag mismatch")
+	}
+}
+
+func TestValidateCSW_ResidueTooLarge(t *testing.T) {
+	csw := CSW{Tag: 7, Residue: 37, Status: StatusPassed}
+
+	if err := ValidateCSW(csw, 7, 36); err == nil {
+		t.Error("ValidateCSW should fail when residue exceeds data length")
+	}
+}
+
+func TestValidateCSW_InvalidStatus(t *testing.T) {
+	csw := CSW{Tag: 7, Status: 0x03}
+
+	if err := ValidateCSW(csw, 7, 0); err == nil {
+		t.Error("ValidateCSW should fail on reserved status value")
+	}
+}
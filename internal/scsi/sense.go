@@ -0,0 +1,138 @@
+package scsi
+
+import (
+	"errors"
+	"fmt"
+)
+
+// SenseDataSize is the length of fixed-format sense data requested
+const SenseDataSize = 18
+
+// Sense keys (SPC-3 table 27)
+const (
+	SenseNoSense        = 0x0
+	SenseRecoveredError = 0x1
+	SenseNotReady       = 0x2
+	SenseMediumError    = 0x3
+	SenseHardwareError  = 0x4
+	SenseIllegalRequest = 0x5
+	SenseUnitAttention  = 0x6
+	SenseAbortedCommand = 0xB
+)
+
+// Additional sense codes used to classify errors
+const (
+	ASCUnrecoveredReadError = 0x11
+	ASCLBAOutOfRange        = 0x21
+	ASCInvalidOpcode        = 0x20
+	ASCInvalidFieldInCDB    = 0x24
+	ASCMediumChanged        = 0x28
+	ASCMediumNotPresent     = 0x3A
+)
+
+// Error categories for SenseError. Match with errors.Is.
+var (
+	ErrMediumNotPresent  = errors.New("medium not present")
+	ErrUnrecoverableRead = errors.New("unrecoverable read error")
+	ErrIllegalRequest    = errors.New("illegal request")
+	ErrUnitAttention     = errors.New("unit attention (media changed or drive reset)")
+)
+
+// SenseData holds the decoded fields of a REQUEST SENSE response
+type SenseData struct {
+	Key  byte // Sense key (lower 4 bits)
+	ASC  byte // Additional sense code
+	ASCQ byte // Additional sense code qualifier
+}
+
+// SenseError is returned when a command fails and the drive reports sense data.
+// Use errors.As to get the raw sense fields, or errors.Is with the Err*
+// categories above.
+type SenseError struct {
+	Sense SenseData
+}
+
+func (e *SenseError) Error() string {
+	s := e.Sense
+	msg := fmt.Sprintf("sense key 0x%X, ASC/ASCQ 0x%02X/0x%02X", s.Key, s.ASC, s.ASCQ)
+	if cat := s.category(); cat != nil {
+		msg = cat.Error() + " (" + msg + ")"
+	}
+	return msg
+}
+
+// Unwrap returns the error category, so errors.Is(err, ErrMediumNotPresent) works
+func (e *SenseError) Unwrap() error {
+	return e.Sense.category()
+}
+
+// Err returns a *SenseError for sense data that reports a failure,
+// or nil for NO SENSE and RECOVERED ERROR.
+func (s SenseData) Err() error {
+	if s.Key == SenseNoSense || s.Key == SenseRecoveredError {
+		return nil
+	}
+	return &SenseError{Sense: s}
+}
+
+// category maps sense key/ASC to one of the Err* categories (nil if unclassified)
+func (s SenseData) category() error {
+	switch {
+	case s.ASC == ASCMediumNotPresent:
+		return ErrMediumNotPresent
+	case s.Key == SenseMediumError:
+		return ErrUnrecoverableRead
+	case s.Key == SenseIllegalRequest:
+		return ErrIllegalRequest
+	case s.Key == SenseUnitAttention:
+		return ErrUnitAttention
+	default:
+		return nil
+	}
+}
+
+// ParseSense parses a REQUEST SENSE response (fixed or descriptor format).
+// This is a pure function: bytes → SenseData.
+// Returns the zero value (NO SENSE) if the data is too short or unrecognized.
+func ParseSense(data []byte) SenseData {
+	if len(data) < 1 {
+		return SenseData{}
+	}
+
+	switch data[0] & 0x7F {
+	case 0x70, 0x71:
+		// Fixed format: key at byte 2, ASC/ASCQ at bytes 12-13
+		if len(data) < 14 {
+			return SenseData{}
+		}
+		return SenseData{
+			Key:  data[2] & 0x0F,
+			ASC:  data[12],
+			ASCQ: data[13],
+		}
+	case 0x72, 0x73:
+		// Descriptor format: key/ASC/ASCQ at bytes 1-3
+		if len(data) < 4 {
+			return SenseData{}
+		}
+		return SenseData{
+			Key:  data[1] & 0x0F,
+			ASC:  data[2],
+			ASCQ: data[3],
+		}
+	default:
+		return SenseData{}
+	}
+}
+
+// buildSenseResponse encodes SenseData as fixed-format sense bytes.
+// This is the inverse of ParseSense.
+func buildSenseResponse(s SenseData) []byte {
+	data := make([]byte, SenseDataSize)
+	data[0] = 0x70              // Current error, fixed format
+	data[2] = s.Key & 0x0F      // Sense key
+	data[7] = SenseDataSize - 8 // Additional sense length
+	data[12] = s.ASC
+	data[13] = s.ASCQ
+	return data
+}
@@ -0,0 +1,467 @@
+package scsi
+
+import (
+	"encoding/binary"
+	"fmt"
+	"os"
+	"sort"
+	"time"
+)
+
+// SimDrive is an in-memory CD drive that serves a TOC and PCM audio from a
+// disc image instead of USB hardware. It answers the same CDBs that Device
+// sends, so everything above SendCommand runs unchanged against it.
+//
+// The image holds raw 2352-byte frames with frame 0 at LBA 0, so a disc whose
+// first track starts at LBA 150 needs 150 frames of pregap at the front.
+type SimDrive struct {
+	Info      InquiryData       // Returned by INQUIRY
+	toc       []byte            // Raw READ TOC response
+	pcm       []byte            // Audio frames, frame N at LBA N
+	faults    map[int]*simFault // Injected read failures by LBA
+	unstable  map[int]int       // Injected silent misreads by LBA (count left)
+	reads     int               // READ CD commands served (varies misreads)
+	lastSense SenseData         // Returned by REQUEST SENSE
+	indexes   []simIndex        // Q subchannel index points beyond the TOC's INDEX 01s
+	cdText    []byte            // Raw READ TOC format 5 response (nil = no CD-TEXT)
+	fullTOC   []byte            // Raw READ TOC format 2 response (nil = unsupported)
+	mcn       string            // Media catalog number ("" = none)
+	isrcs     map[int]string    // ISRCs by track number
+}
+
+// simIndex marks the LBA where a track's index starts in the Q subchannel
+type simIndex struct {
+	lba, track, index int
+}
+
+// simFault is an injected failure for reads covering one LBA
+type simFault struct {
+	sense     SenseData
+	remaining int // Failures left; <= 0 means fail forever
+}
+
+// NewSimDrive creates a simulated drive from a raw READ TOC response and
+// disc image bytes.
+func NewSimDrive(toc, pcm []byte) *SimDrive {
+	return &SimDrive{
+		Info: InquiryData{
+			DeviceType: 5,
+			Vendor:     "SIM",
+			Product:    "Simulated CD",
+			Revision:   "1.0",
+		},
+		toc:      toc,
+		pcm:      pcm,
+		faults:   make(map[int]*simFault),
+		unstable: make(map[int]int),
+		isrcs:    make(map[int]string),
+	}
+}
+
+// OpenSimDrive loads a simulated drive from a disc image file and a file
+// holding the raw READ TOC response (cd-rip saves one as toc.bin).
+func OpenSimDrive(imagePath, tocPath string) (*SimDrive, error) {
+	pcm, err := os.ReadFile(imagePath)
+	if err != nil {
+		return nil, fmt.Errorf("read disc image: %w", err)
+	}
+	toc, err := os.ReadFile(tocPath)
+	if err != nil {
+		return nil, fmt.Errorf("read TOC: %w", err)
+	}
+
+	fmt.Printf("Found: simulated drive (%s)\n", imagePath)
+
+	return NewSimDrive(toc, pcm), nil
+}
+
+// SimTOC encodes tracks starting at lbas and a lead-out as a raw READ TOC
+// (LBA format) response for NewSimDrive. All tracks are audio.
+func SimTOC(lbas []int, leadout int) []byte {
+	raw := make([]byte, 4, 4+8*(len(lbas)+1))
+	binary.BigEndian.PutUint16(raw[0:2], uint16(2+8*(len(lbas)+1)))
+	raw[2] = 1
+	raw[3] = byte(len(lbas))
+
+	entry := func(num, lba int) {
+		e := make([]byte, 8)
+		e[2] = byte(num)
+		binary.BigEndian.PutUint32(e[4:8], uint32(lba))
+		raw = append(raw, e...)
+	}
+	for i, lba := range lbas {
+		entry(i+1, lba)
+	}
+	entry(0xAA, leadout)
+	return raw
+}
+
+// SimImage builds a disc image of n frames where every byte of frame i is
+// byte(i), so the frames a read returns can be told apart
+func SimImage(n int) []byte {
+	pcm := make([]byte, n*FrameSize)
+	for i := 0; i < n; i++ {
+		for j := 0; j < FrameSize; j++ {
+			pcm[i*FrameSize+j] = byte(i)
+		}
+	}
+	return pcm
+}
+
+// InjectFault makes READ CD commands covering lba fail with the given sense
+// data. The fault fires count times, or forever if count <= 0.
+func (s *SimDrive) InjectFault(lba int, sense SenseData, count int) {
+	s.faults[lba] = &simFault{sense: sense, remaining: count}
+}
+
+// InjectMisread makes READ CD return different wrong audio for lba, without
+// reporting an error, like a scratch the drive doesn't detect. The misread
+// happens count times, or on every read if count <= 0.
+func (s *SimDrive) InjectMisread(lba int, count int) {
+	s.unstable[lba] = count
+}
+
+// SetIndex makes the Q subchannel report track's index (0 = pregap,
+// 2+ = subindex) from lba on. Every INDEX 01 comes from the TOC.
+func (s *SimDrive) SetIndex(lba, track, index int) {
+	s.indexes = append(s.indexes, simIndex{lba: lba, track: track, index: index})
+}
+
+// SetFullTOC sets the raw READ TOC format 2 response, which also drives the
+// format 1 session info. Without it the simulated disc has one session and
+// the drive rejects format 2.
+func (s *SimDrive) SetFullTOC(raw []byte) {
+	s.fullTOC = raw
+}
+
+// SetCDText sets the raw READ TOC format 5 response (header and packs).
+// Without it the simulated disc has no CD-TEXT.
+func (s *SimDrive) SetCDText(raw []byte) {
+	s.cdText = raw
+}
+
+// SetMCN sets the media catalog number reported by READ SUB-CHANNEL
+func (s *SimDrive) SetMCN(mcn string) {
+	s.mcn = mcn
+}
+
+// SetISRC sets a track's ISRC reported by READ SUB-CHANNEL
+func (s *SimDrive) SetISRC(track int, isrc string) {
+	s.isrcs[track] = isrc
+}
+
+// Eject removes the simulated disc; later commands report MEDIUM NOT PRESENT.
+func (s *SimDrive) Eject() {
+	s.pcm = nil
+}
+
+// Close is a no-op for simulated drives
+func (s *SimDrive) Close() {}
+
+// SendCommand interprets a CDB against the in-memory disc.
+// Returns (data, status, error) like Device.SendCommand, including a
+// *SenseError when the command fails.
+func (s *SimDrive) SendCommand(cdb []byte, dataLen int, timeout time.Duration) ([]byte, byte, error) {
+	if len(cdb) == 0 {
+		return nil, 0xFF, fmt.Errorf("empty CDB")
+	}
+
+	data, sense := s.execute(cdb)
+	if sense.Err() != nil {
+		s.lastSense = sense
+		return nil, StatusFailed, sense.Err()
+	}
+	if cdb[0] != OpRequestSense {
+		s.lastSense = SenseData{}
+	}
+	return truncate(data, dataLen), StatusPassed, nil
+}
+
+// execute runs one command and returns its data or the sense data describing
+// why it failed.
+func (s *SimDrive) execute(cdb []byte) ([]byte, SenseData) {
+	notReady := SenseData{Key: SenseNotReady, ASC: ASCMediumNotPresent}
+
+	switch cdb[0] {
+	case OpRequestSense:
+		return buildSenseResponse(s.lastSense), SenseData{}
+
+	case OpInquiry:
+		return buildInquiryResponse(s.Info), SenseData{}
+
+	case OpTestUnitReady:
+		if s.pcm == nil {
+			return nil, notReady
+		}
+		return nil, SenseData{}
+
+	case OpReadTOC:
+		if s.pcm == nil {
+			return nil, notReady
+		}
+		switch cdb[2] & 0x0F {
+		case 0x01:
+			return s.sessionInfo(), SenseData{}
+		case 0x02:
+			if s.fullTOC == nil {
+				return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCInvalidFieldInCDB}
+			}
+			return s.fullTOC, SenseData{}
+		case 0x05:
+			if s.cdText == nil {
+				return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCInvalidFieldInCDB}
+			}
+			return s.cdText, SenseData{}
+		}
+		return s.toc, SenseData{}
+
+	case OpReadSubChannel:
+		if s.pcm == nil {
+			return nil, notReady
+		}
+		return s.readSubChannel(cdb[3], int(cdb[6]))
+
+	case OpReadCD:
+		if s.pcm == nil {
+			return nil, notReady
+		}
+		lba := int(cdb[2])<<24 | int(cdb[3])<<16 | int(cdb[4])<<8 | int(cdb[5])
+		frames := int(cdb[6])<<16 | int(cdb[7])<<8 | int(cdb[8])
+		if cdb[10] == 0x02 {
+			return s.readSubQ(lba, frames)
+		}
+		start := lba * FrameSize
+		end := (lba + frames) * FrameSize
+		if lba < 0 || end > len(s.pcm) {
+			return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCLBAOutOfRange}
+		}
+		for i := lba; i < lba+frames; i++ {
+			if f, ok := s.faults[i]; ok {
+				if f.remaining > 0 {
+					f.remaining--
+					if f.remaining == 0 {
+						delete(s.faults, i)
+					}
+				}
+				return nil, f.sense
+			}
+		}
+		data := make([]byte, end-start)
+		copy(data, s.pcm[start:end])
+		s.reads++
+		for i := lba; i < lba+frames; i++ {
+			if count, ok := s.unstable[i]; ok {
+				// Garble the first sample differently on every read
+				off := (i - lba) * FrameSize
+				data[off] ^= byte(s.reads)
+				data[off+1] ^= 0xFF
+				if count > 0 {
+					if count == 1 {
+						delete(s.unstable, i)
+					} else {
+						s.unstable[i] = count - 1
+					}
+				}
+			}
+		}
+		return data, SenseData{}
+
+	default:
+		return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCInvalidOpcode}
+	}
+}
+
+// sessionInfo builds a READ TOC format 1 response: the first track of the
+// last session, taken from the full TOC if set, otherwise the first track
+// of the format 0 TOC
+func (s *SimDrive) sessionInfo() []byte {
+	data := make([]byte, SessionInfoSize)
+	data[1] = SessionInfoSize - 2
+	data[2], data[3] = 1, 1
+	if len(s.toc) >= 12 {
+		data[5] = s.toc[5]            // ADR/control
+		data[6] = s.toc[6]            // Track number
+		copy(data[8:12], s.toc[8:12]) // LBA
+	}
+
+	// Full TOC descriptors: session, ADR/control, TNO, POINT, MSF, zero, PMSF
+	last := 0
+	for off := 4; off+11 <= len(s.fullTOC); off += 11 {
+		d := s.fullTOC[off : off+11]
+		if d[3] != 0xA0 || int(d[0]) < last {
+			continue
+		}
+		last = int(d[0])
+		track := d[8] // PMIN of A0 = first track of the session
+		for o := 4; o+11 <= len(s.fullTOC); o += 11 {
+			t := s.fullTOC[o : o+11]
+			if t[0] == d[0] && t[3] == track {
+				lba := (int(t[8])*60+int(t[9]))*75 + int(t[10]) - 150
+				data[5], data[6] = t[1], track
+				data[8], data[9], data[10], data[11] = byte(lba>>24), byte(lba>>16), byte(lba>>8), byte(lba)
+			}
+		}
+	}
+	if last > 0 {
+		data[3] = byte(last)
+	}
+	return data
+}
+
+// readSubChannel builds a READ SUB-CHANNEL response for the MCN or a
+// track's ISRC, with the valid bit set only if one was configured
+func (s *SimDrive) readSubChannel(format byte, track int) ([]byte, SenseData) {
+	data := make([]byte, SubChannelSize)
+	data[3] = SubChannelSize - 4 // Data length
+	data[4] = format
+
+	var code string
+	switch format {
+	case SubChannelMCN:
+		code = s.mcn
+	case SubChannelISRC:
+		data[6] = byte(track)
+		code = s.isrcs[track]
+	default:
+		return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCInvalidFieldInCDB}
+	}
+	if code != "" {
+		data[8] = 0x80 // MCVal / TCVal
+		copy(data[9:], code)
+	}
+	return data, SenseData{}
+}
+
+// readSubQ synthesizes formatted Q subchannel for frames from the TOC and
+// the index points added with SetIndex
+func (s *SimDrive) readSubQ(lba, frames int) ([]byte, SenseData) {
+	if lba < 0 || (lba+frames)*FrameSize > len(s.pcm) {
+		return nil, SenseData{Key: SenseIllegalRequest, ASC: ASCLBAOutOfRange}
+	}
+
+	// INDEX 01 of each track and the lead-out come from the raw TOC
+	starts := make(map[int]int) // Track → INDEX 01 LBA
+	controls := make(map[int]byte)
+	points := []simIndex{{lba: 0, track: 1, index: 0}}
+	for off := 4; off+8 <= len(s.toc); off += 8 {
+		e := s.toc[off : off+8]
+		track := int(e[2])
+		start := int(e[4])<<24 | int(e[5])<<16 | int(e[6])<<8 | int(e[7])
+		starts[track] = start
+		controls[track] = e[1] & 0x0F
+		points = append(points, simIndex{lba: start, track: track, index: 1})
+	}
+	points = append(points, s.indexes...)
+	sort.SliceStable(points, func(i, j int) bool { return points[i].lba < points[j].lba })
+
+	data := make([]byte, 0, frames*SubQSize)
+	for f := lba; f < lba+frames; f++ {
+		cur := points[0]
+		for _, p := range points {
+			if p.lba <= f {
+				cur = p
+			}
+		}
+		rel := f - starts[cur.track]
+		if rel < 0 {
+			rel = -rel // Counts down through the pregap
+		}
+		data = append(data, buildSubQ(controls[cur.track], cur.track, cur.index, rel, f+150)...)
+	}
+	return data, SenseData{}
+}
+
+// buildSubQ encodes one frame of formatted Q subchannel (mode 1, BCD)
+func buildSubQ(control byte, track, index, rel, abs int) []byte {
+	bcd := func(n int) byte {
+		if n == 0xAA {
+			return 0xAA
+		}
+		return byte(n/10<<4 | n%10)
+	}
+	msf := func(frames int) []byte {
+		return []byte{bcd(frames / 75 / 60), bcd(frames / 75 % 60), bcd(frames % 75)}
+	}
+
+	q := make([]byte, SubQSize)
+	q[0] = control<<4 | 0x01
+	q[1] = bcd(track)
+	q[2] = bcd(index)
+	copy(q[3:6], msf(rel))
+	copy(q[7:10], msf(abs))
+	return q
+}
+
+// Inquiry sends INQUIRY command and returns device info
+func (s *SimDrive) Inquiry() (*InquiryData, error) {
+	return inquiry(s)
+}
+
+// TestUnitReady checks if drive is ready (disc loaded)
+func (s *SimDrive) TestUnitReady() bool {
+	return testUnitReady(s)
+}
+
+// ReadTOCRaw reads raw TOC data from the simulated disc
+func (s *SimDrive) ReadTOCRaw() ([]byte, error) {
+	return readTOCRaw(s)
+}
+
+// ReadSessionInfo reads the simulated disc's multi-session information
+func (s *SimDrive) ReadSessionInfo() ([]byte, error) {
+	return readSessionInfo(s)
+}
+
+// ReadFullTOC reads the full TOC set with SetFullTOC
+func (s *SimDrive) ReadFullTOC() ([]byte, error) {
+	return readFullTOC(s)
+}
+
+// ReadCDFrames reads raw audio frames from the simulated disc
+func (s *SimDrive) ReadCDFrames(startLBA, numFrames int) ([]byte, error) {
+	return readCDFrames(s, startLBA, numFrames)
+}
+
+// ReadSubQ reads the synthesized Q subchannel of the simulated disc
+func (s *SimDrive) ReadSubQ(startLBA, numFrames int) ([]byte, error) {
+	return readSubQ(s, startLBA, numFrames)
+}
+
+// ReadSubChannel reads the MCN or ISRC set with SetMCN or SetISRC
+func (s *SimDrive) ReadSubChannel(format byte, track int) ([]byte, error) {
+	return readSubChannel(s, format, track)
+}
+
+// ReadCDText reads the CD-TEXT set with SetCDText
+func (s *SimDrive) ReadCDText() ([]byte, error) {
+	return readCDText(s)
+}
+
+// buildInquiryResponse encodes InquiryData as a 36-byte INQUIRY response.
+// This is the inverse of ParseInquiry.
+func buildInquiryResponse(info InquiryData) []byte {
+	data := make([]byte, 36)
+	data[0] = info.DeviceType & 0x1F
+	copy(data[8:16], padString(info.Vendor, 8))
+	copy(data[16:32], padString(info.Product, 16))
+	copy(data[32:36], padString(info.Revision, 4))
+	return data
+}
+
+// padString right-pads s with spaces to n bytes (truncating if longer)
+func padString(s string, n int) []byte {
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = ' '
+	}
+	copy(b, s)
+	return b
+}
+
+// truncate limits data to the allocation length like a real drive would
+func truncate(data []byte, n int) []byte {
+	if len(data) > n {
+		return data[:n]
+	}
+	return data
+}
@@ -0,0 +1,216 @@
+package scsi
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSimDrive_Inquiry(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(1))
+
+	info, err := dev.Inquiry()
+	if err != nil {
+		t.Fatalf("Inquiry error: %v", err)
+	}
+	if info.DeviceType != 5 {
+		t.Errorf("DeviceType = %d, want 5", info.DeviceType)
+	}
+	if info.Vendor != "SIM" {
+		t.Errorf("Vendor = %q, want %q", info.Vendor, "SIM")
+	}
+	if info.Product != "Simulated CD" {
+		t.Errorf("Product = %q, want %q", info.Product, "Simulated CD")
+	}
+}
+
+func TestSimDrive_TestUnitReady(t *testing.T) {
+	if !NewSimDrive(nil, SimImage(1)).TestUnitReady() {
+		t.Error("TestUnitReady() = false with disc loaded")
+	}
+	if NewSimDrive(nil, nil).TestUnitReady() {
+		t.Error("TestUnitReady() = true with no disc")
+	}
+}
+
+func TestSimDrive_ReadTOCRaw(t *testing.T) {
+	toc := []byte{0x00, 0x0A, 0x01, 0x01, 0, 0, 0x01, 0, 0, 0, 0, 0x96}
+	dev := NewSimDrive(toc, SimImage(1))
+
+	got, err := dev.ReadTOCRaw()
+	if err != nil {
+		t.Fatalf("ReadTOCRaw error: %v", err)
+	}
+	if !bytes.Equal(got, toc) {
+		t.Errorf("ReadTOCRaw() = %v, want %v", got, toc)
+	}
+}
+
+func TestSimDrive_ReadCDFrames(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+
+	data, err := dev.ReadCDFrames(3, 2)
+	if err != nil {
+		t.Fatalf("ReadCDFrames error: %v", err)
+	}
+	if len(data) != 2*FrameSize {
+		t.Fatalf("len(data) = %d, want %d", len(data), 2*FrameSize)
+	}
+	if data[0] != 3 || data[FrameSize] != 4 {
+		t.Errorf("frames = %d,%d, want 3,4", data[0], data[FrameSize])
+	}
+}
+
+func TestSimDrive_ReadCDFrames_PastEnd(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+
+	_, err := dev.ReadCDFrames(9, 2)
+	if err == nil {
+		t.Error("ReadCDFrames should fail past end of image")
+	}
+}
+
+func TestOpenSimDrive(t *testing.T) {
+	dir := t.TempDir()
+	imagePath := filepath.Join(dir, "disc.bin")
+	tocPath := filepath.Join(dir, "toc.bin")
+	os.WriteFile(imagePath, SimImage(2), 0644)
+	os.WriteFile(tocPath, []byte{0x00, 0x02, 0x01, 0x01}, 0644)
+
+	dev, err := OpenSimDrive(imagePath, tocPath)
+	if err != nil {
+		t.Fatalf("OpenSimDrive error: %v", err)
+	}
+	defer dev.Close()
+
+	data, err := dev.ReadCDFrames(1, 1)
+	if err != nil {
+		t.Fatalf("ReadCDFrames error: %v", err)
+	}
+	if data[0] != 1 {
+		t.Errorf("frame byte = %d, want 1", data[0])
+	}
+}
+
+func TestOpenSimDrive_NotFound(t *testing.T) {
+	_, err := OpenSimDrive("/nonexistent/disc.bin", "/nonexistent/toc.bin")
+	if err == nil {
+		t.Error("OpenSimDrive should fail for missing image")
+	}
+}
+
+func TestSimDrive_InjectFault(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+	dev.InjectFault(4, SenseData{Key: SenseMediumError, ASC: ASCUnrecoveredReadError}, 1)
+
+	_, err := dev.ReadCDFrames(3, 2)
+	if !errors.Is(err, ErrUnrecoverableRead) {
+		t.Fatalf("first read error = %v, want ErrUnrecoverableRead", err)
+	}
+
+	// Fault fired once, so the retry succeeds
+	if _, err := dev.ReadCDFrames(3, 2); err != nil {
+		t.Errorf("retry error = %v, want nil", err)
+	}
+}
+
+func TestSimDrive_Eject(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+	dev.Eject()
+
+	_, err := dev.ReadCDFrames(0, 1)
+	if !errors.Is(err, ErrMediumNotPresent) {
+		t.Errorf("read after eject error = %v, want ErrMediumNotPresent", err)
+	}
+}
+
+func TestSimDrive_RequestSense(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+	dev.ReadCDFrames(20, 1) // Past end of disc
+
+	data, status, err := dev.SendCommand(BuildRequestSense(), SenseDataSize, 0)
+	if err != nil || status != StatusPassed {
+		t.Fatalf("REQUEST SENSE = status %d, err %v", status, err)
+	}
+	sense := ParseSense(data)
+	if sense.Key != SenseIllegalRequest || sense.ASC != ASCLBAOutOfRange {
+		t.Errorf("sense = %+v, want ILLEGAL REQUEST / LBA out of range", sense)
+	}
+}
+
+func TestSimDrive_ReadSubQ(t *testing.T) {
+	toc := []byte{
+		0x00, 0x1A, 0x01, 0x02,
+		0, 0x00, 0x01, 0, 0, 0, 0, 0, // Track 1 at LBA 0
+		0, 0x00, 0x02, 0, 0, 0, 0, 10, // Track 2 at LBA 10
+		0, 0x00, 0xAA, 0, 0, 0, 0, 20, // Lead-out at LBA 20
+	}
+	dev := NewSimDrive(toc, SimImage(20))
+	dev.SetIndex(7, 2, 0)
+
+	data, err := dev.ReadSubQ(6, 5)
+	if err != nil {
+		t.Fatalf("ReadSubQ error: %v", err)
+	}
+	if len(data) != 5*SubQSize {
+		t.Fatalf("len(data) = %d, want %d", len(data), 5*SubQSize)
+	}
+
+	// LBA 6: track 1 index 1; 7-9: track 2 pregap counting down; 10: track 2 index 1
+	want := [][3]byte{{0x01, 0x01, 6}, {0x02, 0x00, 3}, {0x02, 0x00, 2}, {0x02, 0x00, 1}, {0x02, 0x01, 0}}
+	for i, w := range want {
+		q := data[i*SubQSize:]
+		if q[0]&0x0F != 1 || q[1] != w[0] || q[2] != w[1] {
+			t.Errorf("LBA %d: track/index = %02x/%02x, want %02x/%02x", 6+i, q[1], q[2], w[0], w[1])
+		}
+		if q[5] != w[2] {
+			t.Errorf("LBA %d: relative frame = %d, want %d", 6+i, q[5], w[2])
+		}
+	}
+}
+
+func TestSimDrive_ReadCDText(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+
+	_, err := dev.ReadCDText()
+	if !errors.Is(err, ErrIllegalRequest) {
+		t.Errorf("ReadCDText without CD-TEXT error = %v, want ErrIllegalRequest", err)
+	}
+
+	raw := []byte{0x00, 0x14, 0, 0, 0x80, 0, 0, 0, 'A', 'l', 'b', 'u', 'm', 0, 0, 0, 0, 0, 0, 0, 0, 0}
+	dev.SetCDText(raw)
+	data, err := dev.ReadCDText()
+	if err != nil {
+		t.Fatalf("ReadCDText error: %v", err)
+	}
+	if !bytes.Equal(data, raw) {
+		t.Errorf("ReadCDText = % x, want % x", data, raw)
+	}
+}
+
+func TestSimDrive_ReadSubChannel(t *testing.T) {
+	dev := NewSimDrive(nil, SimImage(10))
+	dev.SetMCN("0724384260729")
+	dev.SetISRC(1, "USEE10001993")
+
+	data, err := dev.ReadSubChannel(SubChannelMCN, 0)
+	if err != nil {
+		t.Fatalf("ReadSubChannel(MCN) error: %v", err)
+	}
+	if data[8]&0x80 == 0 || string(data[9:22]) != "0724384260729" {
+		t.Errorf("MCN response = % x", data)
+	}
+
+	data, _ = dev.ReadSubChannel(SubChannelISRC, 1)
+	if data[6] != 1 || data[8]&0x80 == 0 || string(data[9:21]) != "USEE10001993" {
+		t.Errorf("ISRC response = % x", data)
+	}
+
+	// A track without an ISRC reports TCVal clear
+	data, _ = dev.ReadSubChannel(SubChannelISRC, 2)
+	if data[8]&0x80 != 0 {
+		t.Error("track 2 should have no valid ISRC")
+	}
+}
@@ -6,6 +6,9 @@ pkgs.mkShell {
     libusb1
     pkg-config  # for gousb CGO
     lame
+    flac
+    opus-tools
+    vorbis-tools
   ];
 
   shellHook = ''